@@ -0,0 +1,112 @@
+package snitch
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// AsRole copies Snitcher with AWS clients assuming an IAM Role, like
+// "arn:aws:iam::123456789012:role/snitch", of another account.
+func (sn *Snitcher) AsRole(role string) *Snitcher {
+	assumed := *sn
+	assumed.Role = aws.String(role)
+	assumed.Roles = nil
+	assumed.ApplicationAutoScaling = nil
+	assumed.CloudWatch = nil
+	assumed.DynamoDB = nil
+	assumed.EC2 = nil
+	assumed.ECS = nil
+	assumed.EventBridge = nil
+	assumed.FIS = nil
+	assumed.Organizations = nil
+	assumed.S3 = nil
+	assumed.SecretsManager = nil
+	assumed.SNS = nil
+	assumed.SQS = nil
+	assumed.OrganizationRole = nil
+	if stream := sn.stream; stream != nil {
+		assumed.stream = func(metricData []*cloudwatch.MetricDatum) {
+			stream(withAccount(metricData, role))
+		}
+	}
+	return assumed.WithAWS()
+}
+
+// MeasureAccounts measures every account of Roles at once, adding an
+// "AccountId" dimension to their metrics so they can be published centrally.
+func (sn *Snitcher) MeasureAccounts() (metricData []*cloudwatch.MetricDatum) {
+	com := make(chan []*cloudwatch.MetricDatum, len(sn.Roles))
+	for _, role := range sn.Roles {
+		go func(role string) {
+			com <- withAccount(sn.AsRole(role).measure(), role)
+		}(role)
+	}
+	for range sn.Roles {
+		metricData = append(metricData, <-com...)
+	}
+	sortMetricData(metricData)
+	return
+}
+
+// RunAccounts runs in every account of Roles at once, each publishing into
+// its own account. Metrics sent to Sinks get an "AccountId" dimension, like
+// MeasureAccounts', so sinks shared by accounts can tell them apart. It
+// returns why publishing failed in any account, if it did.
+func (sn *Snitcher) RunAccounts() (err error) {
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	for _, role := range sn.Roles {
+		wg.Add(1)
+		go func(role string) {
+			defer wg.Done()
+			assumed := sn.AsRole(role)
+			assumed.sinkRole = role
+			if runErr := assumed.run(); runErr != nil {
+				mutex.Lock()
+				defer mutex.Unlock()
+				err = fmt.Errorf("%s: %s", accountID(role), runErr)
+			}
+		}(role)
+	}
+	wg.Wait()
+	return
+}
+
+// accountID figures out the account of an IAM Role ARN.
+func accountID(role string) string {
+	parts := strings.Split(role, ":")
+	if len(parts) < 5 {
+		defaultLogger.Errorf("Failed to find account ID in %q", role)
+		return "unknown"
+	}
+	return parts[4]
+}
+
+// withAccount adds an "AccountId" dimension, of role's account, to metric
+// data.
+func withAccount(metricData []*cloudwatch.MetricDatum, role string) []*cloudwatch.MetricDatum {
+	account := accountID(role)
+	for _, datum := range metricData {
+		datum.Dimensions = append(datum.Dimensions, &cloudwatch.Dimension{
+			Name:  aws.String("AccountId"),
+			Value: aws.String(account),
+		})
+	}
+	return metricData
+}
+
+// withAccountCopied is withAccount of copies of metricData, leaving metricData
+// as it is.
+func withAccountCopied(metricData []*cloudwatch.MetricDatum, role string) []*cloudwatch.MetricDatum {
+	copied := make([]*cloudwatch.MetricDatum, len(metricData))
+	for i, datum := range metricData {
+		datumCopy := *datum
+		datumCopy.Dimensions = append([]*cloudwatch.Dimension(nil), datum.Dimensions...)
+		copied[i] = &datumCopy
+	}
+	return withAccount(copied, role)
+}
@@ -0,0 +1,64 @@
+package snitch
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+func TestSnitcher_AsRole(t *testing.T) {
+	role := "arn:aws:iam::123456789012:role/snitch"
+	sn := &Snitcher{
+		ECS:        NewFakeECS(t),
+		Region:     aws.String("ca-central-1"),
+		Roles:      []string{role},
+		ExternalID: aws.String("shh"),
+	}
+	assumed := sn.AsRole(role)
+	if assumed == sn || aws.StringValue(assumed.Role) != role || len(assumed.Roles) != 0 {
+		t.Errorf("expected a copy of Snitcher assuming only %q", role)
+	}
+	if assumed.ECS == sn.ECS || assumed.ECS == nil {
+		t.Error("expected ECS client of assumed role")
+	}
+}
+
+func Test_accountID(t *testing.T) {
+	for role, expected := range map[string]string{
+		"arn:aws:iam::123456789012:role/snitch": "123456789012",
+		"snitch":                                "unknown",
+	} {
+		if actual := accountID(role); actual != expected {
+			t.Errorf("expected %q account of %q but got %q", expected, role, actual)
+		}
+	}
+}
+
+func Test_withAccount(t *testing.T) {
+	metricData := []*cloudwatch.MetricDatum{
+		{MetricName: aws.String("RemainingSchedulable")},
+	}
+	dimensions := withAccount(metricData, "arn:aws:iam::123456789012:role/snitch")[0].Dimensions
+	if len(dimensions) != 1 || *dimensions[0].Name != "AccountId" || *dimensions[0].Value != "123456789012" {
+		t.Errorf("expected AccountId dimension but got %v", dimensions)
+	}
+}
+
+func TestSnitcher_SendInAccount(t *testing.T) {
+	fake := &FakeSink{}
+	sn := &Snitcher{Sinks: []*SinkConfig{{Type: "fake", Sink: fake}}, sinkRole: "arn:aws:iam::123456789012:role/snitch"}
+	cr := NewClusterResources(aws.String("fake-cluster"))
+	cr.Remaining["fake.large"] = 2
+	metricData := cr.ToMetricData()
+	if failed := sn.Send(metricData); failed != 0 {
+		t.Errorf("expected no failed sinks but got %d", failed)
+	}
+	report := Report(fake.sent)
+	if len(report) != 1 || report[0].Key() != "123456789012/fake-cluster" {
+		t.Errorf("expected report of fake-cluster in 123456789012 but got %v", report)
+	}
+	if dimensions := metricData[0].Dimensions; len(dimensions) != 2 {
+		t.Errorf("expected metrics published without AccountId but got %v", dimensions)
+	}
+}
@@ -0,0 +1,220 @@
+package snitch
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// Defaults of AlarmConfig.
+const (
+	defaultAlarmPrefix            = "snitch-remaining-"
+	defaultAlarmPeriod            = 300
+	defaultAlarmEvaluationPeriods = 3
+)
+
+// maxDeleteAlarms is how many alarms DeleteAlarms takes at once.
+const maxDeleteAlarms = 100
+
+// AlarmConfig configures CloudWatch alarms SyncAlarms keeps, one per cluster
+// measured, on its RemainingSchedulable summed across instance types:
+//
+//	{"thresholds": {"*": 5, "prod-*": 20}, "period": 300,
+//	 "evaluationPeriods": 3, "actions": ["arn:aws:sns:us-east-1:123456789012:capacity"]}
+type AlarmConfig struct {
+	// Thresholds of RemainingSchedulable, by cluster name, glob or /regexp/,
+	// like SinkConfig's, below which a cluster's alarm fires. Clusters
+	// matching none aren't alarmed.
+	Thresholds map[string]float64 `json:"thresholds"`
+	// Seconds over which RemainingSchedulable is summed, and how many periods
+	// in a row it's below threshold to fire. Defaults are 300 and 3.
+	Period            int `json:"period,omitempty"`
+	EvaluationPeriods int `json:"evaluationPeriods,omitempty"`
+	// SNS topic ARNs notified when alarms fire and when they recover.
+	Actions []string `json:"actions,omitempty"`
+	// Prefix of alarms' names, followed by their cluster's. Alarms with it
+	// whose clusters are gone are deleted. Default is "snitch-remaining-".
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// AlarmSync is what SyncAlarms did, by alarm name.
+type AlarmSync struct {
+	Created []string `json:"created,omitempty"`
+	Updated []string `json:"updated,omitempty"`
+	Deleted []string `json:"deleted,omitempty"`
+}
+
+func (config *AlarmConfig) prefix() string {
+	if config.Prefix == "" {
+		return defaultAlarmPrefix
+	}
+	return config.Prefix
+}
+
+func (config *AlarmConfig) period() int64 {
+	if config.Period <= 0 {
+		return defaultAlarmPeriod
+	}
+	return int64(config.Period)
+}
+
+func (config *AlarmConfig) evaluationPeriods() int64 {
+	if config.EvaluationPeriods <= 0 {
+		return defaultAlarmEvaluationPeriods
+	}
+	return int64(config.EvaluationPeriods)
+}
+
+// alarmQuery is a Metrics Insights query summing metricName, as published, of
+// datum's cluster, in namespace, across metrics of datum's dimensions, besides
+// InstanceType if published as StatisticSets.
+func (sn *Snitcher) alarmQuery(namespace, metricName string, datum *cloudwatch.MetricDatum) string {
+	dimensions := datum.Dimensions
+	if aws.BoolValue(sn.StatisticSets) {
+		dimensions, _ = withoutInstanceType(dimensions)
+	}
+	var names []string
+	cluster := ""
+	for _, dimension := range dimensions {
+		names = append(names, quote(aws.StringValue(dimension.Name)))
+		if aws.StringValue(dimension.Name) == "ClusterName" {
+			cluster = aws.StringValue(dimension.Value)
+		}
+	}
+	return fmt.Sprintf(`SELECT SUM(%s) FROM SCHEMA(%s, %s) WHERE ClusterName = '%s'`,
+		quote(metricName), quote(namespace), strings.Join(names, ", "), strings.Replace(cluster, "'", "''", -1))
+}
+
+// SyncAlarms measures clusters, like Measure, and creates or updates an alarm,
+// configured by Alarms, of each cluster with a threshold, then deletes alarms
+// named with Alarms' prefix of clusters no longer listed, or left out by
+// Include and Exclude. Alarms of clusters listed but without capacity to
+// measure are kept as they are. Alarms aren't deleted if measuring anything
+// failed, lest clusters merely unlisted lose theirs, nor when measuring just
+// Cluster.
+//
+// Alarms are in Snitcher's own region and account, of metrics published to
+// Namespace, even if it's a template, like "ECS/{cluster}".
+//
+// Requires IAM permissions "cloudwatch:DescribeAlarms",
+// "cloudwatch:PutMetricAlarm" and "cloudwatch:DeleteAlarms", besides
+// measuring's.
+func (sn *Snitcher) SyncAlarms() (*AlarmSync, error) {
+	config := sn.Alarms
+	if config == nil || len(config.Thresholds) == 0 {
+		return nil, errors.New("no alarm thresholds")
+	}
+	if aws.StringValue(sn.Namespace) == "" {
+		return nil, errors.New("no namespace of metrics to alarm on")
+	}
+	if sn.Summary == nil {
+		sn.Summary = &Summary{}
+		defer func() { sn.Summary = nil }()
+	}
+	existing := map[string]bool{}
+	input := &cloudwatch.DescribeAlarmsInput{AlarmNamePrefix: aws.String(config.prefix())}
+	err := sn.CloudWatch.DescribeAlarmsPages(input, func(output *cloudwatch.DescribeAlarmsOutput, lastPage bool) bool {
+		for _, alarm := range output.MetricAlarms {
+			existing[aws.StringValue(alarm.AlarmName)] = true
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe alarms: %s", err)
+	}
+	// Clusters are gone only if they're no longer listed, not if they merely
+	// had no capacity to measure, like scaled to zero instances.
+	var listed []string
+	for cluster := range sn.Clusters() {
+		listed = append(listed, aws.StringValue(cluster))
+	}
+	measured := map[string]*cloudwatch.MetricDatum{}
+	for _, datum := range sn.Measure() {
+		if aws.StringValue(datum.MetricName) != "RemainingSchedulable" {
+			continue
+		}
+		for _, dimension := range datum.Dimensions {
+			if cluster := aws.StringValue(dimension.Value); aws.StringValue(dimension.Name) == "ClusterName" && measured[cluster] == nil {
+				measured[cluster] = datum
+			}
+		}
+	}
+	metricName := sn.publishedName("RemainingSchedulable")
+	patterns := thresholdPatterns(config.Thresholds)
+	synced := &AlarmSync{}
+	wanted := map[string]bool{}
+	for _, cluster := range listed {
+		name := config.prefix() + cluster
+		threshold, ok := thresholdOf(config.Thresholds, patterns, cluster)
+		if !ok {
+			continue
+		}
+		wanted[name] = true
+		datum := measured[cluster]
+		if datum == nil {
+			sn.logger().Infof("Not putting alarm %q of %q, which has no RemainingSchedulable to alarm on", name, cluster)
+			continue
+		}
+		namespace, ok := resolveNamespace(aws.StringValue(sn.Namespace), datum)
+		if !ok {
+			sn.logger().Warnf("Not alarming on %q lacking dimensions of namespace %q", cluster, aws.StringValue(sn.Namespace))
+			continue
+		}
+		alarm := &cloudwatch.PutMetricAlarmInput{
+			AlarmName:          aws.String(name),
+			AlarmDescription:   aws.String(fmt.Sprintf("%s of ECS Cluster %q below %g, kept by snitch", metricName, cluster, threshold)),
+			ComparisonOperator: aws.String(cloudwatch.ComparisonOperatorLessThanThreshold),
+			Threshold:          aws.Float64(threshold),
+			EvaluationPeriods:  aws.Int64(config.evaluationPeriods()),
+			Metrics: []*cloudwatch.MetricDataQuery{{
+				Id:         aws.String("headroom"),
+				Expression: aws.String(sn.alarmQuery(namespace, metricName, datum)),
+				Period:     aws.Int64(config.period()),
+				ReturnData: aws.Bool(true),
+			}},
+			AlarmActions: aws.StringSlice(config.Actions),
+			OKActions:    aws.StringSlice(config.Actions),
+		}
+		if _, putErr := sn.CloudWatch.PutMetricAlarm(alarm); putErr != nil {
+			sn.logger().Errorf("Failed to put alarm %q: %s", name, putErr)
+			sn.Summary.fail("Failed to put alarm %q: %s", name, putErr)
+			err = putErr
+		} else if existing[name] {
+			synced.Updated = append(synced.Updated, name)
+		} else {
+			synced.Created = append(synced.Created, name)
+		}
+	}
+	sort.Strings(synced.Created)
+	sort.Strings(synced.Updated)
+	if sn.Summary.Err() != nil || len(sn.Summary.Skipped) > 0 {
+		sn.logger().Warnf("Not deleting alarms since measuring, or alarming on, some clusters failed")
+		return synced, err
+	}
+	var stale []string
+	for name := range existing {
+		// Measuring one Cluster says nothing of others'.
+		if !wanted[name] && aws.StringValue(sn.Cluster) == "" {
+			stale = append(stale, name)
+		}
+	}
+	sort.Strings(stale)
+	for start := 0; start < len(stale); start += maxDeleteAlarms {
+		end := start + maxDeleteAlarms
+		if end > len(stale) {
+			end = len(stale)
+		}
+		deleting := stale[start:end]
+		if _, deleteErr := sn.CloudWatch.DeleteAlarms(&cloudwatch.DeleteAlarmsInput{AlarmNames: aws.StringSlice(deleting)}); deleteErr != nil {
+			sn.logger().Errorf("Failed to delete %d alarms: %s", len(deleting), deleteErr)
+			err = deleteErr
+		} else {
+			synced.Deleted = append(synced.Deleted, deleting...)
+		}
+	}
+	return synced, err
+}
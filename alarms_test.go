@@ -0,0 +1,149 @@
+package snitch
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+// AlarmingCloudWatch keeps alarms by name.
+type AlarmingCloudWatch struct {
+	FakeCloudWatch
+	alarms  map[string]*cloudwatch.PutMetricAlarmInput
+	deleted []string
+}
+
+func (fake *AlarmingCloudWatch) DescribeAlarmsPages(input *cloudwatch.DescribeAlarmsInput, fn func(*cloudwatch.DescribeAlarmsOutput, bool) bool) error {
+	output := &cloudwatch.DescribeAlarmsOutput{}
+	for name := range fake.alarms {
+		if strings.HasPrefix(name, aws.StringValue(input.AlarmNamePrefix)) {
+			output.MetricAlarms = append(output.MetricAlarms, &cloudwatch.MetricAlarm{AlarmName: aws.String(name)})
+		}
+	}
+	fn(output, true)
+	return nil
+}
+
+func (fake *AlarmingCloudWatch) PutMetricAlarm(input *cloudwatch.PutMetricAlarmInput) (*cloudwatch.PutMetricAlarmOutput, error) {
+	if fake.errorToReturn != nil {
+		return nil, fake.errorToReturn
+	}
+	fake.alarms[aws.StringValue(input.AlarmName)] = input
+	return &cloudwatch.PutMetricAlarmOutput{}, nil
+}
+
+func (fake *AlarmingCloudWatch) DeleteAlarms(input *cloudwatch.DeleteAlarmsInput) (*cloudwatch.DeleteAlarmsOutput, error) {
+	for _, name := range aws.StringValueSlice(input.AlarmNames) {
+		delete(fake.alarms, name)
+		fake.deleted = append(fake.deleted, name)
+	}
+	return &cloudwatch.DeleteAlarmsOutput{}, nil
+}
+
+// IdleECS has no Container Instances in its idle cluster.
+type IdleECS struct {
+	*FakeECS
+	idle string
+}
+
+func (fake *IdleECS) ListContainerInstances(input *ecs.ListContainerInstancesInput) (*ecs.ListContainerInstancesOutput, error) {
+	if aws.StringValue(input.Cluster) == fake.idle {
+		return &ecs.ListContainerInstancesOutput{}, nil
+	}
+	return fake.FakeECS.ListContainerInstances(input)
+}
+
+func (fake *IdleECS) DescribeContainerInstances(input *ecs.DescribeContainerInstancesInput) (*ecs.DescribeContainerInstancesOutput, error) {
+	if aws.StringValue(input.Cluster) == fake.idle {
+		return &ecs.DescribeContainerInstancesOutput{}, nil
+	}
+	return fake.FakeECS.DescribeContainerInstances(input)
+}
+
+func TestSnitcher_SyncAlarms(t *testing.T) {
+	fake := NewFakeECS(t)
+	fake.checkCluster = false
+	cloudWatch := &AlarmingCloudWatch{alarms: map[string]*cloudwatch.PutMetricAlarmInput{
+		"snitch-remaining-fake-ecs-cluster":      {},
+		"snitch-remaining-deleted-cluster":       {},
+		"snitch-remaining-who-even-uses-fargate": {},
+		"someone-elses-alarm":                    {},
+	}}
+	sn := &Snitcher{
+		ECS:        &IdleECS{FakeECS: fake, idle: "who-even-uses-fargate"},
+		CloudWatch: cloudWatch,
+		Namespace:  aws.String("ECS/Snitch"),
+		Alarms: &AlarmConfig{
+			Thresholds: map[string]float64{"fake-ecs-cluster": 5, "another-*": 20, "who-even-*": 1},
+			Actions:    []string{"arn:aws:sns:us-east-1:123456789012:capacity"},
+		},
+	}
+	synced, err := sn.SyncAlarms()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &AlarmSync{
+		Created: []string{"snitch-remaining-another-fake-ecs-cluster"},
+		Updated: []string{"snitch-remaining-fake-ecs-cluster"},
+		Deleted: []string{"snitch-remaining-deleted-cluster"},
+	}
+	if !reflect.DeepEqual(synced, expected) {
+		t.Errorf("expected %+v but got %+v", expected, synced)
+	}
+	if _, ok := cloudWatch.alarms["someone-elses-alarm"]; !ok {
+		t.Error("expected alarms without the prefix left alone")
+	}
+	if _, ok := cloudWatch.alarms["snitch-remaining-who-even-uses-fargate"]; !ok {
+		t.Error("expected alarm of cluster without capacity kept")
+	}
+	alarm := cloudWatch.alarms["snitch-remaining-another-fake-ecs-cluster"]
+	if aws.Float64Value(alarm.Threshold) != 20 || aws.Int64Value(alarm.EvaluationPeriods) != defaultAlarmEvaluationPeriods {
+		t.Errorf("expected threshold 20 for %d periods but got %s", defaultAlarmEvaluationPeriods, alarm)
+	}
+	query := `SELECT SUM(RemainingSchedulable) FROM SCHEMA("ECS/Snitch", ClusterName, InstanceType) WHERE ClusterName = 'another-fake-ecs-cluster'`
+	if actual := aws.StringValue(alarm.Metrics[0].Expression); actual != query {
+		t.Errorf("expected query %q but got %q", query, actual)
+	}
+	if actions := aws.StringValueSlice(alarm.AlarmActions); !reflect.DeepEqual(actions, sn.Alarms.Actions) {
+		t.Errorf("expected actions %v but got %v", sn.Alarms.Actions, actions)
+	}
+}
+
+func TestSnitcher_SyncAlarmsFailed(t *testing.T) {
+	fake := NewFakeECS(t)
+	fake.checkCluster = false
+	cloudWatch := &AlarmingCloudWatch{alarms: map[string]*cloudwatch.PutMetricAlarmInput{
+		"snitch-remaining-deleted-cluster": {},
+	}}
+	cloudWatch.errorToReturn = errors.New("CloudWatch is down")
+	sn := &Snitcher{ECS: fake, CloudWatch: cloudWatch, Namespace: aws.String("ECS/Snitch"), Alarms: &AlarmConfig{Thresholds: map[string]float64{"*": 5}}}
+	if _, err := sn.SyncAlarms(); err == nil {
+		t.Error("expected failing to put alarms to fail")
+	}
+	if len(cloudWatch.deleted) != 0 {
+		t.Errorf("expected no alarms deleted after failing but got %v", cloudWatch.deleted)
+	}
+	if _, err := (&Snitcher{Namespace: aws.String("ECS/Snitch")}).SyncAlarms(); err == nil {
+		t.Error("expected syncing without thresholds to fail")
+	}
+}
+
+func TestSnitcher_SyncAlarmsCluster(t *testing.T) {
+	fake := NewFakeECS(t)
+	cloudWatch := &AlarmingCloudWatch{alarms: map[string]*cloudwatch.PutMetricAlarmInput{
+		"snitch-remaining-another-fake-ecs-cluster": {},
+	}}
+	sn := &Snitcher{ECS: fake, CloudWatch: cloudWatch, Cluster: fake.expectedCluster, Namespace: aws.String("ECS/Snitch"), Alarms: &AlarmConfig{Thresholds: map[string]float64{"*": 5}}}
+	synced, err := sn.SyncAlarms()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(synced.Created) != 1 || len(synced.Deleted) != 0 {
+		t.Errorf("expected only fake-ecs-cluster's alarm synced but got %+v", synced)
+	}
+}
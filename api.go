@@ -0,0 +1,128 @@
+package snitch
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// API serves the latest ClusterReports it's sent, as a Sink, in JSON:
+//
+//	GET /clusters       every cluster's report
+//	GET /clusters/{key} one cluster's, by its Key, like "my-cluster" or, when
+//	                    measuring accounts or regions, "123456789012/us-east-1/my-cluster"
+//
+// Package snitchgrpc serves them over gRPC, too. Run by RunEvery, it serves
+// only clusters of the last run, so clusters deleted, excluded or failing to
+// be measured aren't served stale.
+type API struct {
+	mutex   sync.RWMutex
+	reports []*ClusterReport
+	// ran are Keys of clusters sent since the run began, or nil outside runs.
+	ran map[string]bool
+}
+
+// Send keeps reports of metrics, replacing those kept before of the same
+// clusters, by Key, and keeping the others', like of clusters streamed before.
+func (api *API) Send(metricData []*cloudwatch.MetricDatum) error {
+	reports := Report(metricData)
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+	sent := map[string]bool{}
+	for _, report := range reports {
+		sent[report.Key()] = true
+		if api.ran != nil {
+			api.ran[report.Key()] = true
+		}
+	}
+	for _, report := range api.reports {
+		if !sent[report.Key()] {
+			reports = append(reports, report)
+		}
+	}
+	sort.SliceStable(reports, func(i, j int) bool {
+		if reports[i].Cluster != reports[j].Cluster {
+			return reports[i].Cluster < reports[j].Cluster
+		}
+		return reports[i].Key() < reports[j].Key()
+	})
+	api.reports = reports
+	return nil
+}
+
+// begin starts a run, until done.
+func (api *API) begin() {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+	api.ran = map[string]bool{}
+}
+
+// done ends a run, dropping reports of clusters it didn't send.
+func (api *API) done() {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+	var reports []*ClusterReport
+	for _, report := range api.reports {
+		if api.ran[report.Key()] {
+			reports = append(reports, report)
+		}
+	}
+	api.reports = reports
+	api.ran = nil
+}
+
+// ServeHTTP responds with reports, or 404 if there's no such cluster.
+func (api *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		http.Error(w, "only GET is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	api.mutex.RLock()
+	defer api.mutex.RUnlock()
+	var response interface{}
+	switch key := strings.Trim(strings.TrimPrefix(r.URL.Path, "/clusters"), "/"); key {
+	case "":
+		reports := api.reports
+		if reports == nil {
+			reports = []*ClusterReport{}
+		}
+		response = reports
+	default:
+		report := api.report(key)
+		if report == nil {
+			http.Error(w, "no such cluster: "+key, http.StatusNotFound)
+			return
+		}
+		response = report
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Reports are the latest of every cluster, ordered by cluster name.
+func (api *API) Reports() []*ClusterReport {
+	api.mutex.RLock()
+	defer api.mutex.RUnlock()
+	return append([]*ClusterReport(nil), api.reports...)
+}
+
+// Report is the latest of the cluster by its Key, or nil.
+func (api *API) Report(key string) *ClusterReport {
+	api.mutex.RLock()
+	defer api.mutex.RUnlock()
+	return api.report(key)
+}
+
+// report finds the report of the cluster by its Key, or nil.
+func (api *API) report(key string) *ClusterReport {
+	for _, report := range api.reports {
+		if report.Key() == key {
+			return report
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package snitch
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+func TestAPI(t *testing.T) {
+	api := &API{}
+	get := func(path string, response interface{}) int {
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+		if recorder.Code == http.StatusOK {
+			if err := json.Unmarshal(recorder.Body.Bytes(), response); err != nil {
+				t.Error(err)
+			}
+		}
+		return recorder.Code
+	}
+	var reports []*ClusterReport
+	if code := get("/clusters", &reports); code != http.StatusOK || reports == nil || len(reports) != 0 {
+		t.Errorf("expected an empty list before measuring but got %d: %v", code, reports)
+	}
+	cr := NewClusterResources(aws.String("fake-cluster"))
+	cr.Remaining["fake.large"] = 2
+	cr.ClusterDimensions = []*cloudwatch.Dimension{{Name: aws.String("Region"), Value: aws.String("us-east-1")}}
+	sn := &Snitcher{Sinks: []*SinkConfig{{Sink: api}}}
+	if failed := sn.Send(cr.ToMetricData()); failed != 0 {
+		t.Fatalf("expected API to be sent metrics but %d failed", failed)
+	}
+	if code := get("/clusters", &reports); code != http.StatusOK || len(reports) != 1 {
+		t.Errorf("expected fake-cluster listed but got %d: %v", code, reports)
+	}
+	other := NewClusterResources(aws.String("another-cluster"))
+	other.Remaining["fake.large"] = 5
+	cr.Remaining["fake.large"] = 3
+	sn.Send(other.ToMetricData())
+	sn.Send(cr.ToMetricData())
+	if code := get("/clusters", &reports); code != http.StatusOK || len(reports) != 2 || reports[0].Cluster != "another-cluster" {
+		t.Errorf("expected clusters sent separately both listed but got %d: %v", code, reports)
+	}
+	var report ClusterReport
+	if code := get("/clusters/us-east-1/fake-cluster", &report); code != http.StatusOK || report.InstanceTypes["fake.large"]["RemainingSchedulable"] != 3 {
+		t.Errorf("expected fake-cluster's report but got %d: %+v", code, report)
+	}
+	if code := get("/clusters/fake-cluster", &report); code != http.StatusNotFound {
+		t.Errorf("expected 404 without region but got %d", code)
+	}
+	api.begin()
+	sn.Send(other.ToMetricData())
+	if code := get("/clusters/us-east-1/fake-cluster", &report); code != http.StatusOK {
+		t.Errorf("expected fake-cluster's report until the run is done but got %d", code)
+	}
+	api.done()
+	if code := get("/clusters/us-east-1/fake-cluster", &report); code != http.StatusNotFound {
+		t.Errorf("expected 404 for a cluster missing from the last run but got %d", code)
+	}
+	if code := get("/clusters", &reports); code != http.StatusOK || len(reports) != 1 || reports[0].Cluster != "another-cluster" {
+		t.Errorf("expected only another-cluster listed but got %d: %v", code, reports)
+	}
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("POST", "/clusters", nil))
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected POST refused but got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,91 @@
+package snitch
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+const (
+	// defaultBatchSize is how many data points PutMetricData calls publish,
+	// unless BatchSize says otherwise.
+	defaultBatchSize = 20
+	// maxBatchSize is the most data points PutMetricData accepts.
+	maxBatchSize = 1000
+	// maxPayloadBytes is the largest PutMetricData request CloudWatch accepts.
+	maxPayloadBytes = 1 << 20
+	// payloadOverhead leaves room in a request for all but its data points.
+	payloadOverhead = 1 << 10
+)
+
+// batchSize is how many data points publish publishes at once.
+func (sn *Snitcher) batchSize() int {
+	switch {
+	case sn.BatchSize == nil || *sn.BatchSize <= 0:
+		return defaultBatchSize
+	case *sn.BatchSize > maxBatchSize:
+		return maxBatchSize
+	}
+	return *sn.BatchSize
+}
+
+// batches splits metricData into batches of at most size data points, and at
+// most maxBytes, as PutMetricData requests encode them, whichever's fewer.
+func batches(metricData []*cloudwatch.MetricDatum, size, maxBytes int) (split [][]*cloudwatch.MetricDatum) {
+	var batch []*cloudwatch.MetricDatum
+	bytes := payloadOverhead
+	for _, datum := range metricData {
+		datumBytes := payloadSize(datum)
+		if len(batch) > 0 && (len(batch) == size || bytes+datumBytes > maxBytes) {
+			split = append(split, batch)
+			batch, bytes = nil, payloadOverhead
+		}
+		batch = append(batch, datum)
+		bytes += datumBytes
+	}
+	if len(batch) > 0 {
+		split = append(split, batch)
+	}
+	return
+}
+
+// payloadSize estimates, generously, how many bytes datum adds to a
+// PutMetricData request, which encodes it like
+// "MetricData.member.1.MetricName=RemainingSchedulable&...".
+func payloadSize(datum *cloudwatch.MetricDatum) int {
+	prefix := fmt.Sprintf("MetricData.member.%d.", maxBatchSize)
+	values := url.Values{}
+	set := func(key, value string) { values.Set(prefix+key, value) }
+	float := func(f *float64) string { return strconv.FormatFloat(aws.Float64Value(f), 'g', -1, 64) }
+	set("MetricName", aws.StringValue(datum.MetricName))
+	set("Unit", aws.StringValue(datum.Unit))
+	if datum.Timestamp != nil {
+		set("Timestamp", aws.TimeValue(datum.Timestamp).UTC().Format("2006-01-02T15:04:05.999999999Z"))
+	}
+	if datum.Value != nil {
+		set("Value", float(datum.Value))
+	}
+	if datum.StorageResolution != nil {
+		set("StorageResolution", strconv.FormatInt(*datum.StorageResolution, 10))
+	}
+	if statistics := datum.StatisticValues; statistics != nil {
+		set("StatisticValues.SampleCount", float(statistics.SampleCount))
+		set("StatisticValues.Sum", float(statistics.Sum))
+		set("StatisticValues.Minimum", float(statistics.Minimum))
+		set("StatisticValues.Maximum", float(statistics.Maximum))
+	}
+	for i, dimension := range datum.Dimensions {
+		set(fmt.Sprintf("Dimensions.member.%d.Name", i+1), aws.StringValue(dimension.Name))
+		set(fmt.Sprintf("Dimensions.member.%d.Value", i+1), aws.StringValue(dimension.Value))
+	}
+	for i, value := range datum.Values {
+		set(fmt.Sprintf("Values.member.%d", i+1), float(value))
+	}
+	for i, count := range datum.Counts {
+		set(fmt.Sprintf("Counts.member.%d", i+1), float(count))
+	}
+	return len(values.Encode()) + 1 // And the "&" joining it.
+}
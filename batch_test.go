@@ -0,0 +1,72 @@
+package snitch
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+func TestSnitcher_batchSize(t *testing.T) {
+	tests := []struct {
+		batchSize *int
+		expected  int
+	}{
+		{nil, defaultBatchSize},
+		{aws.Int(0), defaultBatchSize},
+		{aws.Int(500), 500},
+		{aws.Int(5000), maxBatchSize},
+	}
+	for _, test := range tests {
+		if actual := (&Snitcher{BatchSize: test.batchSize}).batchSize(); actual != test.expected {
+			t.Errorf("expected batches of %d but got %d", test.expected, actual)
+		}
+	}
+}
+
+func TestBatches(t *testing.T) {
+	var metricData []*cloudwatch.MetricDatum
+	for i := 0; i < 45; i++ {
+		metricData = append(metricData, &cloudwatch.MetricDatum{MetricName: aws.String("RunningTasks"), Value: aws.Float64(1)})
+	}
+	split := batches(metricData, 20, maxPayloadBytes)
+	if len(split) != 3 || len(split[0]) != 20 || len(split[2]) != 5 {
+		t.Errorf("expected batches of 20, 20 and 5 but got %d", len(split))
+	}
+	if split := batches(nil, 20, maxPayloadBytes); len(split) != 0 {
+		t.Errorf("expected no batches of nothing but got %d", len(split))
+	}
+
+	// Each data point of many long dimensions takes about 10 KB encoded.
+	large := &cloudwatch.MetricDatum{MetricName: aws.String("RunningTasks"), Value: aws.Float64(1)}
+	for i := 0; i < 30; i++ {
+		large.Dimensions = append(large.Dimensions, &cloudwatch.Dimension{Name: aws.String("Attribute"), Value: aws.String(strings.Repeat("v", 250))})
+	}
+	metricData = nil
+	for i := 0; i < 300; i++ {
+		metricData = append(metricData, large)
+	}
+	split = batches(metricData, maxBatchSize, maxPayloadBytes)
+	if len(split) < 2 {
+		t.Fatalf("expected large data points split into several batches but got %d", len(split))
+	}
+	for _, batch := range split {
+		bytes := payloadOverhead
+		for _, datum := range batch {
+			bytes += payloadSize(datum)
+		}
+		if bytes > maxPayloadBytes {
+			t.Errorf("expected batches under %d bytes but got %d", maxPayloadBytes, bytes)
+		}
+	}
+}
+
+func TestPayloadSize(t *testing.T) {
+	datum := &cloudwatch.MetricDatum{MetricName: aws.String("RunningTasks"), Value: aws.Float64(1), Unit: aws.String("Count")}
+	small := payloadSize(datum)
+	datum.Dimensions = []*cloudwatch.Dimension{{Name: aws.String("ClusterName"), Value: aws.String("prod")}}
+	if larger := payloadSize(datum); larger <= small {
+		t.Errorf("expected a dimension to add to %d bytes but got %d", small, larger)
+	}
+}
@@ -0,0 +1,74 @@
+package snitch
+
+import (
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+)
+
+// maxCarriedOver is how many batches carryOver keeps, dropping the oldest, so
+// CloudWatch being down for long doesn't grow snitch without bound.
+const maxCarriedOver = 50
+
+// carryOver keeps batches of metrics that failed to publish, even after
+// retrying, to retry at the end of the run and, failing that, the next run.
+// Its methods are safe to call on nil carryOver, which keeps nothing.
+type carryOver struct {
+	mutex   sync.Mutex
+	batches []*carriedBatch
+}
+
+// carriedBatch is a batch to publish with the CloudWatch client, like another
+// account's, it failed with.
+type carriedBatch struct {
+	cloudWatch cloudwatchiface.CloudWatchAPI
+	input      *cloudwatch.PutMetricDataInput
+}
+
+// carry batch of input, failed to publish with cloudWatch by err, if
+// retrying might help, like when throttled or CloudWatch is unreachable, not
+// when CloudWatch rejected it as invalid.
+func (c *carryOver) carry(sn *Snitcher, cloudWatch cloudwatchiface.CloudWatchAPI, input *cloudwatch.PutMetricDataInput, err error) {
+	if c == nil {
+		return
+	}
+	if !request.IsErrorRetryable(err) && !request.IsErrorThrottle(err) {
+		sn.logger().Warnf("Not carrying over %d metrics, since retrying wouldn't help: %s", len(input.MetricData), err)
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.batches = append(c.batches, &carriedBatch{cloudWatch: cloudWatch, input: input})
+	if dropped := len(c.batches) - maxCarriedOver; dropped > 0 {
+		sn.logger().Warnf("Dropping %d oldest batches of metrics carried over", dropped)
+		c.batches = c.batches[dropped:]
+	}
+}
+
+// take the batches carried over, leaving none.
+func (c *carryOver) take() (batches []*carriedBatch) {
+	if c == nil {
+		return nil
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	batches, c.batches = c.batches, nil
+	return
+}
+
+// publishCarriedOver retries publishing batches carried over, carrying over
+// again those that fail again, for the next run.
+func (sn *Snitcher) publishCarriedOver() {
+	for _, batch := range sn.carryOver.take() {
+		if _, err := batch.cloudWatch.PutMetricData(batch.input); err != nil {
+			sn.logger().Errorf("Failed to publish %d metrics carried over to CloudWatch: %s", len(batch.input.MetricData), err)
+			sn.carryOver.carry(sn, batch.cloudWatch, batch.input, err)
+			continue
+		}
+		sn.logger().Infof("Published %d metrics carried over to %q", len(batch.input.MetricData), aws.StringValue(batch.input.Namespace))
+		sn.Summary.publish(len(batch.input.MetricData))
+	}
+}
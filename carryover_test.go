@@ -0,0 +1,61 @@
+package snitch
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+func TestSnitcher_CarryOverFailures(t *testing.T) {
+	fake := NewFakeECS(t)
+	fake.checkCluster = false
+	cloudWatch := &FakeCloudWatch{errorToReturn: errors.New("CloudWatch is down")}
+	sn := &Snitcher{ECS: fake, CloudWatch: cloudWatch, Namespace: aws.String("Testable/Namespace"), ShouldPublish: aws.Bool(true), CarryOverFailures: aws.Bool(true)}
+	if err := sn.run(); err == nil {
+		t.Fatal("expected failure publishing")
+	}
+	failed := len(cloudWatch.payload)
+	if failed == 0 || len(sn.carryOver.batches) == 0 {
+		t.Fatalf("expected failed batches carried over but got %d", len(sn.carryOver.batches))
+	}
+	if attempted := failed - len(sn.carryOver.batches); attempted != len(sn.carryOver.batches) {
+		t.Errorf("expected carried batches retried at the end of the run but got %d calls for %d batches", failed, len(sn.carryOver.batches))
+	}
+
+	cloudWatch.errorToReturn = nil
+	cloudWatch.payload = nil
+	sn.ShouldPublish = aws.Bool(false)
+	if err := sn.run(); err != nil {
+		t.Fatal(err)
+	}
+	if len(sn.carryOver.batches) != 0 || len(cloudWatch.payload) == 0 {
+		t.Errorf("expected carried batches published the next run but got %d left", len(sn.carryOver.batches))
+	}
+}
+
+func TestCarryOver_carry(t *testing.T) {
+	failed := errors.New("CloudWatch is down")
+	var nothing *carryOver
+	nothing.carry(&Snitcher{}, nil, nil, failed)
+	if batches := nothing.take(); batches != nil {
+		t.Errorf("expected nil carryOver to keep nothing but got %v", batches)
+	}
+	c := &carryOver{}
+	for i := 0; i < maxCarriedOver+2; i++ {
+		c.carry(&Snitcher{}, nil, &cloudwatch.PutMetricDataInput{}, failed)
+	}
+	if batches := c.take(); len(batches) != maxCarriedOver {
+		t.Errorf("expected %d batches carried over but got %d", maxCarriedOver, len(batches))
+	}
+	c.carry(&Snitcher{}, nil, &cloudwatch.PutMetricDataInput{}, awserr.New("InvalidParameterValue", "fake", nil))
+	if batches := c.take(); len(batches) != 0 {
+		t.Errorf("expected batches CloudWatch rejected not carried over but got %d", len(batches))
+	}
+	c.carry(&Snitcher{}, nil, &cloudwatch.PutMetricDataInput{}, awserr.New("Throttling", "fake", nil))
+	if batches := c.take(); len(batches) != 1 {
+		t.Errorf("expected throttled batch carried over but got %d", len(batches))
+	}
+}
@@ -0,0 +1,94 @@
+package snitch
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+// ClusterSummary is an ECS Cluster as ListClusters and DescribeClusters see
+// it, and whether snitch would measure it.
+type ClusterSummary struct {
+	Name         string
+	Instances    int64 // Registered Container Instances.
+	RunningTasks int64
+	PendingTasks int64
+	// Why snitch wouldn't measure the cluster, or "" if it would.
+	Skipped string
+}
+
+// SummarizeClusters describes every ECS Cluster, whether or not Cluster,
+// Include, Exclude and SelectTags let it be measured, ordered like
+// ListClusters, for checking them before publishing.
+//
+// Requires IAM permissions "ecs:ListClusters" and "ecs:DescribeClusters".
+func (sn *Snitcher) SummarizeClusters() (summaries []*ClusterSummary, err error) {
+	var arns []*string
+	err = sn.ECS.ListClustersPages(
+		&ecs.ListClustersInput{},
+		func(page *ecs.ListClustersOutput, last bool) bool {
+			arns = append(arns, page.ClusterArns...)
+			return len(page.ClusterArns) > 0
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	input := &ecs.DescribeClustersInput{}
+	if len(sn.SelectTags) > 0 {
+		input.Include = aws.StringSlice([]string{"TAGS"})
+	}
+	// DescribeClusters takes up to 100 clusters at a time.
+	for start := 0; start < len(arns); start += 100 {
+		end := start + 100
+		if end > len(arns) {
+			end = len(arns)
+		}
+		input.Clusters = arns[start:end]
+		output, err := sn.ECS.DescribeClusters(input)
+		if err != nil {
+			return summaries, err
+		}
+		for _, cluster := range output.Clusters {
+			summaries = append(summaries, sn.summarize(cluster))
+		}
+	}
+	return summaries, nil
+}
+
+// summarize a described cluster, explaining why it'd be skipped, if it would.
+func (sn *Snitcher) summarize(cluster *ecs.Cluster) *ClusterSummary {
+	summary := &ClusterSummary{
+		Name:         aws.StringValue(cluster.ClusterName),
+		Instances:    aws.Int64Value(cluster.RegisteredContainerInstancesCount),
+		RunningTasks: aws.Int64Value(cluster.RunningTasksCount),
+		PendingTasks: aws.Int64Value(cluster.PendingTasksCount),
+	}
+	tags := map[string]string{}
+	for _, tag := range cluster.Tags {
+		tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+	var untagged []string
+	for key, value := range sn.SelectTags {
+		if tags[key] != value {
+			untagged = append(untagged, key+"="+value)
+		}
+	}
+	sort.Strings(untagged)
+	sized := aws.IntValue(sn.CPU) > 0 && aws.IntValue(sn.Memory) > 0 ||
+		aws.IntValue(sn.DefaultCPU) > 0 && aws.IntValue(sn.DefaultMemory) > 0
+	switch {
+	case aws.StringValue(sn.Cluster) != "" && summary.Name != aws.StringValue(sn.Cluster):
+		summary.Skipped = fmt.Sprintf("not cluster %q", aws.StringValue(sn.Cluster))
+	case sn.exclusion(summary.Name) != "":
+		summary.Skipped = sn.exclusion(summary.Name)
+	case len(untagged) > 0:
+		summary.Skipped = "not tagged " + strings.Join(untagged, ", ")
+	case summary.RunningTasks == 0 && !sized:
+		summary.Skipped = "runs no Tasks to size containers by"
+	}
+	return summary
+}
@@ -0,0 +1,66 @@
+package snitch
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+func TestSnitcher_SummarizeClusters(t *testing.T) {
+	fake := NewFakeECS(t)
+	fake.expectedTags = []*ecs.Tag{{Key: aws.String("snitch"), Value: aws.String("enabled")}}
+	sn := &Snitcher{
+		ECS:        fake,
+		Exclude:    []string{"who-*"},
+		SelectTags: map[string]string{"snitch": "enabled"},
+	}
+	summaries, err := sn.SummarizeClusters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summaries) != 3 {
+		t.Fatalf("expected every cluster summarized but got %d", len(summaries))
+	}
+	expected := map[string]string{
+		"fake-ecs-cluster":         "",
+		"another-fake-ecs-cluster": "",
+		"who-even-uses-fargate":    `excluded by "who-*"`,
+	}
+	for _, summary := range summaries {
+		if summary.Skipped != expected[summary.Name] {
+			t.Errorf("expected %q skipped for %q but got %q", summary.Name, expected[summary.Name], summary.Skipped)
+		}
+		if summary.Instances != 3 || summary.RunningTasks != 3 {
+			t.Errorf("expected %q's counts described but got %+v", summary.Name, summary)
+		}
+	}
+
+	sn.SelectTags["team"] = "capacity"
+	sn.Cluster = aws.String("another-fake-ecs-cluster")
+	summaries, _ = sn.SummarizeClusters()
+	if summaries[0].Skipped != `not cluster "another-fake-ecs-cluster"` {
+		t.Errorf("expected clusters besides Cluster skipped but got %q", summaries[0].Skipped)
+	}
+	if summaries[1].Skipped != "not tagged team=capacity" {
+		t.Errorf("expected cluster lacking tags skipped but got %q", summaries[1].Skipped)
+	}
+
+	fake.errorToReturn = errors.New("fake error")
+	if _, err = sn.SummarizeClusters(); err == nil {
+		t.Error("expected ListClusters' error")
+	}
+}
+
+func TestSnitcher_summarize(t *testing.T) {
+	sn := &Snitcher{}
+	idle := &ecs.Cluster{ClusterName: aws.String("idle")}
+	if summary := sn.summarize(idle); summary.Skipped != "runs no Tasks to size containers by" {
+		t.Errorf("expected idle cluster skipped but got %q", summary.Skipped)
+	}
+	sn.DefaultCPU, sn.DefaultMemory = aws.Int(256), aws.Int(512)
+	if summary := sn.summarize(idle); summary.Skipped != "" {
+		t.Errorf("expected idle cluster measured at default size but got %q", summary.Skipped)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/shatil/snitch"
+)
+
+// Registered here, since completion lists commands, which would otherwise
+// refer to themselves.
+func init() {
+	commands["completion"] = command{completion, "print bash, zsh or fish completion script"}
+}
+
+// completion prints a shell's completion script, completing commands, flags
+// shared by measuring commands, and cluster names after -cluster and describe:
+//
+//	source <(snitch completion bash)
+//	snitch completion zsh > "${fpath[1]}/_snitch"
+//	snitch completion fish > ~/.config/fish/completions/snitch.fish
+//
+// Scripts list clusters by running "snitch completion clusters".
+func completion(args []string) {
+	flags := flag.NewFlagSet("completion", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: snitch completion bash|zsh|fish")
+	}
+	parse(flags, args)
+	switch flags.Arg(0) {
+	case "bash":
+		fmt.Printf(bashCompletion, strings.Join(flagNames(), " "), strings.Join(commandNames(), " "))
+	case "zsh":
+		fmt.Print("#compdef snitch\n\nautoload -U +X bashcompinit && bashcompinit\n")
+		fmt.Printf(bashCompletion, strings.Join(flagNames(), " "), strings.Join(commandNames(), " "))
+	case "fish":
+		fmt.Println(fishCompletion)
+		for _, name := range commandNames() {
+			fmt.Printf("complete -c snitch -n __fish_use_subcommand -a %s -d %q\n", name, commands[name].summary)
+		}
+		shared := flag.NewFlagSet("", flag.ContinueOnError)
+		newSnitcher(shared)
+		shared.VisitAll(func(f *flag.Flag) {
+			if f.Name != "cluster" {
+				fmt.Printf("complete -c snitch -o %s -d %q\n", f.Name, f.Usage)
+			}
+		})
+	case "clusters":
+		// Completion shouldn't spill logs onto the command line.
+		log.SetOutput(ioutil.Discard)
+		for name := range (&snitch.Snitcher{}).WithAWS().DiscoverClusters() {
+			fmt.Println(*name)
+		}
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+}
+
+// commandNames lists commands, sorted.
+func commandNames() (names []string) {
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+// flagNames lists flags shared by measuring commands, like "-cluster".
+func flagNames() (names []string) {
+	shared := flag.NewFlagSet("", flag.ContinueOnError)
+	newSnitcher(shared)
+	shared.VisitAll(func(f *flag.Flag) {
+		names = append(names, "-"+f.Name)
+	})
+	return
+}
+
+// bashCompletion is a bash completion script, formatted with flags and
+// commands.
+const bashCompletion = `_snitch() {
+	local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"
+	case "$prev" in
+	-cluster|--cluster|describe)
+		COMPREPLY=($(compgen -W "$(snitch completion clusters 2>/dev/null)" -- "$cur"))
+		return
+		;;
+	esac
+	if [[ "$cur" == -* ]]; then
+		COMPREPLY=($(compgen -W "%s" -- "$cur"))
+	elif [[ $COMP_CWORD -eq 1 ]]; then
+		COMPREPLY=($(compgen -W "%s" -- "$cur"))
+	fi
+}
+complete -F _snitch snitch
+`
+
+// fishCompletion is the start of a fish completion script, before commands
+// and flags.
+const fishCompletion = `complete -c snitch -f
+complete -c snitch -n '__fish_seen_subcommand_from describe' -a '(snitch completion clusters 2>/dev/null)'
+complete -c snitch -o cluster -r -a '(snitch completion clusters 2>/dev/null)' -d 'ECS Cluster'`
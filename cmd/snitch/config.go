@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+
+	"github.com/shatil/snitch"
+)
+
+// config is a flag.Value of where, like a YAML or JSON file or "ssm:" SSM
+// parameter, a Snitcher is configured, applied by parse so flags override it
+// wherever they're given.
+type config struct {
+	sn   *snitch.Snitcher
+	path string
+	// What fields apply set, by index, held before, so applying again resets
+	// those the configuration no longer sets.
+	owned map[int]reflect.Value
+}
+
+func (c *config) String() string {
+	if c == nil {
+		return ""
+	}
+	return c.path
+}
+
+func (c *config) Set(value string) error {
+	c.path = value
+	return nil
+}
+
+// parse parses flags from args, then fills what flags didn't set from
+// -config, or SNITCH_CONFIG, if any, then from SNITCH_* environment variables.
+func parse(flags *flag.FlagSet, args []string) {
+	flags.Parse(args)
+	f := flags.Lookup("config")
+	if f == nil {
+		return
+	}
+	c := f.Value.(*config)
+	if c.path == "" {
+		c.path = os.Getenv("SNITCH_CONFIG")
+	}
+	if err := c.apply(flags); err != nil {
+		log.Fatalf("Failed to configure snitch: %s", err)
+	}
+}
+
+// apply fills the Snitcher's fields flags didn't set from its configuration,
+// then from SNITCH_* environment variables, again whenever called, resetting
+// fields it filled before that they no longer set. If it fails, fields keep
+// what they had.
+func (c *config) apply(flags *flag.FlagSet) error {
+	fromEnv, err := snitch.ConfigFromEnv()
+	if err != nil {
+		return fmt.Errorf("environment variables: %s", err)
+	}
+	fromConfig := &snitch.Snitcher{}
+	if c.path != "" {
+		if fromConfig, err = c.sn.LoadConfig(c.path); err != nil {
+			return fmt.Errorf("-config %q: %s", c.path, err)
+		}
+	}
+	fromConfig.WithDefaults(fromEnv)
+	// Flags store values where Snitcher's fields point, or in its fields.
+	set := map[uintptr]bool{}
+	flags.Visit(func(f *flag.Flag) {
+		set[reflect.ValueOf(f.Value).Pointer()] = true
+	})
+	to, from := reflect.ValueOf(c.sn).Elem(), reflect.ValueOf(fromConfig).Elem()
+	for i, value := range c.owned {
+		to.Field(i).Set(value)
+	}
+	owned := map[int]reflect.Value{}
+	for i := 0; i < to.NumField(); i++ {
+		field := to.Field(i)
+		if !field.CanSet() || from.Field(i).IsZero() {
+			continue
+		}
+		address := field.Addr().Pointer()
+		if field.Kind() == reflect.Ptr && !field.IsNil() {
+			address = field.Pointer()
+		}
+		if !set[address] {
+			owned[i] = reflect.ValueOf(field.Interface())
+			field.Set(from.Field(i))
+		}
+	}
+	c.owned = owned
+	return nil
+}
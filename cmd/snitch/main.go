@@ -1,12 +1,31 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	httppprof "net/http/pprof"
 	"os"
+	"os/signal"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go/aws"
 
 	"github.com/shatil/snitch"
+	"github.com/shatil/snitch/snitchgrpc"
 )
 
 // Package arranged so CLI invocation, testing, etc., work outside of Lambda:
@@ -16,18 +35,919 @@ import (
 var lambdaStart = lambda.Start
 var sn *snitch.Snitcher
 
+// version is set at build time, like -ldflags "-X main.version=v1.2.3".
+var version = "dev"
+
+// command is a subcommand, run with the CLI arguments following its name.
+type command struct {
+	run     func(args []string)
+	summary string
+}
+
+// commands run instead of measuring when named as the first CLI argument.
+var commands = map[string]command{
+	"alarms":      {alarms, "create, update and delete an alarm of each cluster's RemainingSchedulable"},
+	"clusters":    {clusters, "list ECS Clusters and whether snitch would measure them"},
+	"daemon":      {daemon, "measure and publish every -interval until SIGTERM"},
+	"describe":    {describe, "report one cluster's capacity"},
+	"explain":     {explain, "show what limits containers in each Container Instance"},
+	"expressions": {expressions, "print CloudWatch Metric Math for dashboards"},
+	"fit":         {fit, "report how many copies of a Task Definition fit"},
+	"iam-policy":  {iamPolicy, "print the IAM policy snitch needs, configured by the same flags"},
+	"instance":    {instance, "report on one Container Instance"},
+	"loadmodel":   {loadmodel, "report how much of a Workload each cluster could place"},
+	"measure":     {measure, "measure and, with -p, publish (the default)"},
+	"metrics":     {metrics, "document every metric snitch can publish"},
+	"publish":     {publish, "measure and publish"},
+	"serve":       {serve, "serve Prometheus metrics, measuring every -interval"},
+	"version":     {printVersion, "print snitch's version"},
+	"watch":       {watch, "redraw clusters' capacity every -interval"},
+}
+
 func main() {
 	if os.Getenv("_LAMBDA_SERVER_PORT") == "" {
 		lambdaStart = func(interface{}) {
-			sn = &snitch.Snitcher{
-				Namespace:     flag.String("n", "", "metrics namespace in CloudWatch"),
-				ShouldPublish: flag.Bool("p", false, "do publish findings to CloudWatch"),
+			if len(os.Args) > 1 {
+				if command, ok := commands[os.Args[1]]; ok {
+					command.run(os.Args[2:])
+					return
+				}
+			}
+			// Without a command, measure, as snitch did before it had any.
+			flag.CommandLine.Usage = usage
+			runMeasure(flag.CommandLine, os.Args[1:], false)
+		}
+	}
+	lambdaStart(handle)
+}
+
+// handle runs snitch for a Lambda event overriding, like
+// {"clusters": ["prod-*"], "namespace": "ECS/Snitch", "publish": true}, the
+// scope, only, of the configuration SNITCH_CONFIG names, like
+// "ssm:/snitch/prod", then SNITCH_* environment variables, so one function
+// serves many schedules.
+//
+// It returns what was measured and published, failing the invocation if
+// anything failed, so Lambda's Errors metric can be alarmed on. Clusters not
+// started within SNITCH_DEADLINE_MARGIN of Lambda's deadline are skipped, so
+// what's measured is published before Lambda times out.
+func handle(ctx context.Context, event json.RawMessage) (*snitch.Summary, error) {
+	overrides, err := snitch.ParseEvent(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse event: %s", err)
+	}
+	sn := &snitch.Snitcher{}
+	if location := os.Getenv("SNITCH_CONFIG"); location != "" {
+		if sn, err = sn.LoadConfig(location); err != nil {
+			return nil, err
+		}
+	}
+	fromEnv, err := snitch.ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	sn = overrides.Override(sn.WithDefaults(fromEnv))
+	sn.Context = ctx
+	if deadline, ok := ctx.Deadline(); ok {
+		sn.Deadline = deadline.Add(-deadlineMargin())
+	}
+	return snitch.RunSummary(sn)
+}
+
+// deadlineMargin is how long before Lambda's deadline to stop measuring, from
+// SNITCH_DEADLINE_MARGIN, like "30s", or else 10 seconds.
+func deadlineMargin() time.Duration {
+	margin, err := time.ParseDuration(os.Getenv("SNITCH_DEADLINE_MARGIN"))
+	if err != nil {
+		return 10 * time.Second
+	}
+	return margin
+}
+
+// usage prints commands and the default command's flags.
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprint(out, "Usage: snitch [command] [flags]\n\nCommands:\n")
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\t%s\n", name, commands[name].summary)
+	}
+	w.Flush()
+	fmt.Fprint(out, "\nRun \"snitch [command] -h\" for a command's flags. Without one, flags are measure's:\n")
+	flag.PrintDefaults()
+}
+
+// measure measures and, with -p, publishes:
+//
+//	snitch measure -n ECS/Snitch -o json
+func measure(args []string) {
+	runMeasure(flag.NewFlagSet("measure", flag.ExitOnError), args, false)
+}
+
+// publish measures and publishes, like measure -p:
+//
+//	snitch publish -n ECS/Snitch
+func publish(args []string) {
+	runMeasure(flag.NewFlagSet("publish", flag.ExitOnError), args, true)
+}
+
+// runMeasure parses flags of measuring from args and measures, publishing if
+// -p or shouldPublish.
+func runMeasure(flags *flag.FlagSet, args []string, shouldPublish bool) {
+	sn = newSnitcher(flags)
+	progress := flags.Duration("progress", 0, "print clusters measured to stderr this often, like 10s (default: never)")
+	cpuProfile := flags.String("cpuprofile", "", "write CPU profile to file")
+	memProfile := flags.String("memprofile", "", "write heap profile to file when done")
+	output := flags.String("o", "", "also print measurements to stdout as \"json\" or \"csv\"")
+	var failBelow thresholds
+	flags.Var(&failBelow, "fail-below-remaining", "exit 3 if any cluster's RemainingSchedulable is below this, or clusters like \"prod-*=20\" are (repeatable)")
+	parse(flags, args)
+	// Like "-dry-run json", which is -dry-run followed by argument "json",
+	// since -dry-run alone means "table".
+	if flags.NArg() > 0 {
+		log.Fatalf("Unexpected arguments %q; give -dry-run a format like -dry-run=json", flags.Args())
+	}
+	if shouldPublish {
+		sn.ShouldPublish = aws.Bool(true)
+	}
+	switch *output {
+	case "":
+	case "json", "csv":
+		sn.Sinks = append(sn.Sinks, &snitch.SinkConfig{Type: *output})
+	default:
+		log.Fatalf("Unknown output format -o %q", *output)
+	}
+	checker := &snitch.ThresholdSink{Thresholds: failBelow}
+	if len(failBelow) > 0 {
+		sn.Sinks = append(sn.Sinks, &snitch.SinkConfig{Type: "thresholds", Sink: checker})
+	}
+	stopProfiling := profile(*cpuProfile, *memProfile)
+	if *progress > 0 {
+		sn.Progress = snitch.NewProgress()
+		go func() {
+			for range time.Tick(*progress) {
+				log.Println("Progress:", sn.Progress)
+			}
+		}()
+	}
+	snitch.Run(sn)
+	stopProfiling()
+	if breaches := checker.Breaches(); len(breaches) > 0 {
+		for _, breach := range breaches {
+			log.Println("Below -fail-below-remaining:", breach)
+		}
+		os.Exit(3)
+	}
+}
+
+// printVersion prints snitch's version and the Go it was built with.
+func printVersion(args []string) {
+	flags := flag.NewFlagSet("version", flag.ExitOnError)
+	parse(flags, args)
+	fmt.Printf("snitch %s (%s)\n", version, runtime.Version())
+}
+
+// newSnitcher defines flags configuring a Snitcher, so subcommands measuring
+// like the default command share them.
+func newSnitcher(flags *flag.FlagSet) *snitch.Snitcher {
+	sn := &snitch.Snitcher{
+		Namespace:               flags.String("n", "", "metrics namespace in CloudWatch, or one per cluster like \"ECS/{cluster}\" or \"{team}/ECS\" of -tag-dimension team"),
+		ShouldPublish:           flags.Bool("p", false, "do publish findings to CloudWatch"),
+		EmbeddedMetricFormat:    flags.Bool("emf", false, "publish by writing CloudWatch Embedded Metric Format to stdout, not calling PutMetricData"),
+		Cluster:                 flags.String("cluster", "", "only ECS Cluster to measure, without listing clusters (default: all)"),
+		CPU:                     flags.Int("cpu", 0, "CPU Units per container (default: largest running Task's)"),
+		Memory:                  flags.Int("memory", 0, "Memory (MiB) per container (default: largest running Task's)"),
+		DefaultCPU:              flags.Int("default-cpu", 0, "CPU Units per container in clusters running no Tasks (default: skip them)"),
+		DefaultMemory:           flags.Int("default-memory", 0, "Memory (MiB) per container in clusters running no Tasks (default: skip them)"),
+		MinCPU:                  flags.Int("min-cpu", 0, "smallest CPU Units per container to measure against"),
+		MinMemory:               flags.Int("min-memory", 0, "smallest Memory (MiB) per container to measure against"),
+		ExcludeDaemons:          flags.Bool("exclude-daemons", false, "ignore DAEMON Services' Tasks when measuring container size"),
+		ECSRetries:              flags.Int("ecs-retries", 5, "times to retry throttled ECS calls, backing off exponentially, before giving up on a cluster"),
+		PublishRetries:          flags.Int("publish-retries", 3, "times to retry failed PutMetricData calls, backing off exponentially"),
+		CarryOverFailures:       flags.Bool("carry-over", false, "retry batches of metrics that failed to publish at the end of the run and, failing that, the next"),
+		Stream:                  flags.Bool("stream", false, "publish, and send to -sinks, each cluster's metrics as soon as they're measured"),
+		StatisticSets:           flags.Bool("statistic-sets", false, "publish metrics by instance type as a StatisticSet per cluster, of their count, sum, min and max"),
+		BatchSize:               flags.Int("batch-size", 20, "data points to publish per PutMetricData call, up to 1000"),
+		MetricPrefix:            flags.String("metric-prefix", "", "prefix of every metric name published, like \"ecs.capacity.\""),
+		DeltaState:              flags.String("delta-state", "", "file, \"s3://bucket/key\" or \"dynamodb:table\" to keep metrics last published in, to skip publishing them unchanged"),
+		DeltaMaxAge:             flags.String("delta-max-age", "15m", "longest to skip publishing a metric unchanged, with -delta-state"),
+		CountUnits:              flags.Bool("count-units", false, "publish LowestCommonMultipleCPU and LowestCommonMultipleMemory with unit Count, as before memory was in Megabytes"),
+		Concurrency:             flags.Int("concurrency", 8, "ECS Clusters to measure at once, per region and account"),
+		MeasureFullPercent:      flags.Bool("full-percent", false, "measure how full each cluster is, 0 through 100"),
+		MeasurePending:          flags.Bool("pending", false, "measure Tasks waiting to be placed or started"),
+		MeasureSubnets:          flags.Bool("subnets", false, "measure free IPs and \"awsvpc\" containers possible per subnet"),
+		MeasureScheduledScaling: flags.Bool("scheduled-scaling", false, "measure shortfall of upcoming scheduled scale-outs"),
+		Profile:                 flags.String("profile", "", "AWS shared config profile to use"),
+		CredentialProcess:       flags.String("credential-process", "", "command printing AWS credentials, like shared config's credential_process"),
+		WebIdentityTokenFile:    flags.String("web-identity-token-file", "", "file of OIDC token to assume -web-identity-role with"),
+		WebIdentityRole:         flags.String("web-identity-role", "", "IAM Role ARN to assume with -web-identity-token-file"),
+		ECSEndpoint:             flags.String("ecs-endpoint", "", "ECS endpoint URL, like LocalStack's \"http://localhost:4566\""),
+		CloudWatchEndpoint:      flags.String("cloudwatch-endpoint", "", "CloudWatch endpoint URL, like LocalStack's \"http://localhost:4566\""),
+		ExternalID:              flags.String("external-id", "", "external ID to assume -role with"),
+		PublishInAccounts:       flags.Bool("publish-in-accounts", false, "publish each -role's metrics into its own account"),
+		OrganizationRole:        flags.String("org-role", "", "IAM Role name to measure every AWS Organizations account as, like -role"),
+		OrganizationalUnit:      flags.String("org-unit", "", "only -org-role accounts directly in this Organizational Unit ID"),
+		MeasureFaultInjection:   flags.Bool("fault-injection", false, "label metrics measured during Fault Injection Simulator experiments"),
+		CanaryNamespace:         flags.String("canary-namespace", "", "metrics namespace in CloudWatch to publish a Canary to every run"),
+		TelemetryNamespace:      flags.String("telemetry-namespace", "", "metrics namespace in CloudWatch to publish snitch's own metrics, like RunDuration, to every run"),
+		LogFormat:               flags.String("log-format", "text", "log as \"text\" or \"json\", an object per line"),
+	}
+	flags.Var((*list)(&sn.Roles), "role", "IAM Role ARN to measure another account as, adding AccountId dimension (repeatable)")
+	flags.Var((*tags)(&sn.AccountTags), "org-tag", "only -org-role accounts tagged like \"snitch=enabled\" (repeatable)")
+	flags.Var((*list)(&sn.Regions), "regions", "AWS Regions to measure, adding Region dimension, or \"all\" enabled (default: only AWS_REGION's)")
+	flags.Var((*list)(&sn.Include), "include", "measure only ECS Clusters named like glob \"prod-*\" or regexp \"/^prod-/\" (repeatable)")
+	flags.Var((*list)(&sn.Exclude), "exclude", "don't measure ECS Clusters named like glob \"ci-*\" or regexp \"/^ci-/\" (repeatable)")
+	flags.Var((*tags)(&sn.SelectTags), "tag", "measure only ECS Clusters tagged like \"snitch:enabled=true\" (repeatable)")
+	flags.Var((*weights)(&sn.InstanceTypeWeights), "weight", "instance type's weight in schedulable counts, like \"c4.large=0\" to ignore it (repeatable)")
+	flags.Var((*list)(&sn.DimensionTags), "tag-dimension", "ECS Cluster tag key to add to metrics as a dimension, like \"Team\" (repeatable)")
+	flags.Var((*tags)(&sn.MetricNames), "metric-name", "name to publish a metric as, like \"RemainingSchedulable=remaining_slots\" (repeatable)")
+	flags.Var((*list)(&sn.ExtraNamespaces), "extra-namespace", "another metrics namespace in CloudWatch to publish every metric to, like while migrating (repeatable)")
+	flags.Var((*list)(&sn.DimensionAttributes), "attribute", "ECS Attribute to partition metrics by as a dimension, like \"stack\" (repeatable)")
+	flags.Var(&config{sn: sn}, "config", "YAML or JSON file, or SSM parameter like \"ssm:/snitch/prod\", configuring Snitcher's fields, which flags override (default: $SNITCH_CONFIG)")
+	sn.DryRun = new(string)
+	sn.LogLevel = new(string)
+	flags.Var((*logLevel)(sn.LogLevel), "log-level", "least severe to log: \"debug\", including every metric published, \"info\", \"warn\" or \"error\" (default \"info\")")
+	flags.Var((*quiet)(sn.LogLevel), "quiet", "log only errors, like -log-level error")
+	flags.Var((*dryRun)(sn.DryRun), "dry-run", "print metrics as \"table\" (if just -dry-run) or \"json\" (-dry-run=json) instead of publishing them")
+	flags.Var((*sinks)(&sn.Sinks), "sinks", "JSON file of sinks to also send metrics to, like [{\"type\": \"cloudwatch\", \"namespace\": \"Team/Capacity\"}] (repeatable)")
+	return sn
+}
+
+// list is a flag.Value of strings, given repeatedly or comma-separated.
+type list []string
+
+func (l *list) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *list) Set(value string) error {
+	*l = append(*l, strings.Split(value, ",")...)
+	return nil
+}
+
+// tags is a flag.Value of values by key, like "snitch:enabled=true", given
+// repeatedly or comma-separated.
+type tags map[string]string
+
+func (t *tags) String() string {
+	var pairs []string
+	for key, value := range *t {
+		pairs = append(pairs, key+"="+value)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (t *tags) Set(value string) error {
+	if *t == nil {
+		*t = tags{}
+	}
+	for _, pair := range strings.Split(value, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("%q isn't like key=value", pair)
+		}
+		(*t)[parts[0]] = parts[1]
+	}
+	return nil
+}
+
+// weights is a flag.Value of weights by name, like "c4.large=0.5", given
+// repeatedly or comma-separated.
+type weights map[string]float64
+
+func (w *weights) String() string {
+	var pairs []string
+	for name, weight := range *w {
+		pairs = append(pairs, fmt.Sprintf("%s=%g", name, weight))
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (w *weights) Set(value string) error {
+	if *w == nil {
+		*w = weights{}
+	}
+	for _, pair := range strings.Split(value, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("%q isn't like name=weight", pair)
+		}
+		weight, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return err
+		}
+		(*w)[parts[0]] = weight
+	}
+	return nil
+}
+
+// sinks is a flag.Value of sinks declared in JSON files, given repeatedly.
+type sinks []*snitch.SinkConfig
+
+func (s *sinks) String() string {
+	var types []string
+	for _, config := range *s {
+		types = append(types, config.Type)
+	}
+	return strings.Join(types, ",")
+}
+
+func (s *sinks) Set(value string) error {
+	b, err := ioutil.ReadFile(value)
+	if err != nil {
+		return err
+	}
+	var configs []*snitch.SinkConfig
+	if err := json.Unmarshal(b, &configs); err != nil {
+		return fmt.Errorf("%s isn't a JSON list of sinks: %s", value, err)
+	}
+	*s = append(*s, configs...)
+	return nil
+}
+
+// thresholds is a flag.Value of thresholds by cluster name or pattern, like
+// "prod-*=20", or of every cluster if just a number, given repeatedly or
+// comma-separated.
+type thresholds map[string]float64
+
+func (t *thresholds) String() string {
+	var pairs []string
+	for pattern, threshold := range *t {
+		pairs = append(pairs, fmt.Sprintf("%s=%g", pattern, threshold))
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (t *thresholds) Set(value string) error {
+	if *t == nil {
+		*t = thresholds{}
+	}
+	for _, pair := range strings.Split(value, ",") {
+		pattern, number := "*", pair
+		if i := strings.LastIndex(pair, "="); i >= 0 {
+			pattern, number = pair[:i], pair[i+1:]
+		}
+		threshold, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			return err
+		}
+		(*t)[pattern] = threshold
+	}
+	return nil
+}
+
+// dryRun is a flag.Value of the format to preview metrics in, "table" if
+// given without one.
+type dryRun string
+
+func (d *dryRun) String() string {
+	return string(*d)
+}
+
+func (d *dryRun) Set(value string) error {
+	switch value {
+	case "true":
+		*d = "table"
+	case "false":
+		*d = ""
+	case "table", "json":
+		*d = dryRun(value)
+	default:
+		return fmt.Errorf("expected \"table\" or \"json\" but got %q", value)
+	}
+	return nil
+}
+
+func (d *dryRun) IsBoolFlag() bool {
+	return true
+}
+
+// logLevel is a flag.Value of the least severe level to log.
+type logLevel string
+
+func (l *logLevel) String() string {
+	return string(*l)
+}
+
+func (l *logLevel) Set(value string) error {
+	switch value {
+	case "debug", "info", "warn", "error":
+		*l = logLevel(value)
+	default:
+		return fmt.Errorf("expected \"debug\", \"info\", \"warn\" or \"error\" but got %q", value)
+	}
+	return nil
+}
+
+// quiet is a flag.Value logging only errors, if true, sharing -log-level's
+// value.
+type quiet string
+
+func (q *quiet) String() string {
+	return strconv.FormatBool(*q == "error")
+}
+
+func (q *quiet) Set(value string) error {
+	on, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	if on {
+		*q = "error"
+	} else if *q == "error" {
+		*q = ""
+	}
+	return nil
+}
+
+func (q *quiet) IsBoolFlag() bool {
+	return true
+}
+
+// profile starts CPU profiling into cpuFile, unless it's "", and returns a
+// func that stops it and writes a heap profile into memFile, unless it's "":
+//
+//	snitch -n ECS/Snitch -cpuprofile cpu.pprof -memprofile mem.pprof
+//	go tool pprof -top cpu.pprof
+func profile(cpuFile, memFile string) (stop func()) {
+	var cpu *os.File
+	if cpuFile != "" {
+		var err error
+		if cpu, err = os.Create(cpuFile); err != nil {
+			log.Printf("Failed to create CPU profile %q: %s", cpuFile, err)
+		} else if err = pprof.StartCPUProfile(cpu); err != nil {
+			log.Printf("Failed to start CPU profile: %s", err)
+			cpu.Close()
+			cpu = nil
+		}
+	}
+	return func() {
+		if cpu != nil {
+			pprof.StopCPUProfile()
+			cpu.Close()
+		}
+		if memFile == "" {
+			return
+		}
+		mem, err := os.Create(memFile)
+		if err != nil {
+			log.Printf("Failed to create heap profile %q: %s", memFile, err)
+			return
+		}
+		defer mem.Close()
+		runtime.GC() // Up-to-date statistics.
+		if err := pprof.WriteHeapProfile(mem); err != nil {
+			log.Printf("Failed to write heap profile: %s", err)
+		}
+	}
+}
+
+// clusters lists every ECS Cluster, its Container Instances and Tasks, and
+// whether measuring flags like -include, -exclude and -tag let it be measured:
+//
+//	snitch clusters -exclude 'ci-*'
+func clusters(args []string) {
+	flags := flag.NewFlagSet("clusters", flag.ExitOnError)
+	sn := newSnitcher(flags)
+	parse(flags, args)
+	summaries, err := sn.WithAWS().SummarizeClusters()
+	if err != nil {
+		log.Fatalln("Failed to list clusters:", err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "CLUSTER\tINSTANCES\tRUNNING\tPENDING\tMEASURED")
+	for _, summary := range summaries {
+		measured := "yes"
+		if summary.Skipped != "" {
+			measured = "no: " + summary.Skipped
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\n", summary.Name, summary.Instances, summary.RunningTasks, summary.PendingTasks, measured)
+	}
+	w.Flush()
+}
+
+// describe reports one cluster's container size and, per instance type,
+// registered and remaining slots and raw resources:
+//
+//	snitch describe my-cluster
+func describe(args []string) {
+	flags := flag.NewFlagSet("describe", flag.ExitOnError)
+	sn := newSnitcher(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: snitch describe [flags] <cluster>")
+		flags.PrintDefaults()
+	}
+	parse(flags, args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	description := sn.WithAWS().DescribeCluster(aws.String(flags.Arg(0)))
+	if description == nil {
+		os.Exit(1)
+	}
+	fmt.Printf("Cluster:         %s\n", description.Cluster)
+	fmt.Printf("Container size:  %d CPU Units, %d MiB\n", description.CPU, description.Memory)
+	fmt.Printf("Registered:      %d CPU Units, %d MiB\n", description.RegisteredCPU, description.RegisteredMemory)
+	fmt.Printf("Remaining:       %d CPU Units, %d MiB\n\n", description.RemainingCPU, description.RemainingMemory)
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "INSTANCE TYPE\tINSTANCES\tREGISTERED SLOTS\tREMAINING SLOTS\tREGISTERED CPU/MiB\tREMAINING CPU/MiB")
+	for _, described := range description.InstanceTypes {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d/%d\t%d/%d\n", described.InstanceType, described.Instances,
+			described.RegisteredSchedulable, described.RemainingSchedulable,
+			described.RegisteredCPU, described.RegisteredMemory, described.RemainingCPU, described.RemainingMemory)
+	}
+	w.Flush()
+}
+
+// explain shows, per Container Instance of -cluster, how many containers its
+// remaining CPU, Memory, host ports and ENIs each allow, and which binds:
+//
+//	snitch explain -cluster my-cluster -ports 80 -awsvpc
+func explain(args []string) {
+	flags := flag.NewFlagSet("explain", flag.ExitOnError)
+	sn := newSnitcher(flags)
+	var ports list
+	flags.Var(&ports, "ports", "static host ports each container reserves, like \"80,443\" (default: none)")
+	awsvpc := flags.Bool("awsvpc", false, "containers use \"awsvpc\" networking, each taking an ENI")
+	parse(flags, args)
+	if aws.StringValue(sn.Cluster) == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+	explanations := sn.WithAWS().Explain(sn.Cluster, ports, *awsvpc)
+	if explanations == nil {
+		os.Exit(1)
+	}
+	allowed := func(count int) string {
+		if count < 0 {
+			return "-"
+		}
+		return strconv.Itoa(count)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "EC2 INSTANCE\tINSTANCE TYPE\tBY CPU\tBY MEMORY\tBY PORTS\tBY ENIS\tSLOTS\tBOTTLENECK")
+	for _, explanation := range explanations {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%s\t%d\t%s\n", explanation.EC2InstanceID, explanation.InstanceType,
+			explanation.ByCPU, explanation.ByMemory, allowed(explanation.ByPorts), allowed(explanation.ByENIs),
+			explanation.Slots, strings.Join(explanation.Bottlenecks, ", "))
+	}
+	w.Flush()
+}
+
+// iamPolicy prints the least IAM policy measuring needs, configured by its
+// flags, as JSON:
+//
+//	snitch iam-policy -p -tag-dimension Team -sinks sinks.json
+func iamPolicy(args []string) {
+	flags := flag.NewFlagSet("iam-policy", flag.ExitOnError)
+	sn := newSnitcher(flags)
+	parse(flags, args)
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(sn.IAMPolicy())
+}
+
+// fit reports how many copies of a Task Definition each ECS Cluster can
+// schedule, and exits non-zero if the Task Definition can't be read:
+//
+//	snitch fit --task-definition my-family:42 --cluster foo
+func fit(args []string) {
+	flags := flag.NewFlagSet("fit", flag.ExitOnError)
+	sn := newSnitcher(flags)
+	taskDefinition := flags.String("task-definition", "", "family:revision or ARN of Task Definition to fit (required)")
+	parse(flags, args)
+	if *taskDefinition == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+	req := sn.WithAWS().DescribeRequirements(taskDefinition)
+	if req == nil {
+		os.Exit(1)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "CLUSTER\tREGISTERED\tREMAINING")
+	for name := range sn.Clusters() {
+		registered, remaining := sn.Fit(name, req)
+		fmt.Fprintf(w, "%s\t%d\t%d\n", *name, registered, remaining)
+	}
+	w.Flush()
+}
+
+// instance reports on one Container Instance, for deciding whether to drain it:
+//
+//	snitch instance -cluster my-cluster -arn 30ed79a6-8ecd-4d7e-89ed-1415960b679a
+func instance(args []string) {
+	flags := flag.NewFlagSet("instance", flag.ExitOnError)
+	sn := newSnitcher(flags)
+	arn := flags.String("arn", "", "ARN or ID of Container Instance to report on (required)")
+	parse(flags, args)
+	if aws.StringValue(sn.Cluster) == "" || *arn == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+	report := sn.WithAWS().MeasureInstance(sn.Cluster, arn)
+	if report == nil {
+		os.Exit(1)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "Container Instance:\t%s\n", report.ContainerInstanceArn)
+	fmt.Fprintf(w, "EC2 Instance:\t%s (%s)\n", report.EC2InstanceID, report.InstanceType)
+	fmt.Fprintf(w, "Status:\t%s (agent connected: %t)\n", report.Status, report.AgentConnected)
+	fmt.Fprintf(w, "Registered:\t%d CPU Units, %d MiB\n", report.RegisteredCPU, report.RegisteredMemory)
+	fmt.Fprintf(w, "Remaining:\t%d CPU Units, %d MiB\n", report.RemainingCPU, report.RemainingMemory)
+	fmt.Fprintf(w, "Container size:\t%d CPU Units, %d MiB\n", report.CPU, report.Memory)
+	fmt.Fprintf(w, "Schedulable:\t%d registered, %d remaining\n", report.RegisteredSchedulable, report.RemainingSchedulable)
+	fmt.Fprintf(w, "Tasks:\t%d running, %d pending\n", report.RunningTasks, report.PendingTasks)
+	for _, task := range report.Tasks {
+		fmt.Fprintf(w, "\t%s\n", task)
+	}
+	w.Flush()
+}
+
+// metrics documents every metric snitch can publish:
+//
+//	snitch metrics list
+func metrics(args []string) {
+	if len(args) != 1 || args[0] != "list" {
+		fmt.Fprintln(os.Stderr, "usage: snitch metrics list")
+		os.Exit(2)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tUNIT\tDIMENSIONS\tDESCRIPTION")
+	for _, metric := range snitch.Metrics {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", metric.Name, metric.Unit, strings.Join(metric.Dimensions, ","), metric.Description)
+	}
+	w.Flush()
+}
+
+// expressions prints Metric Math, as JSON for a CloudWatch Dashboard widget's
+// "metrics," deriving utilization and headroom from published metrics:
+//
+//	snitch expressions -n ECS/Snitch -cluster foo
+func expressions(args []string) {
+	flags := flag.NewFlagSet("expressions", flag.ExitOnError)
+	namespace := flags.String("n", "", "metrics namespace in CloudWatch (required)")
+	cluster := flags.String("cluster", "", "ECS Cluster to derive for (default: all together)")
+	period := flags.Int("period", 300, "period in seconds")
+	insights := flags.Bool("insights", false, "print Metrics Insights queries instead")
+	parse(flags, args)
+	if *namespace == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+	generated := snitch.Expressions(*namespace, *cluster, *period)
+	if *insights {
+		generated = snitch.InsightsQueries(*namespace, *cluster)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(generated)
+}
+
+// alarms syncs, by "sync", a CloudWatch alarm of each measured cluster's
+// RemainingSchedulable, deleting those of clusters gone, configured by
+// -config's "alarms" unless flags override it:
+//
+//	snitch alarms sync -n ECS/Snitch -alarm-threshold 5 -alarm-threshold 'prod-*=20' -alarm-action arn:aws:sns:us-east-1:123456789012:capacity
+func alarms(args []string) {
+	if len(args) == 0 || args[0] != "sync" {
+		fmt.Fprintln(os.Stderr, "usage: snitch alarms sync [flags]")
+		os.Exit(2)
+	}
+	flags := flag.NewFlagSet("alarms sync", flag.ExitOnError)
+	sn := newSnitcher(flags)
+	config := &snitch.AlarmConfig{}
+	flags.Var((*thresholds)(&config.Thresholds), "alarm-threshold", "RemainingSchedulable below which a cluster's alarm fires, like \"5\" or \"prod-*=20\" (repeatable)")
+	flags.IntVar(&config.Period, "alarm-period", 0, "seconds to sum RemainingSchedulable over (default 300)")
+	flags.IntVar(&config.EvaluationPeriods, "alarm-periods", 0, "periods in a row below threshold to fire (default 3)")
+	flags.Var((*list)(&config.Actions), "alarm-action", "SNS topic ARN to notify when alarms fire and recover (repeatable)")
+	flags.StringVar(&config.Prefix, "alarm-prefix", "", "prefix of alarms' names, whose alarms of clusters gone are deleted (default \"snitch-remaining-\")")
+	parse(flags, args[1:])
+	if configured := sn.Alarms; configured != nil {
+		if config.Thresholds == nil {
+			config.Thresholds = configured.Thresholds
+		}
+		if config.Period == 0 {
+			config.Period = configured.Period
+		}
+		if config.EvaluationPeriods == 0 {
+			config.EvaluationPeriods = configured.EvaluationPeriods
+		}
+		if config.Actions == nil {
+			config.Actions = configured.Actions
+		}
+		if config.Prefix == "" {
+			config.Prefix = configured.Prefix
+		}
+	}
+	sn.Alarms = config
+	synced, err := sn.WithAWS().SyncAlarms()
+	if synced != nil {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(synced)
+	}
+	if err != nil {
+		log.Fatalln("Failed to sync alarms:", err)
+	}
+}
+
+// loadmodel reports how much of a hypothetical Workload, read from a JSON file,
+// each ECS Cluster could place right now, across regions and accounts:
+//
+//	snitch loadmodel -f workload.json -regions us-east-1,eu-west-1
+func loadmodel(args []string) {
+	flags := flag.NewFlagSet("loadmodel", flag.ExitOnError)
+	base := newSnitcher(flags)
+	file := flags.String("f", "", "JSON file of Workload, like {\"services\": [{\"name\": \"api\", \"cpu\": 512, \"memory\": 1024, \"count\": 10}]} (required)")
+	parse(flags, args)
+	if *file == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+	contents, err := ioutil.ReadFile(*file)
+	if err != nil {
+		log.Fatalf("Failed to read %q: %s", *file, err)
+	}
+	workload := &snitch.Workload{}
+	if err := json.Unmarshal(contents, workload); err != nil {
+		log.Fatalf("Failed to parse %q: %s", *file, err)
+	}
+	base.WithAWS()
+	snitchers := []*snitch.Snitcher{base}
+	if len(base.Roles) > 0 {
+		snitchers = nil
+		for _, role := range base.Roles {
+			snitchers = append(snitchers, base.AsRole(role))
+		}
+	}
+	if regions := base.Regions; len(regions) > 0 {
+		if len(regions) == 1 && regions[0] == "all" {
+			regions = base.DescribeRegions()
+		}
+		var regional []*snitch.Snitcher
+		for _, sn := range snitchers {
+			for _, region := range regions {
+				regional = append(regional, sn.InRegion(region))
 			}
-			if !flag.Parsed() {
-				flag.Parse()
+		}
+		snitchers = regional
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "ROLE\tREGION\tCLUSTER\tSERVICE\tPLACED\tCOUNT\tFITS")
+	for _, sn := range snitchers {
+		for name := range sn.Clusters() {
+			placed := sn.PlaceWorkload(name, workload)
+			fits := workload.Fits(placed)
+			for _, service := range workload.Services {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\t%t\n", aws.StringValue(sn.Role), aws.StringValue(sn.Region), *name, service.Name, placed[service.Name], service.Count, fits)
 			}
-			snitch.Run(sn)
 		}
 	}
-	lambdaStart(snitch.Run)
+	w.Flush()
+}
+
+// daemon measures and publishes every -interval until SIGTERM or SIGINT, for
+// running as an ECS Service rather than on a schedule in Lambda:
+//
+//	snitch daemon -interval 60s -n ECS/Snitch -p
+func daemon(args []string) {
+	flags := flag.NewFlagSet("daemon", flag.ExitOnError)
+	sn := newSnitcher(flags)
+	interval := flags.Duration("interval", time.Minute, "how often to measure")
+	jitter := flags.Duration("jitter", 5*time.Second, "up to how long to wait beyond -interval, at random")
+	listen := flags.String("listen", "", "address to serve /clusters, /healthz and /readyz on, like \":8080\" (default: don't)")
+	listenGRPC := flags.String("grpc", "", "address to serve gRPC capacity queries on, like \":9090\" (default: don't)")
+	configRefresh := flags.Duration("config-refresh", 0, "how often to re-read -config, like from SSM, before a run (default: never)")
+	profile := flags.Bool("pprof", false, "also serve net/http/pprof's profiles at /debug/pprof/ on -listen")
+	parse(flags, args)
+	if *profile && *listen == "" {
+		log.Fatal("-pprof needs -listen")
+	}
+	sn.Health = snitch.NewHealth(3 * (*interval + *jitter))
+	sn.Progress = snitch.NewProgress()
+	api := &snitch.API{}
+	apiSink := &snitch.SinkConfig{Type: "api", Sink: api}
+	if *listen != "" || *listenGRPC != "" {
+		sn.Sinks = append(sn.Sinks, apiSink)
+	}
+	if *configRefresh > 0 {
+		c := flags.Lookup("config").Value.(*config)
+		refreshed := time.Now()
+		sn.Reconfigure = func(sn *snitch.Snitcher) {
+			if time.Since(refreshed) < *configRefresh {
+				return
+			}
+			refreshed = time.Now()
+			if err := c.apply(flags); err != nil {
+				log.Printf("Failed to refresh configuration: %s", err)
+				return
+			}
+			// Sinks configured anew lack the API's.
+			if *listen != "" || *listenGRPC != "" {
+				for _, sink := range sn.Sinks {
+					if sink == apiSink {
+						return
+					}
+				}
+				sn.Sinks = append(sn.Sinks, apiSink)
+			}
+		}
+	}
+	if *listenGRPC != "" {
+		listener, err := net.Listen("tcp", *listenGRPC)
+		if err != nil {
+			log.Fatalf("Failed to listen on %s: %s", *listenGRPC, err)
+		}
+		go func() {
+			log.Fatal(snitchgrpc.NewServer(api).Serve(listener))
+		}()
+	}
+	if *listen != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/clusters", api)
+		mux.Handle("/clusters/", api)
+		mux.Handle("/healthz", sn.Health)
+		mux.Handle("/readyz", sn.Health)
+		if *profile {
+			handlePprof(mux)
+		}
+		go func() {
+			log.Fatal(http.ListenAndServe(*listen, mux))
+		}()
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	stop := make(chan struct{})
+	go func() {
+		log.Printf("Received %s; stopping after this run", <-signals)
+		close(stop)
+	}()
+	snitch.RunEvery(sn, *interval, *jitter, stop)
+}
+
+// serve measures every -interval and serves the latest metrics to Prometheus.
+func serve(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	sn := newSnitcher(flags)
+	listen := flags.String("listen", ":9100", "address to serve Prometheus metrics at /metrics on")
+	interval := flags.Duration("interval", time.Minute, "how often to measure")
+	profile := flags.Bool("pprof", false, "also serve net/http/pprof's profiles at /debug/pprof/ on -listen")
+	parse(flags, args)
+	sn.Health = snitch.NewHealth(3 * *interval)
+	sn.Progress = snitch.NewProgress()
+	exporter := snitch.NewExporter(sn)
+	go exporter.CollectEvery(*interval)
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exporter)
+	mux.Handle("/healthz", sn.Health)
+	mux.Handle("/readyz", sn.Health)
+	if *profile {
+		handlePprof(mux)
+	}
+	log.Fatal(http.ListenAndServe(*listen, mux))
+}
+
+// handlePprof serves net/http/pprof's profiles on mux, like of a daemon slow
+// to measure, for "go tool pprof http://localhost:8080/debug/pprof/profile".
+// They're on mux, not http.DefaultServeMux, so only with -pprof.
+func handlePprof(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/pprof/", httppprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", httppprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", httppprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", httppprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", httppprof.Trace)
+}
+
+// watch measures every -interval and redraws clusters' capacity, colored by
+// remaining slots, until interrupted:
+//
+//	snitch watch -interval 5s -cluster my-cluster
+func watch(args []string) {
+	flags := flag.NewFlagSet("watch", flag.ExitOnError)
+	sn := newSnitcher(flags)
+	interval := flags.Duration("interval", 10*time.Second, "how often to measure")
+	warning := flags.Float64("warning", 10, "color remaining slots at or below this yellow")
+	critical := flags.Float64("critical", 2, "color remaining slots at or below this red")
+	parse(flags, args)
+	sn.WithAWS()
+	colors := &snitch.WatchColors{Warning: *warning, Critical: *critical}
+	// https://no-color.org/
+	if os.Getenv("NO_COLOR") != "" {
+		colors = nil
+	}
+	for {
+		reports := snitch.Report(sn.Collect())
+		// Clear screen and move cursor home before redrawing.
+		fmt.Print("\033[H\033[2J")
+		fmt.Printf("Every %s: %s\n\n", *interval, time.Now().Format(time.RFC1123))
+		snitch.Watch(os.Stdout, reports, colors)
+		time.Sleep(*interval)
+	}
 }
@@ -1,6 +1,7 @@
 package snitch
 
 import (
+	"sort"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -19,33 +20,88 @@ type ClusterResources struct {
 	Memory     map[string]int
 	Registered map[string]int
 	Remaining  map[string]int
+	// ACTIVE Container Instances.
+	Instances map[string]int
+	// Running Tasks and how many "lowest common multiple" slots they occupy.
+	Running      map[string]int
+	RunningSlots map[string]int
+	// Subnets maps metric name to count per Container Instances' subnet.
+	Subnets map[string]map[string]int
+	// Totals are cluster-wide metrics, by metric name, lacking InstanceType.
+	Totals map[string]float64
+	// Dimensions, besides ClusterName, of Resources keyed by something other
+	// than instance type, like when partitioned by DimensionAttributes.
+	Dimensions map[string][]*cloudwatch.Dimension
+	// ClusterDimensions are added to every metric, like DimensionTags.
+	ClusterDimensions []*cloudwatch.Dimension
+	// Measured is when the cluster was measured, which metrics are timestamped
+	// with, or else when they're formatted.
+	Measured time.Time
 }
 
 // NewClusterResources creates a structure to map "RegisteredSchedulable" or
 // "RemainingSchedulable" to count per *instanceType.
 func NewClusterResources(cluster *string) *ClusterResources {
 	cr := &ClusterResources{
-		Cluster:    cluster,
-		Resources:  map[string]map[string]int{},
-		CPU:        map[string]int{},
-		Memory:     map[string]int{},
-		Registered: map[string]int{},
-		Remaining:  map[string]int{},
+		Cluster:      cluster,
+		Resources:    map[string]map[string]int{},
+		CPU:          map[string]int{},
+		Memory:       map[string]int{},
+		Registered:   map[string]int{},
+		Remaining:    map[string]int{},
+		Instances:    map[string]int{},
+		Running:      map[string]int{},
+		RunningSlots: map[string]int{},
+		Subnets:      map[string]map[string]int{},
+		Totals:       map[string]float64{},
+		Dimensions:   map[string][]*cloudwatch.Dimension{},
 	}
 	cr.Resources["LowestCommonMultipleCPU"] = cr.CPU
 	cr.Resources["LowestCommonMultipleMemory"] = cr.Memory
 	cr.Resources["RegisteredSchedulable"] = cr.Registered
 	cr.Resources["RemainingSchedulable"] = cr.Remaining
+	cr.Resources["ActiveInstanceCount"] = cr.Instances
+	cr.Resources["RunningTasks"] = cr.Running
+	cr.Resources["RunningTaskSlots"] = cr.RunningSlots
 	return cr
 }
 
-// ToMetricData formats metrics as AWS CloudWatch-compatible metric data.
+// unit figures out the CloudWatch unit of a metric from its registration.
+func unit(metricName string) *string {
+	if metric := LookupMetric(metricName); metric != nil {
+		return aws.String(metric.Unit)
+	}
+	return aws.String("Count")
+}
+
+// FullPercent calculates how full a cluster is, from 0 to 100, as
+// 100*(1 - RemainingSchedulable/RegisteredSchedulable) across instance types.
+// A cluster without registered capacity can't schedule anything, so it's full.
+func (cr *ClusterResources) FullPercent() float64 {
+	registered, remaining := 0, 0
+	for _, count := range cr.Registered {
+		registered += count
+	}
+	for _, count := range cr.Remaining {
+		remaining += count
+	}
+	if registered == 0 {
+		return 100
+	}
+	return 100 * (1 - float64(remaining)/float64(registered))
+}
+
+// ToMetricData formats metrics as AWS CloudWatch-compatible metric data,
+// sorted by metric name, then dimensions, like instance type.
 func (cr *ClusterResources) ToMetricData() (metricData []*cloudwatch.MetricDatum) {
 	clusterDimension := &cloudwatch.Dimension{
 		Name:  aws.String("ClusterName"),
 		Value: cr.Cluster,
 	}
-	timestamp := aws.Time(time.Now())
+	timestamp := aws.Time(cr.Measured)
+	if cr.Measured.IsZero() {
+		timestamp = aws.Time(time.Now())
+	}
 	for metricName, metricResources := range cr.Resources {
 		for instanceType, value := range metricResources {
 			dimensions := []*cloudwatch.Dimension{
@@ -55,15 +111,77 @@ func (cr *ClusterResources) ToMetricData() (metricData []*cloudwatch.MetricDatum
 					Value: aws.String(instanceType),
 				},
 			}
+			if partition, ok := cr.Dimensions[instanceType]; ok {
+				dimensions = append([]*cloudwatch.Dimension{clusterDimension}, partition...)
+			}
+			datum := &cloudwatch.MetricDatum{
+				MetricName: aws.String(metricName),
+				Dimensions: dimensions,
+				Timestamp:  timestamp,
+				Value:      aws.Float64(float64(value)),
+				Unit:       unit(metricName),
+			}
+			metricData = append(metricData, datum)
+		}
+	}
+	for metricName, metricSubnets := range cr.Subnets {
+		for subnet, value := range metricSubnets {
+			dimensions := []*cloudwatch.Dimension{
+				clusterDimension,
+				{
+					Name:  aws.String("SubnetId"),
+					Value: aws.String(subnet),
+				},
+			}
 			datum := &cloudwatch.MetricDatum{
 				MetricName: aws.String(metricName),
 				Dimensions: dimensions,
 				Timestamp:  timestamp,
 				Value:      aws.Float64(float64(value)),
-				Unit:       aws.String("Count"),
+				Unit:       unit(metricName),
 			}
 			metricData = append(metricData, datum)
 		}
 	}
+	for metricName, value := range cr.Totals {
+		datum := &cloudwatch.MetricDatum{
+			MetricName: aws.String(metricName),
+			Dimensions: []*cloudwatch.Dimension{clusterDimension},
+			Timestamp:  timestamp,
+			Value:      aws.Float64(value),
+			Unit:       unit(metricName),
+		}
+		metricData = append(metricData, datum)
+	}
+	for _, datum := range metricData {
+		datum.Dimensions = append(datum.Dimensions, cr.ClusterDimensions...)
+	}
+	// Maps iterate randomly, but dry runs, diffs and batches read better in
+	// order, by metric name, then dimensions, like instance type.
+	sortMetricData(metricData)
 	return
 }
+
+// sortMetricData orders metric data by cluster, metric name, then dimensions,
+// rather than by which cluster, region or account finished measuring first.
+func sortMetricData(metricData []*cloudwatch.MetricDatum) {
+	clusters := make(map[*cloudwatch.MetricDatum]string, len(metricData))
+	keys := make(map[*cloudwatch.MetricDatum]string, len(metricData))
+	for _, datum := range metricData {
+		for _, dimension := range datum.Dimensions {
+			if aws.StringValue(dimension.Name) == "ClusterName" {
+				clusters[datum] = aws.StringValue(dimension.Value)
+			}
+		}
+		keys[datum] = dimensionsKey(datum.Dimensions)
+	}
+	sort.Slice(metricData, func(i, j int) bool {
+		if cluster, other := clusters[metricData[i]], clusters[metricData[j]]; cluster != other {
+			return cluster < other
+		}
+		if name, other := aws.StringValue(metricData[i].MetricName), aws.StringValue(metricData[j].MetricName); name != other {
+			return name < other
+		}
+		return keys[metricData[i]] < keys[metricData[j]]
+	})
+}
@@ -1,6 +1,7 @@
 package snitch
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -22,6 +23,12 @@ func TestToMetricData(t *testing.T) {
 	expected.Memory[expectedInstanceType] += expectedMemory
 	expected.Registered[expectedInstanceType] += expectedRegisteredSchedulable
 	expected.Remaining[expectedInstanceType] += expectedRemainingSchedulable
+	expectedActiveInstanceCount := 2
+	expected.Instances[expectedInstanceType] += expectedActiveInstanceCount
+	expectedRunningTasks := 20
+	expectedRunningTaskSlots := expectedRegisteredSchedulable - expectedRemainingSchedulable
+	expected.Running[expectedInstanceType] += expectedRunningTasks
+	expected.RunningSlots[expectedInstanceType] += expectedRunningTaskSlots
 	metricData := expected.ToMetricData()
 	for _, datum := range metricData {
 		switch *datum.MetricName {
@@ -41,6 +48,18 @@ func TestToMetricData(t *testing.T) {
 			if expectedRemainingSchedulable != int(*datum.Value) {
 				t.Errorf("Expected %d RemainingSchedulable but got %d", expectedRemainingSchedulable, int(*datum.Value))
 			}
+		case "ActiveInstanceCount":
+			if expectedActiveInstanceCount != int(*datum.Value) {
+				t.Errorf("Expected %d ActiveInstanceCount but got %d", expectedActiveInstanceCount, int(*datum.Value))
+			}
+		case "RunningTasks":
+			if expectedRunningTasks != int(*datum.Value) {
+				t.Errorf("Expected %d RunningTasks but got %d", expectedRunningTasks, int(*datum.Value))
+			}
+		case "RunningTaskSlots":
+			if expectedRunningTaskSlots != int(*datum.Value) {
+				t.Errorf("Expected %d RunningTaskSlots but got %d", expectedRunningTaskSlots, int(*datum.Value))
+			}
 		}
 		if len(datum.Dimensions) != expectedNumberOfDimensions {
 			t.Error("Expected", expectedNumberOfDimensions, "dimensions, but got:", datum.GoString())
@@ -71,11 +90,110 @@ func TestToMetricData(t *testing.T) {
 		if expectedInstanceType != actualInstanceType {
 			t.Errorf("Expected InstanceType %q but got %q", expectedInstanceType, actualInstanceType)
 		}
-		if "Count" != *datum.Unit {
-			t.Errorf("Expected Unit to be Count, but it's %q", *datum.Unit)
+		expectedUnit := "Count"
+		switch *datum.MetricName {
+		case "LowestCommonMultipleCPU":
+			expectedUnit = "None"
+		case "LowestCommonMultipleMemory":
+			expectedUnit = "Megabytes"
+		}
+		if expectedUnit != *datum.Unit {
+			t.Errorf("Expected %s Unit to be %s, but it's %q", *datum.MetricName, expectedUnit, *datum.Unit)
 		}
 		if beforeTimestamp.After(*datum.Timestamp) {
 			t.Errorf("Expected Timestamp to be _after_ %q but got %q", beforeTimestamp, *datum.Timestamp)
 		}
 	}
 }
+
+// TestToMetricDataTotals verifies cluster-wide metrics lack InstanceType.
+func TestToMetricDataTotals(t *testing.T) {
+	cr := NewClusterResources(aws.String("my-totalled-cluster"))
+	cr.Totals["SomethingClusterWide"] = 42
+	metricData := cr.ToMetricData()
+	if len(metricData) != 1 {
+		t.Fatalf("expected 1 data point but got %d", len(metricData))
+	}
+	datum := metricData[0]
+	if *datum.MetricName != "SomethingClusterWide" || *datum.Value != 42 {
+		t.Errorf("expected SomethingClusterWide of 42 but got %s", datum.GoString())
+	}
+	if len(datum.Dimensions) != 1 || *datum.Dimensions[0].Name != "ClusterName" {
+		t.Errorf("expected ClusterName as only dimension but got %s", datum.GoString())
+	}
+}
+
+// TestToMetricDataOrder guards against map iteration order leaking into
+// dry runs and batches: data points come by metric name, then dimensions.
+func TestToMetricDataOrder(t *testing.T) {
+	cr := NewClusterResources(aws.String("my-ordered-cluster"))
+	for _, instanceType := range []string{"m5.large", "c5.xlarge", "r5.large", "c5.large"} {
+		cr.Registered[instanceType] = 10
+		cr.Remaining[instanceType] = 5
+	}
+	cr.Subnets["SubnetAvailableIPs"] = map[string]int{"subnet-b": 1, "subnet-a": 2}
+	cr.Totals["PendingTasks"] = 1
+	cr.Totals["DisconnectedAgents"] = 0
+	expected := []string{
+		"DisconnectedAgents",
+		"PendingTasks",
+		"RegisteredSchedulable c5.large",
+		"RegisteredSchedulable c5.xlarge",
+		"RegisteredSchedulable m5.large",
+		"RegisteredSchedulable r5.large",
+		"RemainingSchedulable c5.large",
+		"RemainingSchedulable c5.xlarge",
+		"RemainingSchedulable m5.large",
+		"RemainingSchedulable r5.large",
+		"SubnetAvailableIPs subnet-a",
+		"SubnetAvailableIPs subnet-b",
+	}
+	for run := 0; run < 10; run++ {
+		var actual []string
+		for _, datum := range cr.ToMetricData() {
+			name := aws.StringValue(datum.MetricName)
+			if len(datum.Dimensions) > 1 {
+				name += " " + aws.StringValue(datum.Dimensions[1].Value)
+			}
+			actual = append(actual, name)
+		}
+		if strings.Join(actual, ",") != strings.Join(expected, ",") {
+			t.Fatalf("expected %q but got %q", expected, actual)
+		}
+	}
+}
+
+// TestToMetricDataMeasured verifies metrics are timestamped with when their
+// cluster was measured, rather than when they're formatted.
+func TestToMetricDataMeasured(t *testing.T) {
+	cr := NewClusterResources(aws.String("my-measured-cluster"))
+	cr.Registered["m5.large"] = 10
+	cr.Subnets["SubnetAvailableIPs"] = map[string]int{"subnet-a": 2}
+	cr.Totals["PendingTasks"] = 1
+	cr.Measured = time.Now().Add(-time.Minute)
+	for _, datum := range cr.ToMetricData() {
+		if !aws.TimeValue(datum.Timestamp).Equal(cr.Measured) {
+			t.Errorf("expected %s timestamped %s but got %s", aws.StringValue(datum.MetricName), cr.Measured, aws.TimeValue(datum.Timestamp))
+		}
+	}
+}
+
+func TestClusterResources_FullPercent(t *testing.T) {
+	cr := NewClusterResources(aws.String("my-full-cluster"))
+	if actual := cr.FullPercent(); actual != 100 {
+		t.Errorf("expected cluster without capacity to be 100%% full but got %v", actual)
+	}
+	cr.Registered["small"] = 30
+	cr.Registered["large"] = 10
+	cr.Remaining["small"] = 6
+	cr.Remaining["large"] = 4
+	if actual := cr.FullPercent(); actual != 75 {
+		t.Errorf("expected 75%% full but got %v", actual)
+	}
+	cr.Totals["ClusterFullPercent"] = cr.FullPercent()
+	for _, datum := range cr.ToMetricData() {
+		if *datum.MetricName == "ClusterFullPercent" && *datum.Unit != "Percent" {
+			t.Errorf("expected ClusterFullPercent in Percent but got %q", *datum.Unit)
+		}
+	}
+}
@@ -5,7 +5,8 @@
 // and if reporting is enabled, measurements are published to CloudWatch.
 //
 // Example IAM permissions required to run (feel free to adjust "Resource"
-// appropriately):
+// appropriately), of which IAMPolicy, or `snitch iam-policy`, generates just
+// what a configuration needs:
 //	{
 //		"Version": "2012-10-17",
 //		"Statement": [
@@ -13,9 +14,71 @@
 //				"Sid": "PermitReadingFromECS",
 //				"Effect": "Allow",
 //				"Action": [
+//					"ecs:DescribeClusters",
 //					"ecs:DescribeContainerInstances",
+//					"ecs:DescribeServices",
+//					"ecs:DescribeTaskDefinition",
+//					"ecs:DescribeTasks",
 //					"ecs:ListClusters",
-//					"ecs:ListContainerInstances"
+//					"ecs:ListContainerInstances",
+//					"ecs:ListTasks"
+//				],
+//				"Resource": [
+//					"*"
+//				]
+//			},
+//			{
+//				"Sid": "PermitReadingSubnets",
+//				"Effect": "Allow",
+//				"Action": [
+//					"ec2:DescribeInstanceTypes",
+//					"ec2:DescribeInstances",
+//					"ec2:DescribeRegions",
+//					"ec2:DescribeSubnets"
+//				],
+//				"Resource": [
+//					"*"
+//				]
+//			},
+//			{
+//				"Sid": "PermitReadingScheduledScaling",
+//				"Effect": "Allow",
+//				"Action": [
+//					"application-autoscaling:DescribeScheduledActions"
+//				],
+//				"Resource": [
+//					"*"
+//				]
+//			},
+//			{
+//				"Sid": "PermitReadingFaultInjection",
+//				"Effect": "Allow",
+//				"Action": [
+//					"fis:ListExperiments"
+//				],
+//				"Resource": [
+//					"*"
+//				]
+//			},
+//			{
+//				"Sid": "PermitReadingSinkSecrets",
+//				"Effect": "Allow",
+//				"Action": [
+//					"secretsmanager:GetSecretValue"
+//				],
+//				"Resource": [
+//					"*"
+//				]
+//			},
+//			{
+//				"Sid": "PermitWritingToSinks",
+//				"Effect": "Allow",
+//				"Action": [
+//					"dynamodb:PutItem",
+//					"events:PutEvents",
+//					"s3:PutObject",
+//					"sns:Publish",
+//					"sqs:SendMessage"
 //				],
 //				"Resource": [
 //					"*"
@@ -39,42 +102,351 @@
 package snitch
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"path"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/processcreds"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/applicationautoscaling"
+	"github.com/aws/aws-sdk-go/service/applicationautoscaling/applicationautoscalingiface"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 	"github.com/aws/aws-sdk-go/service/ecs"
 	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+	"github.com/aws/aws-sdk-go/service/eventbridge"
+	"github.com/aws/aws-sdk-go/service/eventbridge/eventbridgeiface"
+	"github.com/aws/aws-sdk-go/service/fis"
+	"github.com/aws/aws-sdk-go/service/fis/fisiface"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/aws/aws-sdk-go/service/organizations/organizationsiface"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
 )
 
+// describeConcurrency bounds how many cohorts of a cluster's Tasks are
+// described at once.
+const describeConcurrency = 4
+
+// defaultConcurrency is how many clusters are measured at once, unless
+// Concurrency says otherwise.
+const defaultConcurrency = 8
+
 // Snitcher communicates with web services to collect or report data.
 type Snitcher struct {
 	// AWS clients from Go SDK, drawn from *iface to simplify testing.
-	CloudWatch cloudwatchiface.CloudWatchAPI
-	ECS        ecsiface.ECSAPI
-	// Namespace in CloudWatch to publish metrics to.
+	ApplicationAutoScaling applicationautoscalingiface.ApplicationAutoScalingAPI
+	CloudWatch             cloudwatchiface.CloudWatchAPI
+	DynamoDB               dynamodbiface.DynamoDBAPI
+	EC2                    ec2iface.EC2API
+	EventBridge            eventbridgeiface.EventBridgeAPI
+	ECS                    ecsiface.ECSAPI
+	FIS                    fisiface.FISAPI
+	Organizations          organizationsiface.OrganizationsAPI
+	S3                     s3iface.S3API
+	SecretsManager         secretsmanageriface.SecretsManagerAPI
+	SNS                    snsiface.SNSAPI
+	SQS                    sqsiface.SQSAPI
+	SSM                    ssmiface.SSMAPI
+	// AWS Region of clients WithAWS adds. Nil means AWS SDK's default.
+	Region *string
+	// Where clients WithAWS adds get credentials instead of AWS SDK's default
+	// chain: a named profile of shared config, like "prod", a command printing
+	// credentials, or a web identity token file and the IAM Role it assumes.
+	// CredentialProcess and WebIdentityTokenFile are only set by flags, not
+	// configuration or events, whose writers mustn't run commands.
+	Profile              *string
+	CredentialProcess    *string `json:"-"`
+	WebIdentityTokenFile *string `json:"-"`
+	WebIdentityRole      *string
+	// Endpoint URLs of ECS and CloudWatch clients WithAWS adds, like
+	// "http://localhost:4566" for LocalStack. Nil means AWS SDK's default.
+	ECSEndpoint        *string
+	CloudWatchEndpoint *string
+	// IAM Role clients WithAWS adds assume, with ExternalID if set. Nil means
+	// use credentials as they are.
+	Role       *string
+	ExternalID *string
+	// IAM Roles, like "arn:aws:iam::123456789012:role/snitch", to measure
+	// other accounts as. Their metrics get an "AccountId" dimension and are
+	// published from this account, unless PublishInAccounts is set.
+	Roles             []string
+	PublishInAccounts *bool
+	// Name of IAM Role, like "snitch", to add to Roles in every account of the
+	// AWS Organization, or only those directly in OrganizationalUnit and
+	// tagged with all of AccountTags. Nil means don't discover accounts.
+	OrganizationRole   *string
+	OrganizationalUnit *string
+	AccountTags        map[string]string
+	// AWS Regions to measure, like "us-east-1", or "all" enabled in the
+	// account. Their metrics get a "Region" dimension and are published from
+	// Region. Empty means measure just Region, without that dimension.
+	Regions []string
+	// Namespace in CloudWatch to publish metrics to, or a template of one per
+	// cluster, like "ECS/{cluster}", or "{team}/ECS", of each metric's "team"
+	// dimension, like DimensionTags adds.
 	Namespace *string
+	// More namespaces to publish every metric to as well, like while moving
+	// dashboards from one to another. Sinks, like "cloudwatch" or "otlp",
+	// also get every metric.
+	ExtraNamespaces []string
 	// Whether to publish metrics to CloudWatch.
 	ShouldPublish *bool
+	// Format, "table" or "json", to print metrics in rather than publishing
+	// them, previewing what would be published. Empty or nil means publish.
+	DryRun *string
+	// Whether to publish by writing CloudWatch Embedded Metric Format to
+	// stdout, like Lambda's logs, rather than calling PutMetricData.
+	EmbeddedMetricFormat *bool
+	// CPU Units and Memory (RAM in MiB) of container to measure against,
+	// instead of the "lowest common multiple" of running Tasks. Zero or nil
+	// means measure.
+	CPU    *int
+	Memory *int
+	// CPU Units and Memory (RAM in MiB) of container to measure against in
+	// clusters running no Tasks, which are otherwise skipped. Keeps alarms
+	// from going INSUFFICIENT_DATA.
+	DefaultCPU    *int
+	DefaultMemory *int
+	// Floor of CPU Units and Memory (RAM in MiB) for container size, so
+	// clusters running only tiny Tasks don't claim thousands fit.
+	MinCPU    *int
+	MinMemory *int
+	// Whether to ignore Tasks of DAEMON Services when measuring "lowest common
+	// multiple," since those run once per Container Instance regardless.
+	ExcludeDaemons *bool
+	// Whether to publish ClusterFullPercent, so you needn't use metric math.
+	MeasureFullPercent *bool
+	// Whether to count Tasks waiting to be placed or started.
+	MeasurePending *bool
+	// Whether to measure free IPs in Container Instances' subnets, which
+	// "awsvpc" Tasks can run out of before CPU or Memory.
+	MeasureSubnets *bool
+	// Whether to compare Application Auto Scaling scheduled scale-outs of ECS
+	// Services against remaining capacity.
+	MeasureScheduledScaling *bool
+	// Namespace in CloudWatch to publish a "Canary" metric to every run, so
+	// alarming on it missing catches snitch itself breaking.
+	CanaryNamespace *string
+	// Namespace in CloudWatch to publish snitch's own metrics about every run
+	// to, like RunDuration, APICalls and Throttles.
+	TelemetryNamespace *string
+	// Name of the only ECS Cluster to measure, without listing clusters, so
+	// IAM policy can be scoped to it. Empty or nil means discover clusters.
+	Cluster *string
+	// Whether to only discover clusters, measuring and publishing nothing,
+	// listing them among Summary's Results, like for a Step Functions Map
+	// state to measure each by Cluster.
+	DiscoverOnly *bool
+	// Patterns of cluster names to measure, and not to, as globs like "ci-*"
+	// or regular expressions between slashes like "/^ci-[0-9]+$/". Empty
+	// Include means every cluster not excluded.
+	Include []string
+	Exclude []string
+	// ECS Cluster tags, like "snitch:enabled" of "true", that clusters must
+	// all have to be measured. Empty means measure every cluster.
+	SelectTags map[string]string
+	// Keys of ECS Cluster tags, like "Team", whose values are added to every
+	// metric of the cluster as dimensions.
+	DimensionTags []string
+	// Weights of schedulable containers per instance type, like 0 to ignore
+	// soon-to-be-retired "c4.large" capacity. Unlisted types weigh 1.
+	InstanceTypeWeights map[string]float64
+	// Whether to label metrics measured during AWS Fault Injection Simulator
+	// experiments, underway as a run begins, with a "FaultInjectionExperiment"
+	// dimension.
+	MeasureFaultInjection *bool
+	// Progress of clusters measured, if not nil, shared by copies of Snitcher
+	// for other regions and accounts.
+	Progress *Progress `json:"-"`
+	// Health of runs by RunEvery, or collections by Exporter, if not nil,
+	// for serving health checks.
+	Health *Health `json:"-"`
+	// Called by RunEvery before every run, if not nil, to update Snitcher,
+	// like re-reading its configuration from SSM Parameter Store. AWS clients
+	// are added once, before the first, so fields configuring them, like
+	// Region, ECSEndpoint or PublishRetries, don't change them.
+	Reconfigure func(*Snitcher) `json:"-"`
+	// Where LoadConfig read Snitcher's configuration from, like
+	// "ssm:/snitch/prod", so IAMPolicy permits reading it.
+	ConfigLocation *string `json:"-"`
+	// Summary of runs, if not nil, counting what's measured, published and
+	// failed, like for RunSummary.
+	Summary *Summary `json:"-"`
+	// Whether to trace runs, each cluster's measurement and calls to ECS, EC2
+	// and CloudWatch with AWS X-Ray, under Context's segment, like Lambda's.
+	// Needs build tag "xray".
+	Trace   *bool
+	Context context.Context `json:"-"`
+	// Time after which to stop measuring clusters not yet started, publishing
+	// what's measured, like shortly before Lambda's deadline. Zero means never.
+	Deadline time.Time `json:"-"`
+	// Logger logs what snitch does, if not nil, like to zap. Otherwise, by
+	// LogFormat, "json" logs a JSON object per line to stderr, and "text", the
+	// default, logs with the standard library's log package.
+	Logger    Logger `json:"-"`
+	LogFormat *string
+	// How many times to retry ECS calls that are throttled, backing off
+	// exponentially, before giving up on a cluster. Default is 5.
+	ECSRetries *int
+	// How many times to retry PutMetricData calls that fail retryably,
+	// backing off exponentially. Default is 3.
+	PublishRetries *int
+	// Whether to keep batches of metrics that failed to publish, even after
+	// retrying, to retry at the end of the run and, if they fail again, the
+	// next, like RunEvery's. Only batches failing retryably, like throttled,
+	// are kept.
+	CarryOverFailures *bool
+	carryOver         *carryOver
+	// Whether to publish, and send to Sinks, each cluster's metrics as soon as
+	// they're measured, rather than all of them at the end of a run, so a run
+	// dying midway still publishes what it measured. Sinks reporting whole
+	// runs, like "slack", report each cluster separately, "s3" in an object
+	// per cluster. Sinks writing one document, "csv", "json" and "prometheus",
+	// and DryRun "json", can't stream, so runs streaming to them fail.
+	Stream *bool
+	stream func([]*cloudwatch.MetricDatum) // Takes each cluster's metrics.
+	// Whether to publish metrics by instance type to CloudWatch as a
+	// StatisticSet per cluster, of their sample count, sum, minimum and
+	// maximum, rather than a data point per instance type.
+	StatisticSets *bool
+	// How many data points to publish per PutMetricData call, up to 1000.
+	// Default is 20.
+	BatchSize *int
+	// Names to publish metrics as, by their registered names, like
+	// "RemainingSchedulable" of "ecs.capacity.remaining_slots", to follow
+	// naming conventions. MetricPrefix prefixes every published name.
+	MetricNames  map[string]string
+	MetricPrefix *string
+	// Whether to publish LowestCommonMultipleCPU and LowestCommonMultipleMemory
+	// with Unit "Count", like snitch used to, for dashboards and alarms that
+	// expect it, rather than "None" and "Megabytes".
+	CountUnits *bool
+	// Where to keep metrics' values last published, to skip publishing those
+	// unchanged until DeltaMaxAge, like "15m", has passed: a file,
+	// "s3://my-bucket/snitch.json" or "dynamodb:my-table". Default is to
+	// publish every metric every run.
+	DeltaState  *string
+	DeltaMaxAge *string
+	deltas      *deltas
+	// How many clusters to measure at once, per region and account, so many
+	// clusters don't get ECS throttled. Default is 8.
+	Concurrency *int
+	// Least severe level to log: "debug", which also logs every published
+	// metric, "info", the default, "warn" or "error".
+	LogLevel *string
+	// Names of Container Instances' ECS Attributes, like "stack" or "team",
+	// whose values partition metrics per instance type as extra dimensions.
+	DimensionAttributes []string
+	// Sinks also sent measured metrics, besides publishing to CloudWatch.
+	Sinks    []*SinkConfig
+	sinkRole string // Role of whose account metrics sent to Sinks are, if any.
+	// Alarms SyncAlarms keeps of clusters' RemainingSchedulable.
+	Alarms *AlarmConfig
 }
 
 // WithAWS adds AWS clients to Snitcher.
 func (sn *Snitcher) WithAWS() *Snitcher {
-	conf := &aws.Config{}
-	sess := session.Must(session.NewSession(conf))
+	sess := sn.session()
+	if sn.ApplicationAutoScaling == nil {
+		sn.ApplicationAutoScaling = applicationautoscalingiface.ApplicationAutoScalingAPI(applicationautoscaling.New(sess))
+	}
 	if sn.CloudWatch == nil {
-		sn.CloudWatch = cloudwatchiface.CloudWatchAPI(cloudwatch.New(sess))
+		client := cloudwatch.New(sess, sn.withPublishRetryer(&aws.Config{Endpoint: sn.CloudWatchEndpoint}))
+		sn.instrument(client.Client)
+		sn.count(client.Client)
+		sn.CloudWatch = cloudwatchiface.CloudWatchAPI(client)
+	}
+	if sn.DynamoDB == nil {
+		sn.DynamoDB = dynamodbiface.DynamoDBAPI(dynamodb.New(sess))
+	}
+	if sn.EC2 == nil {
+		client := ec2.New(sess)
+		sn.instrument(client.Client)
+		sn.count(client.Client)
+		sn.EC2 = ec2iface.EC2API(client)
 	}
 	if sn.ECS == nil {
-		sn.ECS = ecsiface.ECSAPI(ecs.New(sess))
+		client := ecs.New(sess, sn.withECSRetryer(&aws.Config{Endpoint: sn.ECSEndpoint}))
+		sn.instrument(client.Client)
+		sn.count(client.Client)
+		sn.ECS = ecsiface.ECSAPI(client)
+	}
+	if sn.EventBridge == nil {
+		sn.EventBridge = eventbridgeiface.EventBridgeAPI(eventbridge.New(sess))
+	}
+	if sn.FIS == nil {
+		sn.FIS = fisiface.FISAPI(fis.New(sess))
+	}
+	if sn.Organizations == nil {
+		sn.Organizations = organizationsiface.OrganizationsAPI(organizations.New(sess))
+	}
+	if sn.S3 == nil {
+		sn.S3 = s3iface.S3API(s3.New(sess))
+	}
+	if sn.SecretsManager == nil {
+		sn.SecretsManager = secretsmanageriface.SecretsManagerAPI(secretsmanager.New(sess))
+	}
+	if sn.SNS == nil {
+		sn.SNS = snsiface.SNSAPI(sns.New(sess))
+	}
+	if sn.SQS == nil {
+		sn.SQS = sqsiface.SQSAPI(sqs.New(sess))
+	}
+	if sn.SSM == nil {
+		sn.SSM = ssmiface.SSMAPI(ssm.New(sess))
 	}
 	return sn
 }
 
+// session creates an AWS session in Region with credentials of, in order of
+// preference, CredentialProcess, WebIdentityTokenFile or Profile, falling
+// back on AWS SDK's default chain, then assuming Role, if set.
+func (sn *Snitcher) session() *session.Session {
+	conf := &aws.Config{Region: sn.Region}
+	if command := aws.StringValue(sn.CredentialProcess); command != "" {
+		conf.Credentials = processcreds.NewCredentials(command)
+	} else if tokenFile := aws.StringValue(sn.WebIdentityTokenFile); tokenFile != "" {
+		conf.Credentials = stscreds.NewWebIdentityCredentials(sn.newSession(conf), aws.StringValue(sn.WebIdentityRole), "snitch", tokenFile)
+	}
+	if role := aws.StringValue(sn.Role); role != "" {
+		conf.Credentials = stscreds.NewCredentials(sn.newSession(conf), role, func(provider *stscreds.AssumeRoleProvider) {
+			provider.ExternalID = sn.ExternalID
+		})
+	}
+	return sn.newSession(conf)
+}
+
+// newSession creates an AWS session of conf, reading Profile from shared
+// config if it's set.
+func (sn *Snitcher) newSession(conf *aws.Config) *session.Session {
+	options := session.Options{Config: *conf}
+	if profile := aws.StringValue(sn.Profile); profile != "" {
+		options.Profile = profile
+		options.SharedConfigState = session.SharedConfigEnable
+	}
+	return session.Must(session.NewSessionWithOptions(options))
+}
+
 // DiscoverTasks communicates pages of ECS Tasks' ARNs discovered in cluster.
 //
 // While I'm no fan of arrays of string pointers, that's what AWS SDK outputs.
@@ -96,7 +468,8 @@ func (sn *Snitcher) DiscoverTasks(cluster *string) <-chan []*string {
 			},
 		)
 		if err != nil {
-			log.Printf("Failed to ListTasksPages for %q: %s", *cluster, err)
+			sn.logger().Errorf("Failed to ListTasksPages for %q: %s", *cluster, err)
+			sn.Summary.failCluster(*cluster, "ListTasks of %q: %s", *cluster, err)
 		}
 		close(com)
 	}()
@@ -107,25 +480,33 @@ func (sn *Snitcher) DiscoverTasks(cluster *string) <-chan []*string {
 // for specified tasks within a cluster.
 //
 // Supply ECS cluster as aws.String() and ECS tasks are arrays communicated
-// from DiscoverTasks.
+// from DiscoverTasks. Tasks of DAEMON Services are skipped if Snitcher's
+// ExcludeDaemons is set.
 func (sn *Snitcher) MeasureResources(cluster *string, tasks []*string) (cpu, memory int) {
-	input := &ecs.DescribeTasksInput{
-		Cluster: cluster,
-		Tasks:   tasks,
-	}
-	output, err := sn.ECS.DescribeTasks(input)
-	if err != nil {
-		log.Printf("Failed to DescribeTasks on %q: %s", *cluster, err)
-		return
+	cpu, memory, _ = sn.measureResources(cluster, tasks)
+	return
+}
+
+// measureResources is MeasureResources, also counting pending Tasks among
+// those described, daemons' too.
+func (sn *Snitcher) measureResources(cluster *string, tasks []*string) (cpu, memory, pending int) {
+	described := sn.DescribeTasks(cluster, tasks)
+	pending = countPending(described)
+	daemons := map[string]bool{}
+	if aws.BoolValue(sn.ExcludeDaemons) {
+		daemons = sn.DescribeDaemonServices(cluster, serviceNames(described))
 	}
-	for _, task := range output.Tasks {
+	for _, task := range described {
+		if daemons[serviceName(task)] {
+			continue
+		}
 		taskCPU, err := strconv.Atoi(*task.Cpu)
 		if err != nil {
-			log.Printf("Failed to convert %q CPU to int: %s", *cluster, err)
+			sn.logger().Errorf("Failed to convert %q CPU to int: %s", *cluster, err)
 		}
 		taskMemory, err := strconv.Atoi(*task.Memory)
 		if err != nil {
-			log.Printf("Failed to convert %q Memory to int: %s", *cluster, err)
+			sn.logger().Errorf("Failed to convert %q Memory to int: %s", *cluster, err)
 		}
 		if taskCPU > cpu {
 			cpu = taskCPU
@@ -134,7 +515,114 @@ func (sn *Snitcher) MeasureResources(cluster *string, tasks []*string) (cpu, mem
 			memory = taskMemory
 		}
 	}
-	log.Printf("%q largest container in cohort has %d CPU Units, %d MiB RAM", *cluster, cpu, memory)
+	sn.logger().Infof("%q largest container in cohort has %d CPU Units, %d MiB RAM", *cluster, cpu, memory)
+	return
+}
+
+// DescribeTasks gathers descriptions of ECS Tasks, like a cohort
+// communicated from DiscoverTasks.
+//
+// Requires IAM permission "ecs:DescribeTasks".
+func (sn *Snitcher) DescribeTasks(cluster *string, tasks []*string) []*ecs.Task {
+	if len(tasks) == 0 {
+		return []*ecs.Task{}
+	}
+	input := &ecs.DescribeTasksInput{
+		Cluster: cluster,
+		Tasks:   tasks,
+	}
+	output, err := sn.ECS.DescribeTasks(input)
+	if err != nil {
+		sn.logger().Errorf("Failed to DescribeTasks on %q: %s", *cluster, err)
+		sn.Summary.failCluster(*cluster, "DescribeTasks of %q: %s", *cluster, err)
+		return []*ecs.Task{}
+	}
+	return output.Tasks
+}
+
+// MeasurePendingTasks adds "PendingTasks" to cluster Totals: how many Tasks
+// are provisioning or waiting to start. Pending pressure plus remaining
+// headroom is what capacity-triggered scale-out needs.
+//
+// ListTasks can't filter by last status, so this describes every Task again.
+// MeasureCluster calls it only when CPU and Memory are given, since measuring
+// container size describes every Task already.
+func (sn *Snitcher) MeasurePendingTasks(cr *ClusterResources) {
+	pending := 0
+	for tasks := range sn.DiscoverTasks(cr.Cluster) {
+		pending += countPending(sn.DescribeTasks(cr.Cluster, tasks))
+	}
+	sn.logger().Infof("%q has %d pending Tasks", *cr.Cluster, pending)
+	cr.Totals["PendingTasks"] = float64(pending)
+}
+
+// countPending counts Tasks provisioning or waiting to start.
+func countPending(tasks []*ecs.Task) (pending int) {
+	for _, task := range tasks {
+		switch aws.StringValue(task.LastStatus) {
+		case "PROVISIONING", "PENDING", "ACTIVATING":
+			pending++
+		}
+	}
+	return
+}
+
+// DescribeServices describes named ECS Services in a cluster.
+//
+// Requires IAM permission "ecs:DescribeServices".
+func (sn *Snitcher) DescribeServices(cluster *string, names []*string) (services []*ecs.Service) {
+	batchSize := 10 // DescribeServices accepts at most 10 Services.
+	for i := 0; i < len(names); i += batchSize {
+		end := i + batchSize
+		if end > len(names) {
+			end = len(names)
+		}
+		input := &ecs.DescribeServicesInput{
+			Cluster:  cluster,
+			Services: names[i:end],
+		}
+		output, err := sn.ECS.DescribeServices(input)
+		if err != nil {
+			sn.logger().Errorf("Failed to DescribeServices in %q! %s", *cluster, err)
+			sn.Summary.failCluster(*cluster, "DescribeServices of %q: %s", *cluster, err)
+			continue
+		}
+		services = append(services, output.Services...)
+	}
+	return
+}
+
+// DescribeDaemonServices finds which of the named ECS Services in a cluster
+// schedule Tasks with the DAEMON strategy.
+func (sn *Snitcher) DescribeDaemonServices(cluster *string, names []*string) map[string]bool {
+	daemons := map[string]bool{}
+	for _, service := range sn.DescribeServices(cluster, names) {
+		if aws.StringValue(service.SchedulingStrategy) == "DAEMON" {
+			daemons[aws.StringValue(service.ServiceName)] = true
+		}
+	}
+	return daemons
+}
+
+// serviceName figures out which ECS Service started a Task from its Group,
+// like "service:my-service". Tasks not started by a Service have "".
+func serviceName(task *ecs.Task) string {
+	group := aws.StringValue(task.Group)
+	if !strings.HasPrefix(group, "service:") {
+		return ""
+	}
+	return strings.TrimPrefix(group, "service:")
+}
+
+// serviceNames lists unique ECS Services that started tasks.
+func serviceNames(tasks []*ecs.Task) (names []*string) {
+	seen := map[string]bool{}
+	for _, task := range tasks {
+		if name := serviceName(task); name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, aws.String(name))
+		}
+	}
 	return
 }
 
@@ -151,7 +639,8 @@ func (sn Snitcher) ListContainerInstances(cluster *string) []*string {
 	}
 	output, err := sn.ECS.ListContainerInstances(input)
 	if err != nil {
-		log.Printf("Failed to ListContainerInstances in %q! %s", *cluster, err)
+		sn.logger().Errorf("Failed to ListContainerInstances in %q! %s", *cluster, err)
+		sn.Summary.failCluster(*cluster, "ListContainerInstances of %q: %s", *cluster, err)
 		return []*string{}
 	}
 	return output.ContainerInstanceArns
@@ -167,7 +656,8 @@ func (sn *Snitcher) DescribeContainerInstances(cluster *string, instances []*str
 	}
 	output, err := sn.ECS.DescribeContainerInstances(input)
 	if err != nil {
-		log.Printf("Failed to DescribeContainerInstances for %q! %s", *cluster, err)
+		sn.logger().Errorf("Failed to DescribeContainerInstances for %q! %s", *cluster, err)
+		sn.Summary.failCluster(*cluster, "DescribeContainerInstances of %q: %s", *cluster, err)
 		return []*ecs.ContainerInstance{}
 	}
 	return output.ContainerInstances
@@ -181,23 +671,119 @@ func (sn *Snitcher) DescribeContainerInstances(cluster *string, instances []*str
 // EC2 Instance Type is gleaned from ECS Attribute "ecs.instance-type", which I
 // think is supplied by ECS.
 func (sn *Snitcher) DescribeResourcesByInstanceType(cluster *string, instances []*string, cpu, memory int) []*cloudwatch.MetricDatum {
+	return sn.DescribeClusterResources(cluster, instances, cpu, memory).ToMetricData()
+}
+
+// DescribeClusterResources is DescribeResourcesByInstanceType before it's
+// formatted as metric data, for adding cluster-wide metrics to.
+//
+// Container Instances with disconnected agents count as registered but not
+// remaining, and as "DisconnectedAgents" in Totals. Registered and remaining
+// counts are weighed by InstanceTypeWeights; running ones aren't.
+func (sn *Snitcher) DescribeClusterResources(cluster *string, instances []*string, cpu, memory int) *ClusterResources {
+	return sn.MeasureContainerInstances(cluster, sn.DescribeContainerInstances(cluster, instances), cpu, memory)
+}
+
+// MeasureContainerInstances is DescribeClusterResources for Container
+// Instances already described.
+func (sn *Snitcher) MeasureContainerInstances(cluster *string, containers []*ecs.ContainerInstance, cpu, memory int) *ClusterResources {
 	cr := NewClusterResources(cluster)
-	for _, container := range sn.DescribeContainerInstances(cluster, instances) {
-		instanceType := getInstanceType(container.Attributes)
+	disconnected := 0
+	for _, container := range containers {
+		instanceType := sn.partition(cr, container.Attributes)
 		// Look, Ma, no KeyError: https://play.golang.org/p/jI4VOhMjcNc
 		cr.CPU[instanceType] = cpu
 		cr.Memory[instanceType] = memory
-		cr.Registered[instanceType] += ContainersPossible(cpu, memory, container.RegisteredResources)
-		cr.Remaining[instanceType] += ContainersPossible(cpu, memory, container.RemainingResources)
+		registered := ContainersPossible(cpu, memory, container.RegisteredResources)
+		remaining := ContainersPossible(cpu, memory, container.RemainingResources)
+		cr.Instances[instanceType]++
+		cr.Running[instanceType] += int(aws.Int64Value(container.RunningTasksCount))
+		cr.RunningSlots[instanceType] += registered - remaining
+		registered, remaining = sn.weigh(container, registered), sn.weigh(container, remaining)
+		cr.Registered[instanceType] += registered
+		if isDisconnected(container) {
+			// ECS won't place anything here till the agent's back, but its
+			// instance type still has RemainingSchedulable, of 0 if need be,
+			// so alarms on it see none remaining rather than no data.
+			cr.Remaining[instanceType] += 0
+			disconnected++
+			continue
+		}
+		cr.Remaining[instanceType] += remaining
 	}
-	log.Printf("%q has %+v", *cluster, cr.Resources)
-	return cr.ToMetricData()
+	sn.logger().Infof("%q has %+v", *cluster, cr.Resources)
+	if len(containers) > 0 {
+		cr.Totals["DisconnectedAgents"] = float64(disconnected)
+	}
+	if disconnected > 0 {
+		sn.logger().Warnf("%q has %d Container Instances with disconnected agents", *cluster, disconnected)
+	}
+	if aws.BoolValue(sn.MeasureSubnets) {
+		sn.MeasureSubnetCapacity(cr, containers, cpu, memory)
+	}
+	return cr
+}
+
+// weigh scales how many containers are schedulable on a Container Instance
+// by its instance type's weight in InstanceTypeWeights, if any.
+func (sn *Snitcher) weigh(container *ecs.ContainerInstance, schedulable int) int {
+	weight, ok := sn.InstanceTypeWeights[getInstanceType(container.Attributes)]
+	if !ok {
+		return schedulable
+	}
+	return int(weight * float64(schedulable))
+}
+
+// isDisconnected tells whether a Container Instance's ECS agent is known to be
+// disconnected. Its capacity is registered but can't be scheduled.
+func isDisconnected(container *ecs.ContainerInstance) bool {
+	return container.AgentConnected != nil && !*container.AgentConnected
+}
+
+// DescribeClusterTags reads an ECS Cluster's tags, by key, or nil if they
+// can't be read. DescribeClusters takes cluster names, where
+// ListTagsForResource would need ARNs.
+//
+// Requires IAM permission "ecs:DescribeClusters".
+func (sn *Snitcher) DescribeClusterTags(cluster *string) map[string]string {
+	input := &ecs.DescribeClustersInput{
+		Clusters: []*string{cluster},
+		Include:  aws.StringSlice([]string{"TAGS"}),
+	}
+	output, err := sn.ECS.DescribeClusters(input)
+	if err != nil {
+		sn.logger().Errorf("Failed to DescribeClusters %q! %s", *cluster, err)
+		sn.Summary.failCluster(*cluster, "DescribeClusters of %q: %s", *cluster, err)
+		return nil
+	}
+	tags := map[string]string{}
+	for _, described := range output.Clusters {
+		for _, tag := range described.Tags {
+			tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+		}
+	}
+	return tags
+}
+
+// tagDimensions are dimensions of a cluster's tags, in order of keys. Missing
+// tags have "none" for their value, since CloudWatch won't take empty ones.
+func tagDimensions(tags map[string]string, keys []string) (dimensions []*cloudwatch.Dimension) {
+	for _, key := range keys {
+		value := tags[key]
+		if value == "" {
+			value = "none"
+		}
+		dimensions = append(dimensions, &cloudwatch.Dimension{Name: aws.String(key), Value: aws.String(value)})
+	}
+	return
 }
 
 // DiscoverClusters reads ECS Clusters' ARNs like
 // "arn:aws:ecs:ca-central-1:123456789012:cluster/my-cluster" and communicates
 // derived Cluster nanme, like "my-cluster", to output channel.
 //
+// Clusters not matching Include, or matching Exclude, are left out.
+//
 // Requires "ecs:ListClusters" IAM permission.
 func (sn *Snitcher) DiscoverClusters() <-chan *string {
 	com := make(chan *string)
@@ -206,19 +792,79 @@ func (sn *Snitcher) DiscoverClusters() <-chan *string {
 			&ecs.ListClustersInput{},
 			func(page *ecs.ListClustersOutput, last bool) bool {
 				for _, arn := range page.ClusterArns {
-					com <- aws.String(strings.Split(*arn, ":cluster/")[1])
+					name := strings.Split(*arn, ":cluster/")[1]
+					if sn.included(name) {
+						com <- aws.String(name)
+					}
 				}
 				return len(page.ClusterArns) > 0
 			},
 		)
 		if err != nil {
-			log.Println("Failed to ListClustersPages!", err)
+			sn.logger().Errorf("Failed to ListClustersPages! %s", err)
+			sn.Summary.fail("ListClusters: %s", err)
 		}
 		close(com)
 	}()
 	return com
 }
 
+// Clusters communicates Cluster, if set, or else clusters discovered by
+// DiscoverClusters.
+func (sn *Snitcher) Clusters() <-chan *string {
+	if aws.StringValue(sn.Cluster) == "" {
+		return sn.DiscoverClusters()
+	}
+	com := make(chan *string, 1)
+	com <- sn.Cluster
+	close(com)
+	return com
+}
+
+// included tells whether a cluster name matches any of Include, when there
+// are any, and none of Exclude.
+func (sn *Snitcher) included(cluster string) bool {
+	return sn.exclusion(cluster) == ""
+}
+
+// exclusion explains why Include and Exclude leave a cluster name out, or is
+// "" if they don't.
+func (sn *Snitcher) exclusion(cluster string) string {
+	for _, pattern := range sn.Exclude {
+		if matches(pattern, cluster) {
+			return fmt.Sprintf("excluded by %q", pattern)
+		}
+	}
+	if len(sn.Include) == 0 {
+		return ""
+	}
+	for _, pattern := range sn.Include {
+		if matches(pattern, cluster) {
+			return ""
+		}
+	}
+	return "not included"
+}
+
+// matches tells whether name matches a glob pattern, like "ci-*", or regular
+// expression between slashes, like "/^ci-[0-9]+$/". Invalid patterns match
+// nothing.
+func matches(pattern, name string) bool {
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			defaultLogger.Errorf("Failed to compile %q: %s", pattern, err)
+			return false
+		}
+		return re.MatchString(name)
+	}
+	matched, err := path.Match(pattern, name)
+	if err != nil {
+		defaultLogger.Errorf("Failed to match %q: %s", pattern, err)
+	}
+	return matched
+}
+
 // ContainersPossible calculates how many containers are possible to launch.
 //
 // This calculates how many containers can be scheduled per EC2 Instance, since
@@ -246,78 +892,446 @@ func ContainersPossible(cpu, memory int, resources []*ecs.Resource) (canSchedule
 // getInstanceType figures out the EC2 Instance Type from an array of ECS
 // Attributes.
 func getInstanceType(attributes []*ecs.Attribute) string {
+	return getAttribute(attributes, "ecs.instance-type")
+}
+
+// getAttribute finds the value of a named ECS Attribute, or "" if it's not
+// there.
+func getAttribute(attributes []*ecs.Attribute, name string) string {
 	for _, attr := range attributes {
-		if *attr.Name == "ecs.instance-type" {
-			return *attr.Value
+		if *attr.Name == name {
+			return aws.StringValue(attr.Value)
 		}
 	}
 	return ""
 }
 
+// partition figures out which key of cr's Resources a Container Instance with
+// attributes counts toward: its instance type, plus the values of Snitcher's
+// DimensionAttributes, if any, whose dimensions are added to cr.
+//
+// Container Instances lacking an attribute have "none" for its value, since
+// CloudWatch won't take empty dimension values.
+func (sn *Snitcher) partition(cr *ClusterResources, attributes []*ecs.Attribute) string {
+	key := getInstanceType(attributes)
+	if len(sn.DimensionAttributes) == 0 {
+		return key
+	}
+	dimensions := []*cloudwatch.Dimension{
+		{Name: aws.String("InstanceType"), Value: aws.String(key)},
+	}
+	for _, name := range sn.DimensionAttributes {
+		value := getAttribute(attributes, name)
+		if value == "" {
+			value = "none"
+		}
+		key += "," + name + "=" + value
+		dimensions = append(dimensions, &cloudwatch.Dimension{Name: aws.String(name), Value: aws.String(value)})
+	}
+	cr.Dimensions[key] = dimensions
+	return key
+}
+
+// MeasureLowestCommonMultiple finds the largest CPU Units and Memory (RAM in
+// MiB) among all Tasks running in an ECS Cluster.
+func (sn *Snitcher) MeasureLowestCommonMultiple(cluster *string) (cpu, memory int) {
+	cpu, memory, _ = sn.measureLowestCommonMultiple(cluster)
+	return
+}
+
+// measureLowestCommonMultiple is MeasureLowestCommonMultiple, also counting
+// pending Tasks.
+func (sn *Snitcher) measureLowestCommonMultiple(cluster *string) (cpu, memory, pending int) {
+	type size struct{ cpu, memory, pending int }
+	sizes := make(chan size)
+	go func() {
+		// Describe cohorts while ListTasks pages onward, a few at a time.
+		busy := make(chan bool, describeConcurrency)
+		var wg sync.WaitGroup
+		for tasks := range sn.DiscoverTasks(cluster) {
+			busy <- true
+			wg.Add(1)
+			go func(tasks []*string) {
+				defer wg.Done()
+				cohortCPU, cohortMemory, cohortPending := sn.measureResources(cluster, tasks)
+				<-busy
+				sizes <- size{cohortCPU, cohortMemory, cohortPending}
+			}(tasks)
+		}
+		wg.Wait()
+		close(sizes)
+	}()
+	for cohort := range sizes {
+		pending += cohort.pending
+		if cohort.cpu > cpu {
+			cpu = cohort.cpu
+		}
+		if cohort.memory > memory {
+			memory = cohort.memory
+		}
+	}
+	return
+}
+
 // MeasureCluster measures how many containers an ECS Cluster can schedule.
+//
+// Container size is Snitcher's CPU and Memory, when supplied, so you can ask
+// "how many 512/1024 Tasks fit right now" regardless of what's running.
+// Otherwise it's the "lowest common multiple" of running Tasks, or DefaultCPU
+// and DefaultMemory if nothing's running. Either way it's no smaller than
+// MinCPU and MinMemory.
+//
+// Clusters lacking any of SelectTags aren't measured.
 func (sn *Snitcher) MeasureCluster(cluster *string) []*cloudwatch.MetricDatum {
-	var cpu, memory int
-	for tasks := range sn.DiscoverTasks(cluster) {
-		cohortCPU, cohortMemory := sn.MeasureResources(cluster, tasks)
-		if cohortCPU > cpu {
-			cpu = cohortCPU
+	var tags map[string]string
+	if len(sn.SelectTags) > 0 || len(sn.DimensionTags) > 0 {
+		if tags = sn.DescribeClusterTags(cluster); tags == nil {
+			// Better missing than published under the wrong dimensions.
+			sn.logger().Infof("%q tags unknown; skipping", *cluster)
+			return []*cloudwatch.MetricDatum{}
 		}
-		if cohortMemory > memory {
-			memory = cohortMemory
+		for key, value := range sn.SelectTags {
+			if tags[key] != value {
+				sn.logger().Infof("%q isn't tagged %s=%s; skipping", *cluster, key, value)
+				return []*cloudwatch.MetricDatum{}
+			}
 		}
 	}
+	// Container Instances don't depend on container size, so describe them
+	// while measuring it.
+	containers := make(chan []*ecs.ContainerInstance, 1)
+	var measured time.Time
+	go func() {
+		described := sn.DescribeContainerInstances(cluster, sn.ListContainerInstances(cluster))
+		measured = time.Now()
+		containers <- described
+	}()
+	cpu, memory, pending := sn.containerSize(cluster)
 	if cpu == 0 || memory == 0 {
-		log.Printf("%q doesn't appear to be running any Tasks; skipping", *cluster)
 		return []*cloudwatch.MetricDatum{}
 	}
-	log.Printf("%q lowest common multiple is %d CPU Units, %d MiB RAM", *cluster, cpu, memory)
-	instances := sn.ListContainerInstances(cluster)
-	return sn.DescribeResourcesByInstanceType(cluster, instances, cpu, memory)
+	cr := sn.MeasureContainerInstances(cluster, <-containers, cpu, memory)
+	// Stamp metrics with when remaining capacity was described, however long
+	// the rest of measuring takes.
+	cr.Measured = measured
+	cr.ClusterDimensions = tagDimensions(tags, sn.DimensionTags)
+	if aws.BoolValue(sn.MeasureFullPercent) {
+		cr.Totals["ClusterFullPercent"] = cr.FullPercent()
+	}
+	if aws.BoolValue(sn.MeasurePending) {
+		if pending < 0 {
+			sn.MeasurePendingTasks(cr)
+		} else {
+			sn.logger().Infof("%q has %d pending Tasks", *cluster, pending)
+			cr.Totals["PendingTasks"] = float64(pending)
+		}
+	}
+	if aws.BoolValue(sn.MeasureScheduledScaling) {
+		sn.MeasureScheduledScalingShortfall(cr)
+	}
+	return cr.ToMetricData()
+}
+
+// ContainerSize figures out the CPU Units and Memory (MiB) of containers to
+// measure a cluster's capacity in: CPU and Memory, if set, or else its lowest
+// common multiple, or DefaultCPU and DefaultMemory if it runs no Tasks, but
+// no smaller than MinCPU and MinMemory.
+//
+// Both are 0 when a cluster runs no Tasks and there's no default size.
+func (sn *Snitcher) ContainerSize(cluster *string) (cpu, memory int) {
+	cpu, memory, _ = sn.containerSize(cluster)
+	return
+}
+
+// containerSize is ContainerSize, also counting pending Tasks when measuring
+// container size describes them, or else -1.
+func (sn *Snitcher) containerSize(cluster *string) (cpu, memory, pending int) {
+	cpu, memory, pending = aws.IntValue(sn.CPU), aws.IntValue(sn.Memory), -1
+	if cpu == 0 || memory == 0 {
+		var measuredCPU, measuredMemory int
+		measuredCPU, measuredMemory, pending = sn.measureLowestCommonMultiple(cluster)
+		if cpu == 0 {
+			cpu = measuredCPU
+		}
+		if memory == 0 {
+			memory = measuredMemory
+		}
+	}
+	if cpu == 0 || memory == 0 {
+		cpu, memory = aws.IntValue(sn.DefaultCPU), aws.IntValue(sn.DefaultMemory)
+		if cpu == 0 || memory == 0 {
+			sn.logger().Infof("%q doesn't appear to be running any Tasks; skipping", *cluster)
+			return 0, 0, pending
+		}
+		sn.logger().Infof("%q doesn't appear to be running any Tasks; using default size", *cluster)
+	}
+	if minCPU := aws.IntValue(sn.MinCPU); cpu < minCPU {
+		cpu = minCPU
+	}
+	if minMemory := aws.IntValue(sn.MinMemory); memory < minMemory {
+		memory = minMemory
+	}
+	sn.logger().Infof("%q lowest common multiple is %d CPU Units, %d MiB RAM", *cluster, cpu, memory)
+	return
 }
 
-// Measure how many containers an ECS Cluster can schedule.
+// Measure how many containers an ECS Cluster can schedule, Concurrency
+// clusters at a time, returning metric data sorted by cluster.
+//
+// Clusters whose ECS calls are throttled, even after retrying, aren't
+// published, rather than published as misleading zeros.
 func (sn *Snitcher) Measure() (metricData []*cloudwatch.MetricDatum) {
+	if sn.Summary == nil {
+		// Summarize anyway, to know which clusters were throttled.
+		summarized := *sn
+		summarized.Summary = &Summary{}
+		sn = &summarized
+	}
+	var experiments []string
+	if aws.BoolValue(sn.MeasureFaultInjection) {
+		experiments = sn.ListActiveExperiments()
+	}
 	com := make(chan []*cloudwatch.MetricDatum)
 	defer close(com)
 	numClusters := 0 // Since we don't know how many Clusters.
-	for cluster := range sn.DiscoverClusters() {
+	busy := make(chan bool, sn.concurrency())
+	sn.Progress.startListing()
+	for cluster := range sn.Clusters() {
+		busy <- true
+		if !sn.Deadline.IsZero() && time.Now().After(sn.Deadline) {
+			sn.logger().Infof("Skipping %q, since deadline %s has passed", *cluster, sn.Deadline)
+			sn.Summary.skip(*cluster)
+			<-busy
+			continue
+		}
+		sn.Progress.discover()
 		go func(cluster *string) {
-			com <- sn.MeasureCluster(cluster)
+			var metricData []*cloudwatch.MetricDatum
+			sn.trace(*cluster, func(sn *Snitcher) error {
+				metricData = sn.MeasureCluster(cluster)
+				return nil
+			})
+			<-busy
+			if sn.Summary.throttledCluster(*cluster) {
+				metricData = nil
+			}
+			sn.Progress.complete()
+			sn.Summary.measure(*cluster, len(metricData))
+			if sn.stream != nil {
+				sn.streamed(sn.withExperiments(metricData, experiments))
+				metricData = nil
+			}
+			com <- metricData
 		}(cluster)
 		numClusters++
 	}
+	sn.Progress.doneListing()
 	for i := 0; i < numClusters; i++ {
 		metricData = append(metricData, <-com...)
 	}
-	return
+	sortMetricData(metricData)
+	return sn.withExperiments(metricData, experiments)
+}
+
+// streamed hands a cluster's metrics to stream, rather than Measure returning
+// them.
+func (sn *Snitcher) streamed(metricData []*cloudwatch.MetricDatum) {
+	if len(metricData) == 0 {
+		return
+	}
+	sn.stream(metricData)
+}
+
+// concurrency is how many clusters Measure measures at once.
+func (sn *Snitcher) concurrency() int {
+	if sn.Concurrency != nil && *sn.Concurrency > 0 {
+		return *sn.Concurrency
+	}
+	return defaultConcurrency
 }
 
 // Publish metrics to CloudWatch.
 //
-// BUG(shatil): Publish must submit in batches of 20 MetricDatum because:
-// https://github.com/aws/aws-sdk-go/issues/2019
+// Batches are shared by every cluster's metrics, rather than sent per cluster,
+// to keep PutMetricData calls few in accounts with many clusters.
+//
+// Metrics not matching their registration in Metrics are dropped.
+//
+// Batches are of BatchSize data points, and split further to keep requests
+// under CloudWatch's 1 MB limit.
+//
+// BUG(shatil): Publish must submit in batches of 20 MetricDatum, by default,
+// because: https://github.com/aws/aws-sdk-go/issues/2019
 func (sn *Snitcher) Publish(metricData []*cloudwatch.MetricDatum) {
+	sn.publishAll(metricData)
+}
+
+// publishAll publishes metricData to Namespace and each of ExtraNamespaces,
+// returning the last error, if any.
+func (sn *Snitcher) publishAll(metricData []*cloudwatch.MetricDatum) (err error) {
+	for _, namespace := range append([]*string{sn.Namespace}, aws.StringSlice(sn.ExtraNamespaces)...) {
+		if publishErr := sn.publish(namespace, metricData); publishErr != nil {
+			err = publishErr
+		}
+	}
+	return
+}
+
+// publish is Publish to namespace, returning the last batch's error, if any.
+// A namespace like "ECS/{cluster}" is resolved per metric.
+func (sn *Snitcher) publish(namespace *string, metricData []*cloudwatch.MetricDatum) error {
+	if isTemplate(namespace) {
+		return sn.publishTemplated(aws.StringValue(namespace), metricData)
+	}
+	return sn.publishTo(namespace, metricData)
+}
+
+// publishTo is publish to namespace, as it is.
+func (sn *Snitcher) publishTo(namespace *string, metricData []*cloudwatch.MetricDatum) (err error) {
+	metricData = validateMetricData(sn.logger(), metricData)
+	// Units are counted by snitch's names, before renaming.
+	if aws.BoolValue(sn.CountUnits) {
+		metricData = countUnits(metricData)
+	}
+	metricData = sn.renamed(metricData)
+	if format := aws.StringValue(sn.DryRun); format != "" {
+		return (&DryRunSink{Namespace: aws.StringValue(namespace), Format: format}).Send(metricData)
+	}
+	if aws.BoolValue(sn.EmbeddedMetricFormat) {
+		if err = (&EMFSink{Namespace: aws.StringValue(namespace)}).Send(metricData); err != nil {
+			sn.logger().Errorf("Failed to write %d metrics as Embedded Metric Format: %s", len(metricData), err)
+			sn.Summary.publishFailed("Failed to write %d metrics as Embedded Metric Format: %s", len(metricData), err)
+		} else {
+			sn.Summary.publish(len(metricData))
+		}
+		return
+	}
+	if aws.BoolValue(sn.StatisticSets) {
+		metricData = statisticSets(metricData)
+	}
+	metricData = sn.deltas.suppress(sn, namespace, metricData)
+	var published []*cloudwatch.MetricDatum
+	defer func() { sn.deltas.published(sn, namespace, published) }()
 	input := &cloudwatch.PutMetricDataInput{
-		Namespace: sn.Namespace,
+		Namespace: namespace,
 	}
-	batchSize := 20
-	log.Printf("Publishing %d metrics in batches of %d", len(metricData), batchSize)
-	for i := 0; i < len(metricData); i += batchSize {
-		end := i + batchSize
-		if end > len(metricData) {
-			end = len(metricData)
-		}
-		input.MetricData = metricData[i:end]
-		if err := input.Validate(); err != nil {
-			log.Println("Failed to validate metrics:", err)
-			log.Println("Invalid metrics:", input.GoString())
-		} else if _, err = sn.CloudWatch.PutMetricData(input); err != nil {
-			log.Printf("Failed to publish %d metrics to CloudWatch: %s", len(input.MetricData), err)
-			log.Printf("Metrics not published: %s", input.GoString())
+	batchSize := sn.batchSize()
+	sn.logger().Infof("Publishing %d metrics in batches of %d", len(metricData), batchSize)
+	// Every batch is tried even if some fail, returning the last failure.
+	for _, batch := range batches(metricData, batchSize, maxPayloadBytes) {
+		input.MetricData = batch
+		if validateErr := input.Validate(); validateErr != nil {
+			err = validateErr
+			sn.logger().Errorf("Failed to validate metrics: %s", err)
+			sn.logger().Debugf("Invalid metrics: %s", input.GoString())
+			sn.Summary.publishFailed("Failed to validate metrics: %s", err)
+		} else if _, putErr := sn.CloudWatch.PutMetricData(input); putErr != nil {
+			err = putErr
+			sn.logger().Errorf("Failed to publish %d metrics to CloudWatch: %s", len(input.MetricData), err)
+			sn.logger().Debugf("Metrics not published: %s", input.GoString())
+			sn.Summary.publishFailed("Failed to publish %d metrics to CloudWatch: %s", len(input.MetricData), err)
+			sn.carryOver.carry(sn, sn.CloudWatch, &cloudwatch.PutMetricDataInput{Namespace: namespace, MetricData: input.MetricData}, putErr)
 		} else {
-			log.Printf("Published %d metrics: %s", len(input.MetricData), input.GoString())
+			sn.logger().Infof("Published %d metrics", len(input.MetricData))
+			sn.logger().Debugf("Published metrics: %s", input.GoString())
+			sn.Summary.publish(len(input.MetricData))
+			published = append(published, input.MetricData...)
+		}
+	}
+	return
+}
+
+// PublishCanary publishes "Canary" to CanaryNamespace. Unlike any cluster's
+// metrics, it goes missing only when snitch does.
+func (sn *Snitcher) PublishCanary() {
+	metricData := []*cloudwatch.MetricDatum{
+		{
+			MetricName: aws.String("Canary"),
+			Timestamp:  aws.Time(time.Now()),
+			Value:      aws.Float64(1),
+			Unit:       unit("Canary"),
+		},
+	}
+	if err := sn.publishOwn(sn.CanaryNamespace, metricData); err != nil {
+		sn.logger().Errorf("Failed to publish canary to %q: %s", aws.StringValue(sn.CanaryNamespace), err)
+	}
+}
+
+// publishOwn publishes snitch's own metrics, like Canary, to namespace in one
+// call, honoring DryRun and EmbeddedMetricFormat.
+func (sn *Snitcher) publishOwn(namespace *string, metricData []*cloudwatch.MetricDatum) (err error) {
+	if format := aws.StringValue(sn.DryRun); format != "" {
+		return (&DryRunSink{Namespace: aws.StringValue(namespace), Format: format}).Send(metricData)
+	}
+	if aws.BoolValue(sn.EmbeddedMetricFormat) {
+		return (&EMFSink{Namespace: aws.StringValue(namespace)}).Send(metricData)
+	}
+	_, err = sn.CloudWatch.PutMetricData(&cloudwatch.PutMetricDataInput{Namespace: namespace, MetricData: metricData})
+	return
+}
+
+// Collect measures, like Run but without publishing, across accounts of
+// OrganizationRole and Roles, and Regions, if any.
+func (sn *Snitcher) Collect() []*cloudwatch.MetricDatum {
+	return sn.withDiscoveredRoles().measure()
+}
+
+// withDiscoveredRoles copies Snitcher with roles of accounts discovered by
+// OrganizationRole, if set, added to Roles.
+func (sn *Snitcher) withDiscoveredRoles() *Snitcher {
+	if aws.StringValue(sn.OrganizationRole) == "" {
+		return sn
+	}
+	discovered := *sn
+	discovered.Roles = append(append([]string{}, sn.Roles...), sn.DiscoverAccountRoles()...)
+	discovered.OrganizationRole = nil
+	return &discovered
+}
+
+// measure measures across Roles or Regions, if any, or just Measure.
+func (sn *Snitcher) measure() []*cloudwatch.MetricDatum {
+	switch {
+	case len(sn.Roles) > 0:
+		return sn.MeasureAccounts()
+	case len(sn.Regions) > 0:
+		return sn.MeasureRegions()
+	}
+	return sn.Measure()
+}
+
+// runStreaming is runTraced, publishing and sending to Sinks each cluster's
+// metrics as soon as they're measured, one cluster at a time.
+func (sn *Snitcher) runStreaming() (err error) {
+	if aws.StringValue(sn.DryRun) == "json" {
+		return fmt.Errorf("dry run %q prints one array per run, so can't stream", "json")
+	}
+	for _, config := range sn.Sinks {
+		if streamlessSinkTypes[config.Type] {
+			return fmt.Errorf("%q sinks write one document per run, so can't stream", config.Type)
 		}
 	}
+	var mutex sync.Mutex
+	publish := aws.BoolValue(sn.ShouldPublish) || aws.StringValue(sn.DryRun) != ""
+	sn.stream = func(metricData []*cloudwatch.MetricDatum) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if len(sn.Sinks) > 0 {
+			if failed := sn.Send(metricData); failed > 0 {
+				err = fmt.Errorf("%d of %d sinks failed", failed, len(sn.Sinks))
+			}
+		}
+		if publish {
+			if publishErr := sn.publishAll(metricData); publishErr != nil {
+				err = publishErr
+			}
+		}
+	}
+	defer func() { sn.stream = nil }()
+	sn.measure()
+	if publish && aws.StringValue(sn.CanaryNamespace) != "" {
+		sn.PublishCanary()
+	}
+	return
 }
 
 // Run measures and maybe publishes findings.
@@ -326,9 +1340,62 @@ func (sn *Snitcher) Publish(metricData []*cloudwatch.MetricDatum) {
 // use these handy environment variables in place of CLI arguments:
 //	AWS_REGION for AWS Region (required unless ~/.aws/config sets it)
 func Run(sn *Snitcher) {
-	sn.WithAWS()
-	metricData := sn.Measure()
-	if *sn.ShouldPublish {
-		sn.Publish(metricData)
+	sn.run()
+}
+
+// run is Run, returning why publishing to CloudWatch or Sinks failed, if it
+// did. Failing to measure is only logged, like always.
+func (sn *Snitcher) run() error {
+	if aws.StringValue(sn.TelemetryNamespace) != "" {
+		if sn.Summary == nil {
+			sn.Summary = &Summary{}
+			defer func() { sn.Summary = nil }()
+		}
+		defer func(started time.Time) {
+			sn.PublishTelemetry(sn.Summary, time.Since(started))
+		}(time.Now())
+	}
+	if aws.BoolValue(sn.CarryOverFailures) && sn.carryOver == nil {
+		sn.carryOver = &carryOver{}
+	}
+	defer sn.publishCarriedOver()
+	if aws.StringValue(sn.DeltaState) != "" && sn.deltas == nil {
+		sn.deltas = sn.newDeltas()
+	}
+	return sn.WithAWS().trace("snitch", (*Snitcher).runTraced)
+}
+
+// runTraced is run, once AWS clients are added and traced, if they are.
+func (sn *Snitcher) runTraced() (err error) {
+	sn = sn.withDiscoveredRoles()
+	if aws.BoolValue(sn.DiscoverOnly) {
+		for cluster := range sn.Clusters() {
+			sn.Summary.discover(*cluster)
+		}
+		return
 	}
+	if secretsErr := sn.ResolveSecrets(); secretsErr != nil {
+		sn.logger().Errorf("Failed to resolve secrets of sinks: %s", secretsErr)
+	}
+	if len(sn.Roles) > 0 && aws.BoolValue(sn.PublishInAccounts) {
+		return sn.RunAccounts()
+	}
+	if aws.BoolValue(sn.Stream) {
+		return sn.runStreaming()
+	}
+	metricData := sn.measure()
+	if len(sn.Sinks) > 0 {
+		if failed := sn.Send(metricData); failed > 0 {
+			err = fmt.Errorf("%d of %d sinks failed", failed, len(sn.Sinks))
+		}
+	}
+	if aws.BoolValue(sn.ShouldPublish) || aws.StringValue(sn.DryRun) != "" {
+		if publishErr := sn.publishAll(metricData); publishErr != nil {
+			err = publishErr
+		}
+		if aws.StringValue(sn.CanaryNamespace) != "" {
+			sn.PublishCanary()
+		}
+	}
+	return
 }
@@ -2,9 +2,14 @@ package snitch
 
 import (
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
@@ -39,7 +44,8 @@ type FakeCloudWatch struct {
 
 // PutMetricDataInput fake-publishes metrics to CloudWatch.
 func (fake *FakeCloudWatch) PutMetricData(input *cloudwatch.PutMetricDataInput) (*cloudwatch.PutMetricDataOutput, error) {
-	fake.payload = append(fake.payload, input)
+	copied := *input // Publish reuses input between batches.
+	fake.payload = append(fake.payload, &copied)
 	return nil, fake.errorToReturn
 }
 
@@ -58,8 +64,11 @@ type FakeECS struct {
 	expectedRegistered            []*ecs.Resource          // Expected registered ECS Cluster resources.
 	expectedRemaining             []*ecs.Resource          // Expected remaining ECS Cluster resources.
 	expectedTaskArns              []string                 // Expected ECS Task ARNs.
+	expectedTaskDefinition        *ecs.TaskDefinition      // Expected response by DescribeTaskDefinition.
 	expectedRegisteredPossible    int                      // Expected number of schedulable containers w/ "RegisteredResources".
 	expectedRemainingPossible     int                      // Expected number of schedulable containers w/ "RemainingResources".
+	expectedServices              []*ecs.Service           // Expected ECS Services described by DescribeServices.
+	expectedTags                  []*ecs.Tag               // Expected ECS Cluster tags described by DescribeClusters.
 	t                             *testing.T               // Enable logging and failure in mock.
 }
 
@@ -159,6 +168,35 @@ func (fake *FakeECS) DescribeTasks(input *ecs.DescribeTasksInput) (*ecs.Describe
 	return fake.expectedDescribeTasksOutput, fake.errorToReturn
 }
 
+// DescribeServices fake-describes whichever expectedServices were asked for.
+func (fake *FakeECS) DescribeServices(input *ecs.DescribeServicesInput) (*ecs.DescribeServicesOutput, error) {
+	output := &ecs.DescribeServicesOutput{}
+	for _, name := range input.Services {
+		for _, service := range fake.expectedServices {
+			if *name == *service.ServiceName {
+				output.Services = append(output.Services, service)
+			}
+		}
+	}
+	return output, fake.errorToReturn
+}
+
+// DescribeClusters fake-describes every requested cluster, by name or ARN,
+// with expectedTags and as many Container Instances and Tasks as expected.
+func (fake *FakeECS) DescribeClusters(input *ecs.DescribeClustersInput) (*ecs.DescribeClustersOutput, error) {
+	output := &ecs.DescribeClustersOutput{}
+	for _, name := range input.Clusters {
+		parts := strings.Split(*name, ":cluster/")
+		output.Clusters = append(output.Clusters, &ecs.Cluster{
+			ClusterName:                       aws.String(parts[len(parts)-1]),
+			RegisteredContainerInstancesCount: aws.Int64(int64(len(fake.expectedContainerInstances))),
+			RunningTasksCount:                 aws.Int64(int64(len(fake.expectedTaskArns))),
+			Tags:                              fake.expectedTags,
+		})
+	}
+	return output, fake.errorToReturn
+}
+
 func (fake *FakeECS) ListContainerInstances(input *ecs.ListContainerInstancesInput) (*ecs.ListContainerInstancesOutput, error) {
 	if "ACTIVE" != *input.Status {
 		fake.t.Errorf("ListContainerInstances should look for ACTIVE only, got: %q", *input.Status)
@@ -218,6 +256,51 @@ func TestSnitcher_Publish(t *testing.T) {
 	sn.Publish(metricData)
 }
 
+func TestRunExtraNamespaces(t *testing.T) {
+	fake := NewFakeECS(t)
+	fake.checkCluster = false
+	cloudWatch := &FakeCloudWatch{}
+	sn := &Snitcher{ECS: fake, CloudWatch: cloudWatch, Namespace: aws.String("Old/Namespace"), ExtraNamespaces: []string{"New/Namespace"}, ShouldPublish: aws.Bool(true)}
+	if err := sn.run(); err != nil {
+		t.Fatal(err)
+	}
+	published := map[string]int{}
+	for _, input := range cloudWatch.payload {
+		published[aws.StringValue(input.Namespace)] += len(input.MetricData)
+	}
+	if len(published) != 2 || published["Old/Namespace"] == 0 || published["Old/Namespace"] != published["New/Namespace"] {
+		t.Errorf("expected every metric published to both namespaces but got %v", published)
+	}
+}
+
+// TestRunBatchesAcrossClusters guards against publishing per cluster: every
+// cluster's data points share batches of 20.
+func TestRunBatchesAcrossClusters(t *testing.T) {
+	cw := &FakeCloudWatch{}
+	ecs := NewFakeECS(t)
+	ecs.checkCluster = false
+	sn := &Snitcher{
+		CloudWatch:    cw,
+		ECS:           ecs,
+		Namespace:     aws.String("Collector/Test"),
+		ShouldPublish: aws.Bool(true),
+	}
+	Run(sn)
+	published, clusters := 0, map[string]bool{}
+	for _, input := range cw.payload {
+		published += len(input.MetricData)
+		for _, datum := range input.MetricData {
+			clusters[*datum.Dimensions[0].Value] = true
+		}
+	}
+	if len(clusters) != len(ecs.expectedClusterArns) {
+		t.Fatalf("expected %d clusters published but got %d", len(ecs.expectedClusterArns), len(clusters))
+	}
+	if expected := (published + 19) / 20; len(cw.payload) != expected {
+		t.Errorf("expected %d data points in %d PutMetricData calls but got %d", published, expected, len(cw.payload))
+	}
+}
+
 // TestSnitcher_PublishValidate forces Validate() failure (in
 // service/cloudwatch/api.go), in this case by missing namespace.
 //
@@ -247,6 +330,36 @@ func TestSnitcher_PublishError(t *testing.T) {
 	sn.Publish(cr.ToMetricData())
 }
 
+// FlakyCloudWatch fails publishing its first batch only.
+type FlakyCloudWatch struct {
+	FakeCloudWatch
+}
+
+func (fake *FlakyCloudWatch) PutMetricData(input *cloudwatch.PutMetricDataInput) (*cloudwatch.PutMetricDataOutput, error) {
+	fake.FakeCloudWatch.PutMetricData(input)
+	if len(fake.payload) == 1 {
+		return nil, errors.New("fake")
+	}
+	return nil, nil
+}
+
+func TestSnitcher_PublishBatchError(t *testing.T) {
+	fake := &FlakyCloudWatch{}
+	sn := &Snitcher{Namespace: aws.String("Publish/Error"), CloudWatch: fake, BatchSize: aws.Int(1), Summary: &Summary{}}
+	cr := NewClusterResources(aws.String("ecs-publish-error"))
+	cr.Registered["fake.publishError"] += 5
+	cr.Registered["another.publishError"] += 10
+	if err := sn.publishTo(sn.Namespace, cr.ToMetricData()); err == nil {
+		t.Error("expected the first batch's error despite later batches publishing")
+	}
+	if len(fake.payload) < 2 {
+		t.Errorf("expected every batch tried but got %d", len(fake.payload))
+	}
+	if sn.Summary.PublishFailures != 1 || sn.Summary.Err() == nil {
+		t.Errorf("expected the failure summarized but got %+v", sn.Summary)
+	}
+}
+
 func TestSnitcher_DiscoverTasks(t *testing.T) {
 	fake := NewFakeECS(t)
 	sn := &Snitcher{
@@ -275,6 +388,164 @@ func TestSnitcher_MeasureResources(t *testing.T) {
 	}
 }
 
+// PagedECS is FakeECS listing the same Tasks over several pages, counting how
+// many cohorts get described.
+type PagedECS struct {
+	*FakeECS
+	pages     int
+	described int32
+}
+
+func (fake *PagedECS) ListTasksPages(input *ecs.ListTasksInput, pager func(*ecs.ListTasksOutput, bool) bool) error {
+	for page := 1; page <= fake.pages; page++ {
+		output := &ecs.ListTasksOutput{
+			TaskArns: aws.StringSlice(fake.expectedTaskArns),
+		}
+		if !pager(output, page == fake.pages) {
+			break
+		}
+	}
+	return fake.errorToReturn
+}
+
+func (fake *PagedECS) DescribeTasks(input *ecs.DescribeTasksInput) (*ecs.DescribeTasksOutput, error) {
+	atomic.AddInt32(&fake.described, 1)
+	return fake.FakeECS.DescribeTasks(input)
+}
+
+func TestSnitcher_MeasureLowestCommonMultiple(t *testing.T) {
+	fake := &PagedECS{FakeECS: NewFakeECS(t), pages: 3 * describeConcurrency}
+	sn := &Snitcher{ECS: fake}
+	cpu, memory := sn.MeasureLowestCommonMultiple(fake.expectedCluster)
+	if cpu != fake.expectedCPU || memory != fake.expectedMemory {
+		t.Errorf("expected %d CPU Units, %d MiB but got %d, %d", fake.expectedCPU, fake.expectedMemory, cpu, memory)
+	}
+	if described := int(atomic.LoadInt32(&fake.described)); described != fake.pages {
+		t.Errorf("expected all %d cohorts described but got %d", fake.pages, described)
+	}
+}
+
+// ManyClustersECS lists many clusters, counting how many are
+// measured at once.
+type ManyClustersECS struct {
+	*FakeECS
+	clusters      int
+	measuring     int32
+	mostMeasuring int32
+}
+
+func (fake *ManyClustersECS) ListClustersPages(input *ecs.ListClustersInput, pager func(*ecs.ListClustersOutput, bool) bool) error {
+	output := &ecs.ListClustersOutput{}
+	for i := 0; i < fake.clusters; i++ {
+		output.ClusterArns = append(output.ClusterArns, aws.String(fmt.Sprintf("arn:aws:ecs:us-east-1:123456789012:cluster/cluster-%d", i)))
+	}
+	pager(output, true)
+	return nil
+}
+
+func (fake *ManyClustersECS) ListTasksPages(input *ecs.ListTasksInput, pager func(*ecs.ListTasksOutput, bool) bool) error {
+	measuring := atomic.AddInt32(&fake.measuring, 1)
+	defer atomic.AddInt32(&fake.measuring, -1)
+	for {
+		most := atomic.LoadInt32(&fake.mostMeasuring)
+		if measuring <= most || atomic.CompareAndSwapInt32(&fake.mostMeasuring, most, measuring) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return fake.FakeECS.ListTasksPages(input, pager)
+}
+
+func TestSnitcher_MeasureConcurrency(t *testing.T) {
+	tests := []struct {
+		concurrency *int
+		expected    int32
+	}{
+		{nil, defaultConcurrency},
+		{aws.Int(2), 2},
+	}
+	for _, test := range tests {
+		fake := &ManyClustersECS{FakeECS: NewFakeECS(t), clusters: 20}
+		fake.checkCluster = false
+		sn := &Snitcher{ECS: fake, Concurrency: test.concurrency, Summary: &Summary{}}
+		sn.Measure()
+		if most := atomic.LoadInt32(&fake.mostMeasuring); most > test.expected || most < 1 {
+			t.Errorf("expected at most %d clusters measured at once but got %d", test.expected, most)
+		}
+		if len(sn.Summary.Results) != fake.clusters {
+			t.Errorf("expected %d clusters measured but got %d", fake.clusters, len(sn.Summary.Results))
+		}
+	}
+}
+
+func TestSnitcher_MeasureOrder(t *testing.T) {
+	fake := &ManyClustersECS{FakeECS: NewFakeECS(t), clusters: 5}
+	fake.checkCluster = false
+	sn := &Snitcher{ECS: fake}
+	var previous []string
+	clusters := map[string]bool{}
+	for _, datum := range sn.Measure() {
+		var cluster string
+		for _, dimension := range datum.Dimensions {
+			if aws.StringValue(dimension.Name) == "ClusterName" {
+				cluster = aws.StringValue(dimension.Value)
+			}
+		}
+		clusters[cluster] = true
+		current := []string{cluster, aws.StringValue(datum.MetricName), dimensionsKey(datum.Dimensions)}
+		if strings.Join(current, "\n") < strings.Join(previous, "\n") {
+			t.Errorf("expected %q after %q", current, previous)
+		}
+		previous = current
+	}
+	if len(clusters) != fake.clusters {
+		t.Errorf("expected metrics of %d clusters but got %v", fake.clusters, clusters)
+	}
+}
+
+// SizedCohortsECS pages one Task per cohort, sized by sizes, describing
+// earlier cohorts slower so they finish out of order.
+type SizedCohortsECS struct {
+	*FakeECS
+	sizes [][2]int
+}
+
+func (fake *SizedCohortsECS) ListTasksPages(input *ecs.ListTasksInput, pager func(*ecs.ListTasksOutput, bool) bool) error {
+	for i := range fake.sizes {
+		output := &ecs.ListTasksOutput{TaskArns: aws.StringSlice([]string{strconv.Itoa(i)})}
+		if !pager(output, i == len(fake.sizes)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func (fake *SizedCohortsECS) DescribeTasks(input *ecs.DescribeTasksInput) (*ecs.DescribeTasksOutput, error) {
+	i, _ := strconv.Atoi(aws.StringValue(input.Tasks[0]))
+	time.Sleep(time.Duration(len(fake.sizes)-i) * time.Millisecond)
+	task := &ecs.Task{
+		Cpu:    aws.String(strconv.Itoa(fake.sizes[i][0])),
+		Memory: aws.String(strconv.Itoa(fake.sizes[i][1])),
+	}
+	return &ecs.DescribeTasksOutput{Tasks: []*ecs.Task{task}}, nil
+}
+
+func TestSnitcher_MeasureLowestCommonMultipleOrder(t *testing.T) {
+	tests := [][][2]int{
+		{{4096, 512}, {256, 512}, {512, 1024}, {1024, 8192}},
+		{{256, 8192}, {512, 1024}, {1024, 512}, {4096, 512}, {256, 256}, {256, 256}},
+		{{1024, 2048}, {4096, 8192}},
+	}
+	for _, sizes := range tests {
+		fake := &SizedCohortsECS{FakeECS: NewFakeECS(t), sizes: sizes}
+		sn := &Snitcher{ECS: fake}
+		cpu, memory := sn.MeasureLowestCommonMultiple(fake.expectedCluster)
+		if cpu != 4096 || memory != 8192 {
+			t.Errorf("expected largest of %v, 4096 CPU Units and 8192 MiB, but got %d, %d", sizes, cpu, memory)
+		}
+	}
+}
+
 func TestSnitcher_MeasureResourcesError(t *testing.T) {
 	fake := NewFakeECS(t)
 	fake.errorToReturn = errors.New("cpu, memory ought to be zero when DiscoverTasks errors")
@@ -284,6 +555,121 @@ func TestSnitcher_MeasureResourcesError(t *testing.T) {
 	}
 }
 
+func TestSnitcher_DescribeTasks(t *testing.T) {
+	fake := NewFakeECS(t)
+	sn := &Snitcher{ECS: fake}
+	if tasks := sn.DescribeTasks(fake.expectedCluster, aws.StringSlice(fake.expectedTaskArns)); len(tasks) != len(fake.expectedDescribeTasksOutput.Tasks) {
+		t.Errorf("expected %d Tasks but got %d", len(fake.expectedDescribeTasksOutput.Tasks), len(tasks))
+	}
+	if tasks := sn.DescribeTasks(fake.expectedCluster, []*string{}); len(tasks) != 0 {
+		t.Errorf("expected no Tasks when none are asked for but got %d", len(tasks))
+	}
+	fake.errorToReturn = errors.New("there should be no Tasks on error")
+	if tasks := sn.DescribeTasks(fake.expectedCluster, aws.StringSlice(fake.expectedTaskArns)); len(tasks) != 0 {
+		t.Errorf("expected no Tasks but got %d", len(tasks))
+	}
+}
+
+func TestSnitcher_MeasurePendingTasks(t *testing.T) {
+	fake := NewFakeECS(t)
+	for index, status := range []string{"RUNNING", "PENDING", "PROVISIONING"} {
+		fake.expectedDescribeTasksOutput.Tasks[index].LastStatus = aws.String(status)
+	}
+	sn := &Snitcher{ECS: fake, MeasurePending: aws.Bool(true)}
+	cr := NewClusterResources(fake.expectedCluster)
+	sn.MeasurePendingTasks(cr)
+	if actual := cr.Totals["PendingTasks"]; actual != 2 {
+		t.Errorf("expected 2 PendingTasks but got %v", actual)
+	}
+	pendingTasks := func(metricData []*cloudwatch.MetricDatum) float64 {
+		for _, datum := range metricData {
+			if *datum.MetricName == "PendingTasks" {
+				return *datum.Value
+			}
+		}
+		return -1
+	}
+	if actual := pendingTasks(sn.MeasureCluster(fake.expectedCluster)); actual != 2 {
+		t.Errorf("expected 2 PendingTasks among metric data but got %v", actual)
+	}
+}
+
+func TestSnitcher_MeasurePendingTasksDescribedOnce(t *testing.T) {
+	faults := NewFaults(1)
+	sn, faulty, _ := NewFaultySnitcher(t, faults)
+	faulty.expectedDescribeTasksOutput.Tasks[1].LastStatus = aws.String("PENDING")
+	sn.MeasureCluster(faulty.expectedCluster)
+	described := faults.Calls["DescribeTasks"]
+	sn.MeasurePending = aws.Bool(true)
+	sn.MeasureCluster(faulty.expectedCluster)
+	if described == 0 || faults.Calls["DescribeTasks"] != 2*described {
+		t.Errorf("expected Tasks described once to measure container size and PendingTasks but got %d calls, not %d", faults.Calls["DescribeTasks"]-described, described)
+	}
+	sn.CPU, sn.Memory = aws.Int(256), aws.Int(512)
+	before := faults.Calls["DescribeTasks"]
+	sn.MeasureCluster(faulty.expectedCluster)
+	if faults.Calls["DescribeTasks"] == before {
+		t.Error("expected Tasks described for PendingTasks when container size is given")
+	}
+}
+
+func TestSnitcher_MeasureResourcesExcludeDaemons(t *testing.T) {
+	fake := NewFakeECS(t)
+	fake.expectedDescribeTasksOutput.Tasks = append(
+		fake.expectedDescribeTasksOutput.Tasks,
+		&ecs.Task{Cpu: aws.String("4096"), Group: aws.String("service:log-shipper"), Memory: aws.String("8192")},
+		&ecs.Task{Cpu: aws.String("128"), Group: aws.String("service:web"), Memory: aws.String("256")},
+	)
+	fake.expectedServices = []*ecs.Service{
+		{SchedulingStrategy: aws.String("DAEMON"), ServiceName: aws.String("log-shipper")},
+		{SchedulingStrategy: aws.String("REPLICA"), ServiceName: aws.String("web")},
+	}
+	sn := &Snitcher{ECS: fake}
+	if cpu, memory := sn.MeasureResources(fake.expectedCluster, <-sn.DiscoverTasks(fake.expectedCluster)); cpu != 4096 || memory != 8192 {
+		t.Errorf("expected DAEMON Task's 4096, 8192 without ExcludeDaemons but got %d, %d", cpu, memory)
+	}
+	sn.ExcludeDaemons = aws.Bool(true)
+	cpu, memory := sn.MeasureResources(fake.expectedCluster, <-sn.DiscoverTasks(fake.expectedCluster))
+	if fake.expectedCPU != cpu || fake.expectedMemory != memory {
+		t.Errorf("expected %d, %d with ExcludeDaemons but got %d, %d", fake.expectedCPU, fake.expectedMemory, cpu, memory)
+	}
+}
+
+func TestSnitcher_DescribeDaemonServices(t *testing.T) {
+	fake := NewFakeECS(t)
+	var names []*string
+	for i := 0; i < 15; i++ {
+		name := "service-" + strconv.Itoa(i)
+		strategy := "REPLICA"
+		if i%7 == 0 {
+			strategy = "DAEMON"
+		}
+		names = append(names, aws.String(name))
+		fake.expectedServices = append(fake.expectedServices, &ecs.Service{SchedulingStrategy: aws.String(strategy), ServiceName: aws.String(name)})
+	}
+	sn := &Snitcher{ECS: fake}
+	daemons := sn.DescribeDaemonServices(fake.expectedCluster, names)
+	if !daemons["service-0"] || !daemons["service-7"] || !daemons["service-14"] || len(daemons) != 3 {
+		t.Errorf("expected DAEMON Services 0, 7 and 14 across batches but got %v", daemons)
+	}
+	fake.errorToReturn = errors.New("there should be no DAEMON Services on error")
+	if daemons := sn.DescribeDaemonServices(fake.expectedCluster, names); len(daemons) != 0 {
+		t.Errorf("expected no DAEMON Services but got %v", daemons)
+	}
+}
+
+func Test_serviceName(t *testing.T) {
+	for group, expected := range map[string]string{
+		"service:my-service": "my-service",
+		"family:my-family":   "",
+		"":                   "",
+	} {
+		if actual := serviceName(&ecs.Task{Group: aws.String(group)}); actual != expected {
+			t.Errorf("serviceName() of Group %q = %q, want %q", group, actual, expected)
+		}
+	}
+}
+
 func TestSnitcher_ListContainerInstances(t *testing.T) {
 	fake := NewFakeECS(t)
 	sn := &Snitcher{ECS: fake}
@@ -330,6 +716,129 @@ func TestSnitcher_DescribeResourcesByInstanceType(t *testing.T) {
 	}
 }
 
+func TestSnitcher_DescribeClusterResources(t *testing.T) {
+	fake := NewFakeECS(t)
+	for _, container := range fake.expectedContainerInstances {
+		container.RunningTasksCount = aws.Int64(4)
+	}
+	sn := &Snitcher{ECS: fake}
+	cr := sn.DescribeClusterResources(
+		fake.expectedCluster,
+		aws.StringSlice(fake.expectedContainerInstanceArns),
+		fake.expectedCPU,
+		fake.expectedMemory,
+	)
+	if expected := len(fake.expectedContainerInstances); cr.Instances["fake.2xlarge"] != expected {
+		t.Errorf("expected %d ActiveInstanceCount but got %d", expected, cr.Instances["fake.2xlarge"])
+	}
+	if expected := 4 * len(fake.expectedContainerInstances); cr.Running["fake.2xlarge"] != expected {
+		t.Errorf("expected %d RunningTasks but got %d", expected, cr.Running["fake.2xlarge"])
+	}
+	if expected := fake.expectedRegisteredPossible - fake.expectedRemainingPossible; cr.RunningSlots["fake.2xlarge"] != expected {
+		t.Errorf("expected %d RunningTaskSlots but got %d", expected, cr.RunningSlots["fake.2xlarge"])
+	}
+}
+
+func TestSnitcher_DescribeClusterResourcesDisconnected(t *testing.T) {
+	fake := NewFakeECS(t)
+	disconnected := fake.expectedContainerInstances[0]
+	disconnected.AgentConnected = aws.Bool(false)
+	sn := &Snitcher{ECS: fake}
+	cr := sn.DescribeClusterResources(
+		fake.expectedCluster,
+		aws.StringSlice(fake.expectedContainerInstanceArns),
+		fake.expectedCPU,
+		fake.expectedMemory,
+	)
+	if actual := cr.Totals["DisconnectedAgents"]; actual != 1 {
+		t.Errorf("expected 1 DisconnectedAgents but got %f", actual)
+	}
+	if cr.Registered["fake.2xlarge"] != fake.expectedRegisteredPossible {
+		t.Errorf("expected disconnected capacity to stay registered (%d) but got %d", fake.expectedRegisteredPossible, cr.Registered["fake.2xlarge"])
+	}
+	expected := fake.expectedRemainingPossible - ContainersPossible(fake.expectedCPU, fake.expectedMemory, disconnected.RemainingResources)
+	if cr.Remaining["fake.2xlarge"] != expected {
+		t.Errorf("expected %d RemainingSchedulable without disconnected agent but got %d", expected, cr.Remaining["fake.2xlarge"])
+	}
+}
+
+func TestSnitcher_DescribeClusterResourcesAllDisconnected(t *testing.T) {
+	fake := NewFakeECS(t)
+	for _, container := range fake.expectedContainerInstances {
+		container.AgentConnected = aws.Bool(false)
+	}
+	sn := &Snitcher{ECS: fake}
+	cr := sn.DescribeClusterResources(
+		fake.expectedCluster,
+		aws.StringSlice(fake.expectedContainerInstanceArns),
+		fake.expectedCPU,
+		fake.expectedMemory,
+	)
+	remaining, ok := cr.Remaining["fake.2xlarge"]
+	if !ok || remaining != 0 {
+		t.Errorf("expected 0 RemainingSchedulable, not none, with every agent disconnected but got %d (%t)", remaining, ok)
+	}
+	published := false
+	for _, datum := range cr.ToMetricData() {
+		published = published || aws.StringValue(datum.MetricName) == "RemainingSchedulable"
+	}
+	if !published {
+		t.Error("expected RemainingSchedulable among metrics with every agent disconnected")
+	}
+}
+
+func TestSnitcher_DescribeClusterResourcesAttributes(t *testing.T) {
+	fake := NewFakeECS(t)
+	fake.expectedContainerInstances[0].Attributes = append(fake.expectedContainerInstances[0].Attributes, &ecs.Attribute{
+		Name:  aws.String("stack"),
+		Value: aws.String("blue"),
+	})
+	sn := &Snitcher{ECS: fake, DimensionAttributes: []string{"stack"}}
+	cr := sn.DescribeClusterResources(
+		fake.expectedCluster,
+		aws.StringSlice(fake.expectedContainerInstanceArns),
+		fake.expectedCPU,
+		fake.expectedMemory,
+	)
+	blue, none := "fake.2xlarge,stack=blue", "fake.2xlarge,stack=none"
+	if cr.Instances[blue] != 1 || cr.Instances[none] != len(fake.expectedContainerInstances)-1 {
+		t.Errorf("expected Container Instances partitioned by stack but got %v", cr.Instances)
+	}
+	if actual := cr.Dimensions[blue]; len(actual) != 2 || *actual[1].Name != "stack" || *actual[1].Value != "blue" {
+		t.Errorf("expected InstanceType and stack dimensions but got %v", actual)
+	}
+	if valid := ValidateMetricData(cr.ToMetricData()); len(valid) == 0 {
+		t.Error("expected partitioned metrics to be valid")
+	}
+}
+
+func TestSnitcher_DescribeClusterResourcesWeights(t *testing.T) {
+	fake := NewFakeECS(t)
+	sn := &Snitcher{ECS: fake, InstanceTypeWeights: map[string]float64{"fake.2xlarge": 0}}
+	cr := sn.DescribeClusterResources(
+		fake.expectedCluster,
+		aws.StringSlice(fake.expectedContainerInstanceArns),
+		fake.expectedCPU,
+		fake.expectedMemory,
+	)
+	if cr.Registered["fake.2xlarge"] != 0 || cr.Remaining["fake.2xlarge"] != 0 {
+		t.Errorf("expected ignored instance type to have 0 schedulable but got %d, %d", cr.Registered["fake.2xlarge"], cr.Remaining["fake.2xlarge"])
+	}
+	if expected := fake.expectedRegisteredPossible - fake.expectedRemainingPossible; cr.RunningSlots["fake.2xlarge"] != expected {
+		t.Errorf("expected %d RunningTaskSlots regardless of weight but got %d", expected, cr.RunningSlots["fake.2xlarge"])
+	}
+	sn.InstanceTypeWeights["fake.2xlarge"] = 0.5
+	cr = sn.DescribeClusterResources(
+		fake.expectedCluster,
+		aws.StringSlice(fake.expectedContainerInstanceArns),
+		fake.expectedCPU,
+		fake.expectedMemory,
+	)
+	if expected := len(fake.expectedContainerInstances) * (fake.expectedRegisteredPossible / len(fake.expectedContainerInstances) / 2); cr.Registered["fake.2xlarge"] != expected {
+		t.Errorf("expected %d RegisteredSchedulable at half weight but got %d", expected, cr.Registered["fake.2xlarge"])
+	}
+}
+
 func Test_getInstanceType(t *testing.T) {
 	expected := "wanted.2xl"
 	attributes := []*ecs.Attribute{
@@ -380,6 +889,42 @@ func TestSnitcher_DiscoverClusters(t *testing.T) {
 	}
 }
 
+func TestSnitcher_DiscoverClustersPatterns(t *testing.T) {
+	fake := NewFakeECS(t)
+	for _, test := range []struct {
+		include, exclude []string
+		expected         string
+	}{
+		{nil, nil, "fake-ecs-cluster another-fake-ecs-cluster who-even-uses-fargate"},
+		{[]string{"*fake*"}, nil, "fake-ecs-cluster another-fake-ecs-cluster"},
+		{nil, []string{"/^another-/", "who-*"}, "fake-ecs-cluster"},
+		{[]string{"*-cluster"}, []string{"fake-*"}, "another-fake-ecs-cluster"},
+		{[]string{"[", "/(/"}, nil, ""},
+	} {
+		sn := &Snitcher{ECS: fake, Include: test.include, Exclude: test.exclude}
+		var names []string
+		for name := range sn.DiscoverClusters() {
+			names = append(names, *name)
+		}
+		if actual := strings.Join(names, " "); actual != test.expected {
+			t.Errorf("expected include %v, exclude %v to discover %q but got %q", test.include, test.exclude, test.expected, actual)
+		}
+	}
+}
+
+func TestSnitcher_Clusters(t *testing.T) {
+	fake := NewFakeECS(t)
+	fake.errorToReturn = errors.New("single-cluster mode shouldn't ListClusters")
+	sn := &Snitcher{ECS: fake, Cluster: aws.String("only-cluster")}
+	var names []string
+	for name := range sn.Clusters() {
+		names = append(names, *name)
+	}
+	if len(names) != 1 || names[0] != "only-cluster" {
+		t.Errorf("expected only \"only-cluster\" but got %v", names)
+	}
+}
+
 func TestSnitcher_DiscoverClustersError(t *testing.T) {
 	// For some reason errorToReturn doesn't work right if NewFakeECS constructor is used here like this:
 	//	fake = NewFakeECS(t)
@@ -405,6 +950,48 @@ func TestSnitcher_WithAWS(t *testing.T) {
 
 }
 
+func TestSnitcher_WithAWSCredentialProcess(t *testing.T) {
+	sn := (&Snitcher{
+		Region:            aws.String("us-east-1"),
+		CredentialProcess: aws.String(`echo '{"Version": 1, "AccessKeyId": "AKIDPROCESS", "SecretAccessKey": "secret"}'`),
+	}).WithAWS()
+	credentials, err := sn.ECS.(*ecs.ECS).Config.Credentials.Get()
+	if err != nil || credentials.AccessKeyID != "AKIDPROCESS" {
+		t.Errorf("expected credentials from process but got %+v, %v", credentials, err)
+	}
+}
+
+func TestSnitcher_WithAWSProfile(t *testing.T) {
+	file, err := ioutil.TempFile("", "credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	fmt.Fprintln(file, "[snitch-test]\naws_access_key_id = AKIDPROFILE\naws_secret_access_key = secret")
+	file.Close()
+	defer os.Setenv("AWS_SHARED_CREDENTIALS_FILE", os.Getenv("AWS_SHARED_CREDENTIALS_FILE"))
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", file.Name())
+	sn := (&Snitcher{Region: aws.String("us-east-1"), Profile: aws.String("snitch-test")}).WithAWS()
+	credentials, err := sn.ECS.(*ecs.ECS).Config.Credentials.Get()
+	if err != nil || credentials.AccessKeyID != "AKIDPROFILE" {
+		t.Errorf("expected credentials of profile but got %+v, %v", credentials, err)
+	}
+}
+
+func TestSnitcher_WithAWSEndpoints(t *testing.T) {
+	sn := (&Snitcher{
+		Region:             aws.String("us-east-1"),
+		ECSEndpoint:        aws.String("http://localhost:4566"),
+		CloudWatchEndpoint: aws.String(""),
+	}).WithAWS()
+	if endpoint := sn.ECS.(*ecs.ECS).Endpoint; endpoint != "http://localhost:4566" {
+		t.Errorf("expected ECS endpoint overridden but got %q", endpoint)
+	}
+	if endpoint := sn.CloudWatch.(*cloudwatch.CloudWatch).Endpoint; !strings.Contains(endpoint, "amazonaws.com") {
+		t.Errorf("expected CloudWatch's default endpoint but got %q", endpoint)
+	}
+}
+
 func TestRun(t *testing.T) {
 	cw := &FakeCloudWatch{}
 	ecs := NewFakeECS(t)
@@ -423,6 +1010,74 @@ func TestRun(t *testing.T) {
 	}
 }
 
+func TestRunCanary(t *testing.T) {
+	cw := &FakeCloudWatch{}
+	ecs := NewFakeECS(t)
+	ecs.expectedClusterArns = nil
+	sn := &Snitcher{
+		CloudWatch:      cw,
+		ECS:             ecs,
+		Namespace:       aws.String("Collector/Test"),
+		ShouldPublish:   aws.Bool(true),
+		CanaryNamespace: aws.String("Collector/Canary"),
+	}
+	Run(sn)
+	if len(cw.payload) != 1 {
+		t.Fatalf("expected only canary published but got %d payloads", len(cw.payload))
+	}
+	canary := cw.payload[0]
+	if *canary.Namespace != *sn.CanaryNamespace || *canary.MetricData[0].MetricName != "Canary" {
+		t.Errorf("expected Canary in %q but got %s", *sn.CanaryNamespace, canary)
+	}
+	if valid := ValidateMetricData(canary.MetricData); len(valid) != 1 {
+		t.Error("expected Canary to be valid")
+	}
+}
+
+func TestSnitcher_MeasureClusterTags(t *testing.T) {
+	fake := NewFakeECS(t)
+	fake.expectedTags = []*ecs.Tag{{Key: aws.String("Team"), Value: aws.String("capacity")}}
+	sn := &Snitcher{ECS: fake, DimensionTags: []string{"Team", "CostCenter"}}
+	metricData := sn.MeasureCluster(fake.expectedCluster)
+	if len(metricData) == 0 {
+		t.Fatal("expected some data points")
+	}
+	for _, datum := range metricData {
+		tags := map[string]string{}
+		for _, dimension := range datum.Dimensions {
+			tags[*dimension.Name] = *dimension.Value
+		}
+		if tags["Team"] != "capacity" || tags["CostCenter"] != "none" {
+			t.Errorf("expected Team and CostCenter dimensions but got %v", datum.Dimensions)
+		}
+	}
+	if valid := ValidateMetricData(metricData); len(valid) != len(metricData) {
+		t.Errorf("expected all %d data points to be valid but got %d", len(metricData), len(valid))
+	}
+}
+
+func TestSnitcher_MeasureClusterSelectTags(t *testing.T) {
+	fake := NewFakeECS(t)
+	fake.expectedTags = []*ecs.Tag{{Key: aws.String("snitch:enabled"), Value: aws.String("false")}}
+	sn := &Snitcher{ECS: fake, SelectTags: map[string]string{"snitch:enabled": "true"}}
+	if metricData := sn.MeasureCluster(fake.expectedCluster); len(metricData) != 0 {
+		t.Errorf("expected cluster not tagged to be skipped but got %d data points", len(metricData))
+	}
+	fake.expectedTags[0].Value = aws.String("true")
+	if metricData := sn.MeasureCluster(fake.expectedCluster); len(metricData) == 0 {
+		t.Error("expected cluster tagged to be measured")
+	}
+}
+
+func TestSnitcher_DescribeClusterTagsError(t *testing.T) {
+	fake := NewFakeECS(t)
+	fake.errorToReturn = errors.New("tags should be unknown on error")
+	sn := &Snitcher{ECS: fake}
+	if tags := sn.DescribeClusterTags(fake.expectedCluster); tags != nil {
+		t.Errorf("expected nil but got %v", tags)
+	}
+}
+
 func TestSnitcher_MeasureClusterEmpty(t *testing.T) {
 	// Ensure empty response from FakeECS.
 	ecs := &FakeECS{
@@ -436,3 +1091,85 @@ func TestSnitcher_MeasureClusterEmpty(t *testing.T) {
 		t.Errorf("expected 0 data points but got %d", len(actual))
 	}
 }
+
+func TestSnitcher_MeasureClusterOverride(t *testing.T) {
+	fake := NewFakeECS(t)
+	// Nothing's running, so without CPU and Memory the cluster is skipped.
+	fake.expectedDescribeTasksOutput = &ecs.DescribeTasksOutput{}
+	sn := &Snitcher{
+		CPU:    aws.Int(512),
+		ECS:    fake,
+		Memory: aws.Int(1024),
+	}
+	metricData := sn.MeasureCluster(fake.expectedCluster)
+	if len(metricData) == 0 {
+		t.Fatal("expected data points when CPU and Memory are supplied")
+	}
+	expected := len(fake.expectedContainerInstances) * ContainersPossible(512, 1024, fake.expectedRemaining)
+	for _, datum := range metricData {
+		switch *datum.MetricName {
+		case "LowestCommonMultipleCPU":
+			if int(*datum.Value) != 512 {
+				t.Errorf("expected 512 CPU Units but got %v", *datum.Value)
+			}
+		case "LowestCommonMultipleMemory":
+			if int(*datum.Value) != 1024 {
+				t.Errorf("expected 1024 MiB but got %v", *datum.Value)
+			}
+		case "RemainingSchedulable":
+			if int(*datum.Value) != expected {
+				t.Errorf("expected %d RemainingSchedulable but got %v", expected, *datum.Value)
+			}
+		}
+	}
+}
+
+func TestSnitcher_MeasureClusterFloor(t *testing.T) {
+	fake := NewFakeECS(t)
+	fake.expectedDescribeTasksOutput = &ecs.DescribeTasksOutput{
+		Tasks: []*ecs.Task{{Cpu: aws.String("16"), Memory: aws.String("32")}},
+	}
+	sn := &Snitcher{
+		ECS:       fake,
+		MinCPU:    aws.Int(256),
+		MinMemory: aws.Int(512),
+	}
+	for _, datum := range sn.MeasureCluster(fake.expectedCluster) {
+		switch *datum.MetricName {
+		case "LowestCommonMultipleCPU":
+			if int(*datum.Value) != 256 {
+				t.Errorf("expected CPU clamped to 256 but got %v", *datum.Value)
+			}
+		case "LowestCommonMultipleMemory":
+			if int(*datum.Value) != 512 {
+				t.Errorf("expected Memory clamped to 512 but got %v", *datum.Value)
+			}
+		}
+	}
+}
+
+func TestSnitcher_MeasureClusterIdle(t *testing.T) {
+	fake := NewFakeECS(t)
+	fake.expectedDescribeTasksOutput = &ecs.DescribeTasksOutput{}
+	sn := &Snitcher{
+		DefaultCPU:    aws.Int(fake.expectedCPU),
+		DefaultMemory: aws.Int(fake.expectedMemory),
+		ECS:           fake,
+	}
+	metricData := sn.MeasureCluster(fake.expectedCluster)
+	if len(metricData) == 0 {
+		t.Fatal("expected data points for idle cluster with default size")
+	}
+	for _, datum := range metricData {
+		switch *datum.MetricName {
+		case "RegisteredSchedulable":
+			if int(*datum.Value) != fake.expectedRegisteredPossible {
+				t.Errorf("expected %d RegisteredSchedulable but got %v", fake.expectedRegisteredPossible, *datum.Value)
+			}
+		case "RunningTasks":
+			if *datum.Value != 0 {
+				t.Errorf("expected 0 RunningTasks but got %v", *datum.Value)
+			}
+		}
+	}
+}
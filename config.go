@@ -0,0 +1,130 @@
+package snitch
+
+import (
+	"encoding/json"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// aliases name Snitcher's fields, in configuration, Lambda events and
+// environment variables, besides their own names.
+var aliases = map[string]string{
+	"publish":  "ShouldPublish",
+	"clusters": "Include",
+}
+
+// ParseConfig reads a Snitcher from YAML, or JSON, with keys named like its
+// fields, in any case, or aliases like "clusters" for Include and "publish"
+// for ShouldPublish, like the Lambda event:
+//
+//	namespace: ECS/Snitch
+//	shouldPublish: true
+//	exclude: ["ci-*"]
+//	regions: [us-east-1, us-west-2]
+//	roles: ["arn:aws:iam::123456789012:role/snitch"]
+//	sinks:
+//	  - type: sns
+//	    url: arn:aws:sns:us-east-1:123456789012:capacity
+//	    thresholds: {"*": 5, "prod-*": 20}
+func ParseConfig(data []byte) (*Snitcher, error) {
+	var parsed interface{}
+	if err := yaml.Unmarshal(data, &parsed); err != nil {
+		return nil, err
+	}
+	if fields, ok := parsed.(map[string]interface{}); ok {
+		unalias(fields)
+	}
+	// Snitcher's JSON, which Lambda events are, is what YAML maps to.
+	encoded, err := json.Marshal(parsed)
+	if err != nil {
+		return nil, err
+	}
+	sn := &Snitcher{}
+	if parsed == nil {
+		return sn, nil
+	}
+	return sn, json.Unmarshal(encoded, sn)
+}
+
+// unalias renames aliased fields to their own names, unless also given by
+// their own names, which win.
+func unalias(fields map[string]interface{}) {
+	for key, value := range fields {
+		name, ok := aliases[strings.ToLower(key)]
+		if !ok {
+			continue
+		}
+		delete(fields, key)
+		given := false
+		for other := range fields {
+			given = given || strings.EqualFold(other, name)
+		}
+		if !given {
+			fields[name] = value
+		}
+	}
+}
+
+// Event is what a Lambda event may override of configuration: a schedule's
+// scope, like
+//
+//	{"clusters": ["prod-*"], "namespace": "ECS/Snitch", "publish": true, "cpu": 512, "memory": 1024}
+//
+// and nothing else, since anyone allowed to invoke the function sets it, lest
+// they run commands, like CredentialProcess, or send credentials or metrics
+// elsewhere, like by Roles, endpoints or Sinks.
+type Event struct {
+	Clusters      []string `json:"clusters"`
+	Cluster       *string  `json:"cluster"`
+	Namespace     *string  `json:"namespace"`
+	ShouldPublish *bool    `json:"publish"`
+	CPU           *int     `json:"cpu"`
+	Memory        *int     `json:"memory"`
+	// Step Functions fan out by listing clusters, then measuring each.
+	DiscoverOnly         *bool `json:"discoverOnly"`
+	Trace                *bool `json:"trace"`
+	EmbeddedMetricFormat *bool `json:"embeddedMetricFormat"`
+}
+
+// ParseEvent reads an Event from a Lambda event's JSON, ignoring other keys,
+// like a scheduled event's "detail-type".
+func ParseEvent(data []byte) (*Event, error) {
+	event := &Event{}
+	if len(data) == 0 {
+		return event, nil
+	}
+	return event, json.Unmarshal(data, event)
+}
+
+// Override sets sn's fields to those event sets.
+func (event *Event) Override(sn *Snitcher) *Snitcher {
+	if event.Clusters != nil {
+		sn.Include = event.Clusters
+	}
+	if event.Cluster != nil {
+		sn.Cluster = event.Cluster
+	}
+	if event.Namespace != nil {
+		sn.Namespace = event.Namespace
+	}
+	if event.ShouldPublish != nil {
+		sn.ShouldPublish = event.ShouldPublish
+	}
+	if event.CPU != nil {
+		sn.CPU = event.CPU
+	}
+	if event.Memory != nil {
+		sn.Memory = event.Memory
+	}
+	if event.DiscoverOnly != nil {
+		sn.DiscoverOnly = event.DiscoverOnly
+	}
+	if event.Trace != nil {
+		sn.Trace = event.Trace
+	}
+	if event.EmbeddedMetricFormat != nil {
+		sn.EmbeddedMetricFormat = event.EmbeddedMetricFormat
+	}
+	return sn
+}
@@ -0,0 +1,79 @@
+package snitch
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestParseConfig(t *testing.T) {
+	sn, err := ParseConfig([]byte(`
+namespace: ECS/Snitch
+shouldPublish: true
+cpu: 512
+exclude: ["ci-*"]
+selectTags: {snitch: enabled}
+regions: [us-east-1, us-west-2]
+sinks:
+  - type: sns
+    url: arn:aws:sns:us-east-1:123456789012:capacity
+    thresholds: {"*": 5, "prod-*": 20}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aws.StringValue(sn.Namespace) != "ECS/Snitch" || !aws.BoolValue(sn.ShouldPublish) || aws.IntValue(sn.CPU) != 512 {
+		t.Errorf("expected namespace, publishing and CPU but got %+v", sn)
+	}
+	if len(sn.Exclude) != 1 || sn.SelectTags["snitch"] != "enabled" || len(sn.Regions) != 2 {
+		t.Errorf("expected filters and regions but got %+v", sn)
+	}
+	if len(sn.Sinks) != 1 || sn.Sinks[0].Type != "sns" || sn.Sinks[0].Thresholds["prod-*"] != 20 {
+		t.Errorf("expected an SNS sink but got %+v", sn.Sinks)
+	}
+
+	if sn, err = ParseConfig([]byte(`{"Namespace": "Testable/Namespace"}`)); err != nil || aws.StringValue(sn.Namespace) != "Testable/Namespace" {
+		t.Errorf("expected JSON read too but got %+v: %s", sn, err)
+	}
+	sn, err = ParseConfig([]byte(`{"clusters": ["prod-*"], "namespace": "ECS/Snitch", "publish": true, "cpu": 512, "memory": 1024}`))
+	if err != nil || len(sn.Include) != 1 || !aws.BoolValue(sn.ShouldPublish) || aws.IntValue(sn.Memory) != 1024 {
+		t.Errorf("expected clusters and publish aliases read but got %+v: %s", sn, err)
+	}
+	if sn, err = ParseConfig([]byte(`{"publish": true, "shouldPublish": false}`)); err != nil || aws.BoolValue(sn.ShouldPublish) {
+		t.Errorf("expected field's own name over its alias but got %+v: %s", sn, err)
+	}
+	if sn, err = ParseConfig(nil); err != nil || sn == nil {
+		t.Errorf("expected empty config to be empty Snitcher but got %+v: %s", sn, err)
+	}
+	for _, invalid := range []string{"namespace: [", "cpu: lots", "- just\n- a list"} {
+		if _, err = ParseConfig([]byte(invalid)); err == nil {
+			t.Errorf("expected %q to fail", invalid)
+		}
+	}
+}
+
+func TestParseEvent(t *testing.T) {
+	event, err := ParseEvent([]byte(`{"clusters": ["prod-*"], "namespace": "ECS/Snitch", "publish": true, "cpu": 512,
+		"credentialProcess": "touch /tmp/pwned", "roles": ["arn:aws:iam::999999999999:role/elsewhere"],
+		"sinks": [{"type": "webhook", "url": "https://example.com"}], "ecsEndpoint": "http://example.com"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	configured := &Snitcher{Namespace: aws.String("Configured/Namespace"), Memory: aws.Int(1024), Exclude: []string{"ci-*"}}
+	sn := event.Override(configured)
+	if aws.StringValue(sn.Namespace) != "ECS/Snitch" || !aws.BoolValue(sn.ShouldPublish) || aws.IntValue(sn.CPU) != 512 || len(sn.Include) != 1 {
+		t.Errorf("expected event's scope to override configuration but got %+v", sn)
+	}
+	if aws.IntValue(sn.Memory) != 1024 || len(sn.Exclude) != 1 {
+		t.Errorf("expected configuration the event doesn't set kept but got %+v", sn)
+	}
+	if sn.CredentialProcess != nil || sn.Roles != nil || sn.Sinks != nil || sn.ECSEndpoint != nil {
+		t.Errorf("expected event unable to set anything but scope but got %+v", sn)
+	}
+	if _, err = ParseEvent(nil); err != nil {
+		t.Error(err)
+	}
+	if sn, err = ParseConfig([]byte(`{"credentialProcess": "touch /tmp/pwned", "webIdentityTokenFile": "/etc/shadow"}`)); err != nil || sn.CredentialProcess != nil || sn.WebIdentityTokenFile != nil {
+		t.Errorf("expected configuration unable to set credential commands or files but got %+v: %s", sn, err)
+	}
+}
@@ -0,0 +1,58 @@
+package snitch
+
+import (
+	"math/rand"
+	"time"
+)
+
+// RunEvery runs Run every interval, plus up to jitter so many snitches don't
+// call AWS in lockstep, until stop is closed. AWS clients are added once and
+// reused. A run in progress when stop closes is finished first:
+//
+//	stop := make(chan struct{})
+//	go func() { <-sigterm; close(stop) }()
+//	snitch.RunEvery(sn, time.Minute, 5*time.Second, stop)
+func RunEvery(sn *Snitcher, interval, jitter time.Duration, stop <-chan struct{}) {
+	sn.WithAWS()
+	for {
+		started := time.Now()
+		if sn.Reconfigure != nil {
+			sn.Reconfigure(sn)
+		}
+		sn.Progress.restart()
+		sn.Health.begin(sn.Progress)
+		sinks := sn.runSinks()
+		for _, sink := range sinks {
+			sink.begin()
+		}
+		sn.Health.done(sn.checked(sn.run))
+		for _, sink := range sinks {
+			sink.done()
+		}
+		wait := interval - time.Since(started)
+		if jitter > 0 {
+			wait += time.Duration(rand.Int63n(int64(jitter)))
+		}
+		if wait < 0 {
+			sn.logger().Warnf("Running took %s, longer than interval %s", time.Since(started), interval)
+			wait = 0
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// runSinks are Sinks that keep state between runs, like API.
+func (sn *Snitcher) runSinks() (sinks []runSink) {
+	for _, config := range sn.Sinks {
+		if sink, ok := config.Sink.(runSink); ok {
+			sinks = append(sinks, sink)
+		}
+	}
+	return
+}
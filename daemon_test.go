@@ -0,0 +1,36 @@
+package snitch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestRunEvery(t *testing.T) {
+	fake := NewFakeECS(t)
+	fake.checkCluster = false
+	cloudWatch := &FakeCloudWatch{}
+	sn := &Snitcher{ECS: fake, CloudWatch: cloudWatch, Namespace: aws.String("Testable/Namespace"), ShouldPublish: aws.Bool(true)}
+	reconfigured := 0
+	sn.Reconfigure = func(*Snitcher) { reconfigured++ }
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		RunEvery(sn, time.Millisecond, time.Millisecond, stop)
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected RunEvery to stop")
+	}
+	if len(cloudWatch.payload) < 2 {
+		t.Errorf("expected metrics published every run but got %d batches", len(cloudWatch.payload))
+	}
+	if reconfigured == 0 {
+		t.Error("expected Reconfigure before runs")
+	}
+}
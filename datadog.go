@@ -0,0 +1,110 @@
+package snitch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// DatadogSink submits metrics to Datadog's API as gauges tagged with their
+// dimensions, like "cluster:my-cluster", skipping CloudWatch altogether.
+type DatadogSink struct {
+	// URL of Datadog's API, like "https://api.datadoghq.eu".
+	URL string
+	// API key, and optionally application key, of Datadog.
+	APIKey string
+	AppKey string
+	// Prefix of metric names, like "ecs.snitch".
+	Prefix string
+	Client *http.Client
+}
+
+// newDatadogSink configures a DatadogSink with API key of config's Secret, if
+// set, or else environment variable DD_API_KEY. Its URL is config's, if set,
+// or else of environment variable DD_SITE, like "datadoghq.eu".
+func (sn *Snitcher) newDatadogSink(config *SinkConfig) *DatadogSink {
+	sink := &DatadogSink{
+		URL:    config.URL,
+		APIKey: os.Getenv("DD_API_KEY"),
+		AppKey: os.Getenv("DD_APP_KEY"),
+		Prefix: config.Namespace,
+		Client: config.client(),
+	}
+	if config.Secret != "" {
+		sink.APIKey = sn.secretOf(config)
+	}
+	if sink.URL == "" {
+		site := os.Getenv("DD_SITE")
+		if site == "" {
+			site = "datadoghq.com"
+		}
+		sink.URL = "https://api." + site
+	}
+	return sink
+}
+
+// datadogSeries is one series of Datadog's v1 series API.
+type datadogSeries struct {
+	Metric string       `json:"metric"`
+	Points [][2]float64 `json:"points"`
+	Type   string       `json:"type"`
+	Tags   []string     `json:"tags,omitempty"`
+}
+
+// Send metrics to Datadog as a series each.
+func (sink *DatadogSink) Send(metricData []*cloudwatch.MetricDatum) error {
+	if sink.APIKey == "" {
+		return errors.New("no Datadog API key")
+	}
+	var series []*datadogSeries
+	for _, datum := range metricData {
+		timestamp := time.Now()
+		if datum.Timestamp != nil {
+			timestamp = *datum.Timestamp
+		}
+		metric := aws.StringValue(datum.MetricName)
+		if sink.Prefix != "" {
+			metric = strings.Replace(sink.Prefix, "/", ".", -1) + "." + metric
+		}
+		var tags []string
+		for _, dimension := range datum.Dimensions {
+			tags = append(tags, label(aws.StringValue(dimension.Name))+":"+aws.StringValue(dimension.Value))
+		}
+		series = append(series, &datadogSeries{
+			Metric: metric,
+			Points: [][2]float64{{float64(timestamp.Unix()), aws.Float64Value(datum.Value)}},
+			Type:   "gauge",
+			Tags:   tags,
+		})
+	}
+	body, err := json.Marshal(map[string][]*datadogSeries{"series": series})
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest("POST", strings.TrimSuffix(sink.URL, "/")+"/api/v1/series", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("DD-API-KEY", sink.APIKey)
+	if sink.AppKey != "" {
+		request.Header.Set("DD-APPLICATION-KEY", sink.AppKey)
+	}
+	response, err := sink.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		return fmt.Errorf("Datadog responded %s", response.Status)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package snitch
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestDatadogSink_Send(t *testing.T) {
+	var received map[string][]*datadogSeries
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/series" || r.Header.Get("DD-API-KEY") != "fake-key" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	sn := &Snitcher{SecretsManager: &FakeSecretsManager{secrets: map[string]string{"snitch/datadog": "fake-key"}}}
+	sink := sn.newDatadogSink(&SinkConfig{URL: server.URL, Namespace: "ecs.snitch", Secret: "snitch/datadog"})
+	cr := NewClusterResources(aws.String("fake-cluster"))
+	cr.Remaining["fake.large"] = 2
+	if err := sink.Send(cr.ToMetricData()); err != nil {
+		t.Fatal(err)
+	}
+	series := received["series"]
+	if len(series) != 1 {
+		t.Fatalf("expected 1 series but got %v", series)
+	}
+	if series[0].Metric != "ecs.snitch.RemainingSchedulable" || series[0].Type != "gauge" || series[0].Points[0][1] != 2 {
+		t.Errorf("expected RemainingSchedulable gauge of 2 but got %+v", series[0])
+	}
+	if len(series[0].Tags) != 2 || series[0].Tags[0] != "cluster:fake-cluster" || series[0].Tags[1] != "instance_type:fake.large" {
+		t.Errorf("expected cluster and instance_type tags but got %v", series[0].Tags)
+	}
+	sink.APIKey = "wrong-key"
+	if err := sink.Send(cr.ToMetricData()); err == nil {
+		t.Error("expected an error when Datadog refuses")
+	}
+}
+
+func TestSnitcher_newDatadogSink(t *testing.T) {
+	defer os.Setenv("DD_SITE", os.Getenv("DD_SITE"))
+	os.Setenv("DD_SITE", "datadoghq.eu")
+	if sink := (&Snitcher{}).newDatadogSink(&SinkConfig{}); sink.URL != "https://api.datadoghq.eu" {
+		t.Errorf("expected DD_SITE's URL but got %q", sink.URL)
+	}
+	if err := (&DatadogSink{}).Send(nil); err == nil {
+		t.Error("expected an error without an API key")
+	}
+}
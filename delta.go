@@ -0,0 +1,313 @@
+package snitch
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// defaultDeltaMaxAge is how long an unchanged metric goes unpublished, at
+// most, so alarms on it don't run out of data.
+const defaultDeltaMaxAge = 15 * time.Minute
+
+// maxBatchWrite is how many items DynamoDB's BatchWriteItem takes at once.
+const maxBatchWrite = 25
+
+// deltaEntry is a metric's value last published, and when, in Unix seconds.
+type deltaEntry struct {
+	Value     float64 `json:"value"`
+	Published int64   `json:"published"`
+}
+
+// deltaStore keeps deltaEntries, by deltaKey, between runs. save is given
+// every entry and the keys of those changed, for stores that write only those.
+type deltaStore interface {
+	load() (map[string]*deltaEntry, error)
+	save(entries map[string]*deltaEntry, changed []string) error
+}
+
+// deltas suppresses publishing metrics whose values haven't changed since
+// they were last published, less than maxAge ago, keeping what was published
+// in store. Its methods are safe to call on nil deltas, which suppress nothing.
+type deltas struct {
+	mutex   sync.Mutex
+	store   deltaStore
+	maxAge  time.Duration
+	entries map[string]*deltaEntry // Nil until loaded from store.
+	// now tells time. Nil means time.Now.
+	now func() time.Time
+}
+
+// newDeltas keeps deltas in DeltaState: a file, "s3://my-bucket/snitch.json"
+// or "dynamodb:my-table", whose partition key is string "Key".
+//
+// Requires IAM permissions "s3:GetObject" and "s3:PutObject", or
+// "dynamodb:Scan" and "dynamodb:BatchWriteItem", of those.
+func (sn *Snitcher) newDeltas() *deltas {
+	d := &deltas{maxAge: defaultDeltaMaxAge}
+	if maxAge := aws.StringValue(sn.DeltaMaxAge); maxAge != "" {
+		if parsed, err := time.ParseDuration(maxAge); err != nil {
+			sn.logger().Errorf("Failed to parse delta max age %q: %s", maxAge, err)
+		} else {
+			d.maxAge = parsed
+		}
+	}
+	location := aws.StringValue(sn.DeltaState)
+	switch {
+	case strings.HasPrefix(location, "dynamodb:"):
+		d.store = &dynamoDBDeltas{Snitcher: sn, Table: strings.TrimPrefix(location, "dynamodb:")}
+	case strings.HasPrefix(location, "s3://"):
+		store := &s3Deltas{Snitcher: sn}
+		if parsed, err := url.Parse(location); err == nil {
+			store.Bucket, store.Key = parsed.Host, strings.TrimPrefix(parsed.Path, "/")
+		}
+		d.store = store
+	default:
+		d.store = fileDeltas(location)
+	}
+	return d
+}
+
+// deltaKey identifies a datum published by sn to namespace across runs, by
+// the role and region it's published as, its name, unit and dimensions.
+func (sn *Snitcher) deltaKey(namespace *string, datum *cloudwatch.MetricDatum) string {
+	return strings.Join([]string{
+		aws.StringValue(sn.Role),
+		aws.StringValue(sn.Region),
+		aws.StringValue(namespace),
+		aws.StringValue(datum.MetricName),
+		aws.StringValue(datum.Unit),
+		dimensionsKey(datum.Dimensions),
+	}, "/")
+}
+
+// loaded loads entries from store, if they aren't yet, reporting whether they
+// are. Callers hold mutex.
+func (d *deltas) loaded(sn *Snitcher) bool {
+	if d.entries != nil {
+		return true
+	}
+	entries, err := d.store.load()
+	if err != nil {
+		sn.logger().Errorf("Failed to load metrics last published: %s", err)
+		return false
+	}
+	if entries == nil {
+		entries = map[string]*deltaEntry{}
+	}
+	d.entries = entries
+	return true
+}
+
+func (d *deltas) timeNow() time.Time {
+	if d.now != nil {
+		return d.now()
+	}
+	return time.Now()
+}
+
+// suppress drops metricData published to namespace whose values are as last
+// published, less than maxAge ago. StatisticSets are always published.
+func (d *deltas) suppress(sn *Snitcher, namespace *string, metricData []*cloudwatch.MetricDatum) []*cloudwatch.MetricDatum {
+	if d == nil {
+		return metricData
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if !d.loaded(sn) {
+		return metricData
+	}
+	oldest := d.timeNow().Add(-d.maxAge).Unix()
+	changed := make([]*cloudwatch.MetricDatum, 0, len(metricData))
+	for _, datum := range metricData {
+		entry, ok := d.entries[sn.deltaKey(namespace, datum)]
+		if ok && datum.Value != nil && entry.Value == *datum.Value && entry.Published > oldest {
+			continue
+		}
+		changed = append(changed, datum)
+	}
+	if suppressed := len(metricData) - len(changed); suppressed > 0 {
+		sn.logger().Infof("Skipping %d metrics unchanged since last published", suppressed)
+	}
+	return changed
+}
+
+// published records metricData as published to namespace, and saves them.
+func (d *deltas) published(sn *Snitcher, namespace *string, metricData []*cloudwatch.MetricDatum) {
+	if d == nil || len(metricData) == 0 {
+		return
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if !d.loaded(sn) {
+		return
+	}
+	now := d.timeNow()
+	// Entries older than maxAge suppress nothing, like those of clusters since
+	// deleted, so stores written whole don't keep them.
+	oldest := now.Add(-d.maxAge).Unix()
+	for key, entry := range d.entries {
+		if entry.Published <= oldest {
+			delete(d.entries, key)
+		}
+	}
+	var changed []string
+	for _, datum := range metricData {
+		if datum.Value == nil {
+			continue
+		}
+		key := sn.deltaKey(namespace, datum)
+		d.entries[key] = &deltaEntry{Value: *datum.Value, Published: now.Unix()}
+		changed = append(changed, key)
+	}
+	if err := d.store.save(d.entries, changed); err != nil {
+		sn.logger().Errorf("Failed to save %d metrics published: %s", len(changed), err)
+	}
+}
+
+// fileDeltas keeps deltas as JSON in a file.
+type fileDeltas string
+
+func (path fileDeltas) load() (entries map[string]*deltaEntry, err error) {
+	data, err := ioutil.ReadFile(string(path))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &entries)
+	return
+}
+
+// save writes a temporary file and renames it over path, so runs interrupted
+// while saving don't leave half of it.
+func (path fileDeltas) save(entries map[string]*deltaEntry, changed []string) error {
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	temporary, err := ioutil.TempFile(filepath.Dir(string(path)), filepath.Base(string(path)))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temporary.Name())
+	if _, err = temporary.Write(data); err != nil {
+		temporary.Close()
+		return err
+	}
+	if err = temporary.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temporary.Name(), string(path))
+}
+
+// s3Deltas keeps deltas as a JSON object in S3.
+type s3Deltas struct {
+	Snitcher *Snitcher
+	Bucket   string
+	Key      string
+}
+
+func (store *s3Deltas) load() (entries map[string]*deltaEntry, err error) {
+	output, err := store.Snitcher.S3.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(store.Bucket),
+		Key:    aws.String(store.Key),
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer output.Body.Close()
+	err = json.NewDecoder(output.Body).Decode(&entries)
+	return
+}
+
+func (store *s3Deltas) save(entries map[string]*deltaEntry, changed []string) error {
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	_, err = store.Snitcher.S3.PutObject(&s3.PutObjectInput{
+		Bucket:      aws.String(store.Bucket),
+		Key:         aws.String(store.Key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	return err
+}
+
+// dynamoDBDeltas keeps deltas as an item per metric in a DynamoDB table.
+type dynamoDBDeltas struct {
+	Snitcher *Snitcher
+	Table    string
+}
+
+// deltaItem is a deltaEntry as stored in DynamoDB.
+type deltaItem struct {
+	Key       string
+	Value     float64
+	Published int64
+}
+
+func (store *dynamoDBDeltas) load() (map[string]*deltaEntry, error) {
+	entries := map[string]*deltaEntry{}
+	var unmarshalErr error
+	err := store.Snitcher.DynamoDB.ScanPages(&dynamodb.ScanInput{TableName: aws.String(store.Table)}, func(output *dynamodb.ScanOutput, lastPage bool) bool {
+		var items []*deltaItem
+		if unmarshalErr = dynamodbattribute.UnmarshalListOfMaps(output.Items, &items); unmarshalErr != nil {
+			return false
+		}
+		for _, item := range items {
+			entries[item.Key] = &deltaEntry{Value: item.Value, Published: item.Published}
+		}
+		return true
+	})
+	if err == nil {
+		err = unmarshalErr
+	}
+	return entries, err
+}
+
+// save writes changed entries, in as few BatchWriteItem calls as it can,
+// trying every batch even if some fail, and returns the last failure.
+func (store *dynamoDBDeltas) save(entries map[string]*deltaEntry, changed []string) (err error) {
+	for start := 0; start < len(changed); start += maxBatchWrite {
+		end := start + maxBatchWrite
+		if end > len(changed) {
+			end = len(changed)
+		}
+		var requests []*dynamodb.WriteRequest
+		for _, key := range changed[start:end] {
+			entry := entries[key]
+			attributes, marshalErr := dynamodbattribute.MarshalMap(&deltaItem{Key: key, Value: entry.Value, Published: entry.Published})
+			if marshalErr != nil {
+				err = marshalErr
+				continue
+			}
+			requests = append(requests, &dynamodb.WriteRequest{PutRequest: &dynamodb.PutRequest{Item: attributes}})
+		}
+		output, writeErr := store.Snitcher.DynamoDB.BatchWriteItem(&dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]*dynamodb.WriteRequest{store.Table: requests},
+		})
+		if writeErr != nil {
+			err = writeErr
+		} else if unprocessed := len(output.UnprocessedItems[store.Table]); unprocessed > 0 {
+			store.Snitcher.logger().Warnf("DynamoDB didn't save %d of %d metrics published; they'll be published again", unprocessed, len(requests))
+		}
+	}
+	return
+}
@@ -0,0 +1,139 @@
+package snitch
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+func TestSnitcher_publishDeltas(t *testing.T) {
+	directory, err := ioutil.TempDir("", "snitch-deltas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+	now := time.Now()
+	newSnitcher := func() (*Snitcher, *FakeCloudWatch) {
+		cloudWatch := &FakeCloudWatch{}
+		sn := &Snitcher{CloudWatch: cloudWatch, Namespace: aws.String("Testable/Namespace"), DeltaState: aws.String(filepath.Join(directory, "deltas.json"))}
+		sn.deltas = sn.newDeltas()
+		sn.deltas.now = func() time.Time { return now }
+		return sn, cloudWatch
+	}
+	measure := func(remaining int) []*cloudwatch.MetricDatum {
+		cr := NewClusterResources(aws.String("cluster"))
+		cr.Registered["c5.large"] = 4
+		cr.Remaining["c5.large"] = remaining
+		return cr.ToMetricData()
+	}
+	published := func(cloudWatch *FakeCloudWatch) (names []string) {
+		for _, input := range cloudWatch.payload {
+			for _, datum := range input.MetricData {
+				names = append(names, aws.StringValue(datum.MetricName))
+			}
+		}
+		return
+	}
+
+	sn, cloudWatch := newSnitcher()
+	sn.publish(sn.Namespace, measure(2))
+	if names := published(cloudWatch); len(names) != 2 {
+		t.Fatalf("expected every metric published the first run but got %v", names)
+	}
+	sn, cloudWatch = newSnitcher()
+	now = now.Add(time.Minute)
+	sn.publish(sn.Namespace, measure(1))
+	if names := published(cloudWatch); len(names) != 1 || names[0] != "RemainingSchedulable" {
+		t.Errorf("expected only RemainingSchedulable, which changed, published but got %v", names)
+	}
+	now = now.Add(defaultDeltaMaxAge - time.Second)
+	sn.publish(sn.Namespace, measure(1))
+	if names := published(cloudWatch); len(names) != 2 || names[1] != "RegisteredSchedulable" {
+		t.Errorf("expected RegisteredSchedulable published again after %s but got %v", defaultDeltaMaxAge, names)
+	}
+	sn, cloudWatch = newSnitcher()
+	sn.Namespace = aws.String("Another/Namespace")
+	sn.publish(sn.Namespace, measure(1))
+	if names := published(cloudWatch); len(names) != 2 {
+		t.Errorf("expected every metric published to another namespace but got %v", names)
+	}
+}
+
+func TestSnitcher_publishDeltasFailed(t *testing.T) {
+	store := &DeltaDynamoDB{}
+	cloudWatch := &FakeCloudWatch{errorToReturn: errors.New("CloudWatch is down")}
+	sn := &Snitcher{CloudWatch: cloudWatch, DynamoDB: store, Namespace: aws.String("Testable/Namespace"), DeltaState: aws.String("dynamodb:deltas")}
+	sn.deltas = sn.newDeltas()
+	cr := NewClusterResources(aws.String("cluster"))
+	cr.Remaining["c5.large"] = 2
+	sn.publish(sn.Namespace, cr.ToMetricData())
+	if len(store.items) != 0 {
+		t.Errorf("expected metrics failing to publish not saved but got %v", store.items)
+	}
+}
+
+// DeltaDynamoDB keeps items by Key, in memory.
+type DeltaDynamoDB struct {
+	dynamodbiface.DynamoDBAPI
+	items  map[string]map[string]*dynamodb.AttributeValue
+	writes int // BatchWriteItem calls.
+}
+
+func (fake *DeltaDynamoDB) ScanPages(input *dynamodb.ScanInput, fn func(*dynamodb.ScanOutput, bool) bool) error {
+	output := &dynamodb.ScanOutput{}
+	for _, item := range fake.items {
+		output.Items = append(output.Items, item)
+	}
+	fn(output, true)
+	return nil
+}
+
+func (fake *DeltaDynamoDB) BatchWriteItem(input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+	if fake.items == nil {
+		fake.items = map[string]map[string]*dynamodb.AttributeValue{}
+	}
+	fake.writes++
+	for _, requests := range input.RequestItems {
+		if len(requests) > maxBatchWrite {
+			return nil, errors.New("too many items")
+		}
+		for _, request := range requests {
+			fake.items[aws.StringValue(request.PutRequest.Item["Key"].S)] = request.PutRequest.Item
+		}
+	}
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
+func TestDynamoDBDeltas(t *testing.T) {
+	fake := &DeltaDynamoDB{}
+	store := &dynamoDBDeltas{Snitcher: &Snitcher{DynamoDB: fake}, Table: "deltas"}
+	entries := map[string]*deltaEntry{}
+	var changed []string
+	for i := 0; i < 30; i++ {
+		key := "metric-" + strconv.Itoa(i)
+		entries[key] = &deltaEntry{Value: float64(i), Published: 1538395200}
+		changed = append(changed, key)
+	}
+	if err := store.save(entries, changed); err != nil {
+		t.Fatal(err)
+	}
+	if fake.writes != 2 {
+		t.Errorf("expected 30 items written in 2 batches but got %d", fake.writes)
+	}
+	loaded, err := store.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 30 || *loaded["metric-29"] != *entries["metric-29"] {
+		t.Errorf("expected %d entries loaded as saved but got %d", len(entries), len(loaded))
+	}
+}
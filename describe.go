@@ -0,0 +1,78 @@
+package snitch
+
+import (
+	"sort"
+)
+
+// ClusterDescription sums up one cluster's capacity for people, rather than
+// CloudWatch.
+type ClusterDescription struct {
+	Cluster string
+	// Lowest common multiple container size, or CPU and Memory if set.
+	CPU    int
+	Memory int
+	// By instance type, or partition, ordered by name.
+	InstanceTypes []*InstanceTypeDescription
+	// CPU Units and Memory (MiB) registered and remaining across Container
+	// Instances.
+	RegisteredCPU    int
+	RegisteredMemory int
+	RemainingCPU     int
+	RemainingMemory  int
+}
+
+// InstanceTypeDescription is the capacity of a cluster's Container Instances
+// of one instance type, or partition.
+type InstanceTypeDescription struct {
+	InstanceType string
+	Instances    int
+	// Containers of the cluster's size that fit, weighed like published metrics.
+	RegisteredSchedulable int
+	RemainingSchedulable  int
+	RegisteredCPU         int
+	RegisteredMemory      int
+	RemainingCPU          int
+	RemainingMemory       int
+}
+
+// DescribeCluster measures one cluster like MeasureCluster, adding raw CPU
+// Units and Memory, or returns nil if its container size is unknown.
+func (sn *Snitcher) DescribeCluster(cluster *string) *ClusterDescription {
+	containers := sn.DescribeContainerInstances(cluster, sn.ListContainerInstances(cluster))
+	cpu, memory := sn.ContainerSize(cluster)
+	if cpu == 0 || memory == 0 {
+		sn.logger().Infof("%q runs no Tasks to size containers by", *cluster)
+		return nil
+	}
+	cr := sn.MeasureContainerInstances(cluster, containers, cpu, memory)
+	description := &ClusterDescription{Cluster: *cluster, CPU: cpu, Memory: memory}
+	byType := map[string]*InstanceTypeDescription{}
+	for instanceType, count := range cr.Instances {
+		byType[instanceType] = &InstanceTypeDescription{
+			InstanceType:          instanceType,
+			Instances:             count,
+			RegisteredSchedulable: cr.Registered[instanceType],
+			RemainingSchedulable:  cr.Remaining[instanceType],
+		}
+	}
+	for _, container := range containers {
+		described := byType[sn.partition(cr, container.Attributes)]
+		registeredCPU, registeredMemory := resources(container.RegisteredResources)
+		remainingCPU, remainingMemory := resources(container.RemainingResources)
+		described.RegisteredCPU += registeredCPU
+		described.RegisteredMemory += registeredMemory
+		described.RemainingCPU += remainingCPU
+		described.RemainingMemory += remainingMemory
+		description.RegisteredCPU += registeredCPU
+		description.RegisteredMemory += registeredMemory
+		description.RemainingCPU += remainingCPU
+		description.RemainingMemory += remainingMemory
+	}
+	for _, described := range byType {
+		description.InstanceTypes = append(description.InstanceTypes, described)
+	}
+	sort.Slice(description.InstanceTypes, func(i, j int) bool {
+		return description.InstanceTypes[i].InstanceType < description.InstanceTypes[j].InstanceType
+	})
+	return description
+}
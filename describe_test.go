@@ -0,0 +1,33 @@
+package snitch
+
+import "testing"
+
+func TestSnitcher_DescribeCluster(t *testing.T) {
+	fake := NewFakeECS(t)
+	sn := &Snitcher{ECS: fake}
+	description := sn.DescribeCluster(fake.expectedCluster)
+	if description == nil {
+		t.Fatal("expected a description")
+	}
+	if description.CPU != fake.expectedCPU || description.Memory != fake.expectedMemory {
+		t.Errorf("expected container size %d/%d but got %d/%d", fake.expectedCPU, fake.expectedMemory, description.CPU, description.Memory)
+	}
+	if len(description.InstanceTypes) != 1 {
+		t.Fatalf("expected one instance type but got %d", len(description.InstanceTypes))
+	}
+	described := description.InstanceTypes[0]
+	if described.InstanceType != "fake.2xlarge" || described.Instances != 3 {
+		t.Errorf("expected 3 fake.2xlarge but got %+v", described)
+	}
+	if described.RegisteredSchedulable != fake.expectedRegisteredPossible || described.RemainingSchedulable != fake.expectedRemainingPossible {
+		t.Errorf("expected %d registered and %d remaining but got %+v", fake.expectedRegisteredPossible, fake.expectedRemainingPossible, described)
+	}
+	if described.RegisteredCPU != 3*8192 || description.RemainingMemory != 3*(15468-fake.expectedMemory) {
+		t.Errorf("expected raw resources summed but got %+v and %+v", described, description)
+	}
+
+	fake.expectedDescribeTasksOutput.Tasks = nil
+	if description = sn.DescribeCluster(fake.expectedCluster); description != nil {
+		t.Errorf("expected no description without container size but got %+v", description)
+	}
+}
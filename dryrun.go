@@ -0,0 +1,71 @@
+package snitch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// DryRunSink prints metrics as they'd be published to CloudWatch, without
+// publishing them, as a "table" or "json".
+type DryRunSink struct {
+	Namespace string
+	Format    string
+	// Writer of the preview. Nil means stdout.
+	Writer io.Writer
+}
+
+// dryRunDatum is a MetricDatum as previewed in JSON.
+type dryRunDatum struct {
+	Namespace  string            `json:"namespace"`
+	MetricName string            `json:"metricName"`
+	Dimensions map[string]string `json:"dimensions"`
+	Value      float64           `json:"value"`
+	Unit       string            `json:"unit"`
+	Timestamp  time.Time         `json:"timestamp"`
+}
+
+// Send prints metrics in Format, in the order they'd be published.
+func (sink *DryRunSink) Send(metricData []*cloudwatch.MetricDatum) error {
+	w := sink.Writer
+	if w == nil {
+		w = os.Stdout
+	}
+	switch sink.Format {
+	case "json":
+		data := []*dryRunDatum{}
+		for _, datum := range metricData {
+			data = append(data, &dryRunDatum{
+				Namespace:  sink.Namespace,
+				MetricName: aws.StringValue(datum.MetricName),
+				Dimensions: dimensionMap(datum.Dimensions),
+				Value:      aws.Float64Value(datum.Value),
+				Unit:       aws.StringValue(datum.Unit),
+				Timestamp:  aws.TimeValue(datum.Timestamp),
+			})
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(data)
+	case "table", "":
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAMESPACE\tMETRIC\tDIMENSIONS\tVALUE\tUNIT")
+		for _, datum := range metricData {
+			var dimensions []string
+			for _, dimension := range datum.Dimensions {
+				dimensions = append(dimensions, aws.StringValue(dimension.Name)+"="+aws.StringValue(dimension.Value))
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%g\t%s\n", sink.Namespace, aws.StringValue(datum.MetricName),
+				strings.Join(dimensions, ","), aws.Float64Value(datum.Value), aws.StringValue(datum.Unit))
+		}
+		return tw.Flush()
+	}
+	return fmt.Errorf("unknown dry run format %q", sink.Format)
+}
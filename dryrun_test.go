@@ -0,0 +1,66 @@
+package snitch
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+func TestDryRunSink_Send(t *testing.T) {
+	metricData := []*cloudwatch.MetricDatum{{
+		MetricName: aws.String("RemainingSchedulable"),
+		Dimensions: []*cloudwatch.Dimension{
+			{Name: aws.String("ClusterName"), Value: aws.String("fake-cluster")},
+			{Name: aws.String("InstanceType"), Value: aws.String("fake.large")},
+		},
+		Value: aws.Float64(3),
+		Unit:  aws.String("Count"),
+	}}
+	var buffer bytes.Buffer
+	sink := &DryRunSink{Namespace: "Testable/Namespace", Format: "table", Writer: &buffer}
+	if err := sink.Send(metricData); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != 2 || strings.Join(strings.Fields(lines[1]), " ") != "Testable/Namespace RemainingSchedulable ClusterName=fake-cluster,InstanceType=fake.large 3 Count" {
+		t.Errorf("expected a row per metric but got:\n%s", buffer.String())
+	}
+
+	buffer.Reset()
+	sink.Format = "json"
+	if err := sink.Send(metricData); err != nil {
+		t.Fatal(err)
+	}
+	var data []*dryRunDatum
+	if err := json.Unmarshal(buffer.Bytes(), &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 1 || data[0].Namespace != "Testable/Namespace" || data[0].Dimensions["InstanceType"] != "fake.large" || data[0].Value != 3 {
+		t.Errorf("expected metric as JSON but got %s", buffer.String())
+	}
+
+	sink.Format = "yaml"
+	if err := sink.Send(metricData); err == nil {
+		t.Error("expected unknown format to fail")
+	}
+}
+
+func TestSnitcher_publishDryRun(t *testing.T) {
+	fake := &FakeCloudWatch{}
+	sn := &Snitcher{CloudWatch: fake, DryRun: aws.String("json")}
+	cr := NewClusterResources(aws.String("fake-cluster"))
+	cr.Remaining["fake.large"] = 3
+	defer func(stdout *os.File) { os.Stdout = stdout }(os.Stdout)
+	os.Stdout, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err := sn.publish(aws.String("Testable/Namespace"), cr.ToMetricData()); err != nil {
+		t.Error(err)
+	}
+	if len(fake.payload) != 0 {
+		t.Errorf("expected dry run not to publish but got %d payloads", len(fake.payload))
+	}
+}
@@ -0,0 +1,74 @@
+package snitch
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// DynamoDBSink stores each cluster's ClusterReport as an item of Table, for
+// comparing runs with history. The table's partition key is string "Key",
+// like "123456789012/us-east-1/my-cluster", and its sort key is number
+// "Timestamp", in Unix seconds. Items expire by number "ExpiresAt" if TTL is
+// set, which needs Time to Live enabled on that attribute.
+type DynamoDBSink struct {
+	Snitcher *Snitcher
+	Table    string
+	TTL      time.Duration
+}
+
+// historyItem is a ClusterReport as stored in DynamoDB.
+type historyItem struct {
+	Key       string
+	Timestamp int64
+	ExpiresAt int64 `dynamodbav:",omitempty"`
+	*ClusterReport
+}
+
+// Key identifies a cluster across accounts and regions, like
+// "123456789012/us-east-1/my-cluster", omitting AccountId or Region if not
+// among its Dimensions.
+func (report *ClusterReport) Key() string {
+	var parts []string
+	for _, dimension := range []string{"AccountId", "Region"} {
+		if value, ok := report.Dimensions[dimension]; ok {
+			parts = append(parts, value)
+		}
+	}
+	return strings.Join(append(parts, report.Cluster), "/")
+}
+
+// Send metrics to DynamoDB, an item per cluster. All clusters are tried even
+// if some fail, and the last failure is returned.
+//
+// Requires IAM permission "dynamodb:PutItem".
+func (sink *DynamoDBSink) Send(metricData []*cloudwatch.MetricDatum) (err error) {
+	if sink.Table == "" {
+		return errors.New("no DynamoDB table")
+	}
+	for _, report := range Report(metricData) {
+		item := &historyItem{Key: report.Key(), Timestamp: report.Timestamp.Unix(), ClusterReport: report}
+		if sink.TTL > 0 {
+			item.ExpiresAt = report.Timestamp.Add(sink.TTL).Unix()
+		}
+		attributes, marshalErr := dynamodbattribute.MarshalMap(item)
+		if marshalErr != nil {
+			err = marshalErr
+			continue
+		}
+		input := &dynamodb.PutItemInput{
+			TableName: aws.String(sink.Table),
+			Item:      attributes,
+		}
+		if _, putErr := sink.Snitcher.DynamoDB.PutItem(input); putErr != nil {
+			sink.Snitcher.logger().Errorf("Failed to PutItem %q into %q: %s", item.Key, sink.Table, putErr)
+			err = putErr
+		}
+	}
+	return
+}
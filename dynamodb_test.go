@@ -0,0 +1,59 @@
+package snitch
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// FakeDynamoDB mocks DynamoDB, keeping items put.
+type FakeDynamoDB struct {
+	dynamodbiface.DynamoDBAPI
+	errorToReturn error                                 // `error` to return from fake methods.
+	items         []map[string]*dynamodb.AttributeValue // Items put, in order.
+}
+
+func (fake *FakeDynamoDB) PutItem(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+	if fake.errorToReturn != nil {
+		return nil, fake.errorToReturn
+	}
+	fake.items = append(fake.items, input.Item)
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func TestDynamoDBSink(t *testing.T) {
+	fake := &FakeDynamoDB{}
+	sn := &Snitcher{DynamoDB: fake}
+	sink := SinkTypes["dynamodb"](sn, &SinkConfig{Table: "snitch-history", TTL: "720h"})
+	cr := NewClusterResources(aws.String("fake-cluster"))
+	cr.Remaining["fake.large"] = 2
+	cr.ClusterDimensions = []*cloudwatch.Dimension{{Name: aws.String("AccountId"), Value: aws.String("123456789012")}}
+	timestamp := time.Date(2018, 10, 1, 12, 0, 0, 0, time.UTC)
+	metricData := cr.ToMetricData()
+	for _, datum := range metricData {
+		datum.Timestamp = aws.Time(timestamp)
+	}
+	if err := sink.Send(metricData); err != nil {
+		t.Fatal(err)
+	}
+	item := fake.items[0]
+	if aws.StringValue(item["Key"].S) != "123456789012/fake-cluster" || aws.StringValue(item["Timestamp"].N) != "1538395200" {
+		t.Errorf("expected key and timestamp of fake-cluster but got %v", item)
+	}
+	if expected := "1540987200"; aws.StringValue(item["ExpiresAt"].N) != expected {
+		t.Errorf("expected expiry %s but got %v", expected, item["ExpiresAt"])
+	}
+	fake.errorToReturn = errors.New("fake")
+	if err := sink.Send(metricData); err == nil {
+		t.Error("expected an error when DynamoDB fails")
+	}
+	invalid := SinkTypes["dynamodb"](sn, &SinkConfig{Table: "snitch-history", TTL: "a month"})
+	if err := invalid.Send(metricData); err == nil {
+		t.Error("expected an error of an unparsable TTL")
+	}
+}
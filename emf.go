@@ -0,0 +1,123 @@
+package snitch
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// emfMaxMetrics is how many metrics one Embedded Metric Format document may
+// hold.
+const emfMaxMetrics = 100
+
+// EMFSink writes metrics as CloudWatch Embedded Metric Format, a JSON document
+// per line, which CloudWatch Logs turns into metrics in Namespace. In Lambda,
+// that needs no "cloudwatch:PutMetricData" permission nor API quota:
+//
+//	{"_aws":{"CloudWatchMetrics":[{"Dimensions":[["ClusterName","InstanceType"]],
+//	 "Metrics":[{"Name":"RemainingSchedulable","Unit":"Count"}],"Namespace":"ECS/Snitch"}],
+//	 "Timestamp":1540000000000},"ClusterName":"my-cluster","InstanceType":"c5.large","RemainingSchedulable":3}
+type EMFSink struct {
+	Namespace string
+	// Writer of documents. Nil means stdout.
+	Writer io.Writer
+}
+
+// emfMetric is a metric's definition in an Embedded Metric Format document.
+type emfMetric struct {
+	Name string
+	Unit string `json:",omitempty"`
+}
+
+// emfDocument is metrics sharing dimensions, as one Embedded Metric Format
+// document.
+type emfDocument struct {
+	namespace  string
+	timestamp  time.Time
+	dimensions []*cloudwatch.Dimension
+	metrics    []*emfMetric
+	values     map[string]float64
+}
+
+// MarshalJSON puts dimensions and values at the document's top level, beside
+// the "_aws" metadata describing them.
+func (document *emfDocument) MarshalJSON() ([]byte, error) {
+	fields := map[string]interface{}{}
+	var names []string
+	for _, dimension := range document.dimensions {
+		names = append(names, aws.StringValue(dimension.Name))
+		fields[aws.StringValue(dimension.Name)] = aws.StringValue(dimension.Value)
+	}
+	for name, value := range document.values {
+		fields[name] = value
+	}
+	fields["_aws"] = map[string]interface{}{
+		"Timestamp": document.timestamp.UnixNano() / int64(time.Millisecond),
+		"CloudWatchMetrics": []interface{}{
+			map[string]interface{}{
+				"Namespace":  document.namespace,
+				"Dimensions": [][]string{names},
+				"Metrics":    document.metrics,
+			},
+		},
+	}
+	return json.Marshal(fields)
+}
+
+// Send metrics as Embedded Metric Format, one document per set of dimensions.
+func (sink *EMFSink) Send(metricData []*cloudwatch.MetricDatum) error {
+	w := sink.Writer
+	if w == nil {
+		w = os.Stdout
+	}
+	encoder := json.NewEncoder(w)
+	for _, document := range sink.documents(metricData) {
+		if err := encoder.Encode(document); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// documents groups metrics sharing dimensions into Embedded Metric Format
+// documents, starting another when a metric name repeats or there are too many.
+func (sink *EMFSink) documents(metricData []*cloudwatch.MetricDatum) (documents []*emfDocument) {
+	open := map[string]*emfDocument{}
+	for _, datum := range metricData {
+		var pairs []string
+		for _, dimension := range datum.Dimensions {
+			pairs = append(pairs, aws.StringValue(dimension.Name)+"="+aws.StringValue(dimension.Value))
+		}
+		sort.Strings(pairs)
+		key := strings.Join(pairs, ",")
+		name := aws.StringValue(datum.MetricName)
+		document, ok := open[key]
+		if ok {
+			if _, repeated := document.values[name]; repeated || len(document.metrics) == emfMaxMetrics {
+				ok = false
+			}
+		}
+		if !ok {
+			document = &emfDocument{
+				namespace:  sink.Namespace,
+				timestamp:  time.Now(),
+				dimensions: datum.Dimensions,
+				values:     map[string]float64{},
+			}
+			if datum.Timestamp != nil {
+				document.timestamp = *datum.Timestamp
+			}
+			open[key] = document
+			documents = append(documents, document)
+		}
+		document.metrics = append(document.metrics, &emfMetric{Name: name, Unit: aws.StringValue(datum.Unit)})
+		document.values[name] = aws.Float64Value(datum.Value)
+	}
+	return
+}
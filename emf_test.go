@@ -0,0 +1,67 @@
+package snitch
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestEMFSink_Send(t *testing.T) {
+	cr := NewClusterResources(aws.String("fake-cluster"))
+	cr.Registered["fake.large"] = 4
+	cr.Remaining["fake.large"] = 2
+	cr.Remaining["fake.xlarge"] = 5
+	cr.Totals["PendingTasks"] = 1
+	var b bytes.Buffer
+	sink := &EMFSink{Namespace: "ECS/Snitch", Writer: &b}
+	if err := sink.Send(cr.ToMetricData()); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected a document per set of dimensions but got:\n%s", b.String())
+	}
+	for _, line := range lines {
+		var document struct {
+			AWS struct {
+				Timestamp         int64
+				CloudWatchMetrics []struct {
+					Namespace  string
+					Dimensions [][]string
+					Metrics    []*emfMetric
+				}
+			} `json:"_aws"`
+			ClusterName           string
+			InstanceType          string
+			RegisteredSchedulable *float64
+			RemainingSchedulable  *float64
+		}
+		if err := json.Unmarshal([]byte(line), &document); err != nil {
+			t.Fatal(err)
+		}
+		directive := document.AWS.CloudWatchMetrics[0]
+		if document.AWS.Timestamp == 0 || directive.Namespace != "ECS/Snitch" || document.ClusterName != "fake-cluster" {
+			t.Errorf("expected timestamp, namespace and cluster in %s", line)
+		}
+		if document.InstanceType == "fake.large" {
+			if len(directive.Metrics) != 2 || *document.RegisteredSchedulable != 4 || *document.RemainingSchedulable != 2 {
+				t.Errorf("expected fake.large metrics together in %s", line)
+			}
+			if len(directive.Dimensions[0]) != 2 || directive.Dimensions[0][1] != "InstanceType" {
+				t.Errorf("expected ClusterName and InstanceType dimensions in %s", line)
+			}
+		}
+	}
+}
+
+func TestEMFSink_documents(t *testing.T) {
+	cr := NewClusterResources(aws.String("fake-cluster"))
+	cr.Remaining["fake.large"] = 2
+	metricData := append(cr.ToMetricData(), cr.ToMetricData()...)
+	if documents := (&EMFSink{}).documents(metricData); len(documents) != 2 {
+		t.Errorf("expected repeated metric in another document but got %d", len(documents))
+	}
+}
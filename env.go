@@ -0,0 +1,131 @@
+package snitch
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// Word boundaries of Snitcher's field names, including after acronyms, like
+// "ECS|Endpoint".
+var (
+	lowerUpper   = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+	acronymUpper = regexp.MustCompile(`([A-Z]+)([A-Z][a-z])`)
+)
+
+// envName names the environment variable of a Snitcher field, like
+// "SNITCH_ECS_ENDPOINT" of ECSEndpoint.
+func envName(field string) string {
+	return "SNITCH_" + strings.ToUpper(lowerUpper.ReplaceAllString(acronymUpper.ReplaceAllString(field, "${1}_${2}"), "${1}_${2}"))
+}
+
+// ConfigFromEnv reads a Snitcher from environment variables named like its
+// fields, like SNITCH_NAMESPACE, SNITCH_SHOULD_PUBLISH (or SNITCH_PUBLISH),
+// SNITCH_INCLUDE (or SNITCH_CLUSTERS) or SNITCH_DEFAULT_CPU.
+//
+// Lists are comma-separated, like "us-east-1,us-west-2", maps are pairs like
+// "Team=capacity,stack=blue", and SNITCH_SINKS and SNITCH_ALARMS are JSON.
+func ConfigFromEnv() (*Snitcher, error) {
+	sn := &Snitcher{}
+	value := reflect.ValueOf(sn).Elem()
+	// Aliases go first, so variables named like fields win.
+	names := [][2]string{}
+	for alias, name := range aliases {
+		names = append(names, [2]string{envName(alias), name})
+	}
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if field.PkgPath == "" && field.Tag.Get("json") != "-" && field.Type.Kind() != reflect.Interface {
+			names = append(names, [2]string{envName(field.Name), field.Name})
+		}
+	}
+	for _, name := range names {
+		text, ok := os.LookupEnv(name[0])
+		if !ok || text == "" {
+			continue
+		}
+		if err := setField(value.FieldByName(name[1]), text); err != nil {
+			return nil, fmt.Errorf("%s: %s", name[0], err)
+		}
+	}
+	return sn, nil
+}
+
+// setField parses text into a Snitcher field by its type.
+func setField(field reflect.Value, text string) error {
+	switch field.Interface().(type) {
+	case *string:
+		field.Set(reflect.ValueOf(aws.String(text)))
+	case *bool:
+		parsed, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(aws.Bool(parsed)))
+	case *int:
+		parsed, err := strconv.Atoi(text)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(aws.Int(parsed)))
+	case []string:
+		field.Set(reflect.ValueOf(strings.Split(text, ",")))
+	case map[string]string:
+		pairs := map[string]string{}
+		for _, pair := range strings.Split(text, ",") {
+			parts := strings.SplitN(pair, "=", 2)
+			if len(parts) != 2 {
+				return fmt.Errorf("%q isn't like key=value", pair)
+			}
+			pairs[parts[0]] = parts[1]
+		}
+		field.Set(reflect.ValueOf(pairs))
+	case map[string]float64:
+		pairs := map[string]float64{}
+		for _, pair := range strings.Split(text, ",") {
+			parts := strings.SplitN(pair, "=", 2)
+			if len(parts) != 2 {
+				return fmt.Errorf("%q isn't like name=number", pair)
+			}
+			parsed, err := strconv.ParseFloat(parts[1], 64)
+			if err != nil {
+				return err
+			}
+			pairs[parts[0]] = parsed
+		}
+		field.Set(reflect.ValueOf(pairs))
+	case []*SinkConfig:
+		var sinks []*SinkConfig
+		if err := json.Unmarshal([]byte(text), &sinks); err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(sinks))
+	case *AlarmConfig:
+		alarms := &AlarmConfig{}
+		if err := json.Unmarshal([]byte(text), alarms); err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(alarms))
+	default:
+		return fmt.Errorf("can't be set by environment variable")
+	}
+	return nil
+}
+
+// WithDefaults sets Snitcher's nil or empty fields to those of defaults, like
+// ConfigFromEnv's, so Snitcher's own settings win.
+func (sn *Snitcher) WithDefaults(defaults *Snitcher) *Snitcher {
+	to, from := reflect.ValueOf(sn).Elem(), reflect.ValueOf(defaults).Elem()
+	for i := 0; i < to.NumField(); i++ {
+		if to.Field(i).CanSet() && to.Field(i).IsZero() {
+			to.Field(i).Set(from.Field(i))
+		}
+	}
+	return sn
+}
@@ -0,0 +1,87 @@
+package snitch
+
+import (
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// setenv sets environment variables, returning a func unsetting them.
+func setenv(env map[string]string) func() {
+	for name, value := range env {
+		os.Setenv(name, value)
+	}
+	return func() {
+		for name := range env {
+			os.Unsetenv(name)
+		}
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	for field, expected := range map[string]string{
+		"Namespace":     "SNITCH_NAMESPACE",
+		"ShouldPublish": "SNITCH_SHOULD_PUBLISH",
+		"DefaultCPU":    "SNITCH_DEFAULT_CPU",
+		"ECSEndpoint":   "SNITCH_ECS_ENDPOINT",
+		"ExternalID":    "SNITCH_EXTERNAL_ID",
+	} {
+		if actual := envName(field); actual != expected {
+			t.Errorf("expected %s of %s but got %s", expected, field, actual)
+		}
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	defer setenv(map[string]string{
+		"SNITCH_NAMESPACE":             "ECS/Snitch",
+		"SNITCH_PUBLISH":               "true",
+		"SNITCH_CLUSTERS":              "prod-*,staging",
+		"SNITCH_REGIONS":               "us-east-1,us-west-2",
+		"SNITCH_DEFAULT_CPU":           "256",
+		"SNITCH_SELECT_TAGS":           "snitch=enabled",
+		"SNITCH_INSTANCE_TYPE_WEIGHTS": "t3.micro=0",
+		"SNITCH_SINKS":                 `[{"type": "sns", "url": "arn:aws:sns:us-east-1:123456789012:capacity"}]`,
+	})()
+	sn, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aws.StringValue(sn.Namespace) != "ECS/Snitch" || !aws.BoolValue(sn.ShouldPublish) || aws.IntValue(sn.DefaultCPU) != 256 {
+		t.Errorf("expected namespace, publishing and default CPU but got %+v", sn)
+	}
+	if len(sn.Include) != 2 || len(sn.Regions) != 2 || sn.SelectTags["snitch"] != "enabled" {
+		t.Errorf("expected clusters, regions and tags but got %+v", sn)
+	}
+	if weight, ok := sn.InstanceTypeWeights["t3.micro"]; !ok || weight != 0 {
+		t.Errorf("expected t3.micro weighed 0 but got %v", sn.InstanceTypeWeights)
+	}
+	if len(sn.Sinks) != 1 || sn.Sinks[0].Type != "sns" {
+		t.Errorf("expected an SNS sink but got %+v", sn.Sinks)
+	}
+
+	for name, invalid := range map[string]string{
+		"SNITCH_PUBLISH":               "sometimes",
+		"SNITCH_CPU":                   "lots",
+		"SNITCH_SELECT_TAGS":           "snitch",
+		"SNITCH_SINKS":                 "[",
+		"SNITCH_INSTANCE_TYPE_WEIGHTS": "t3.micro=none",
+	} {
+		unset := setenv(map[string]string{name: invalid})
+		if _, err := ConfigFromEnv(); err == nil {
+			t.Errorf("expected %s=%q to fail", name, invalid)
+		}
+		unset()
+	}
+}
+
+func TestWithDefaults(t *testing.T) {
+	sn := (&Snitcher{Namespace: aws.String("Mine")}).WithDefaults(&Snitcher{
+		Namespace: aws.String("Theirs"),
+		Regions:   []string{"us-east-1"},
+	})
+	if aws.StringValue(sn.Namespace) != "Mine" || len(sn.Regions) != 1 {
+		t.Errorf("expected own namespace and default regions but got %+v", sn)
+	}
+}
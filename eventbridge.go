@@ -0,0 +1,82 @@
+package snitch
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/eventbridge"
+)
+
+// eventBridgeBatchSize is how many entries PutEvents takes at most.
+const eventBridgeBatchSize = 10
+
+// EventBridgeSink puts events of source "snitch" on EventBus: detail-type
+// "ClusterCapacityMeasured" with each cluster's ClusterReport, and
+// "ClusterCapacityLow" with a Breach of each cluster below Thresholds.
+type EventBridgeSink struct {
+	Snitcher *Snitcher
+	// Name or ARN of the event bus. Empty means "default".
+	EventBus   string
+	Thresholds map[string]float64
+}
+
+// Send events of metrics, in batches. All batches are tried even if some
+// fail, and the last failure is returned.
+//
+// Requires IAM permission "events:PutEvents".
+func (sink *EventBridgeSink) Send(metricData []*cloudwatch.MetricDatum) (err error) {
+	reports := Report(metricData)
+	var entries []*eventbridge.PutEventsRequestEntry
+	for _, report := range reports {
+		entry, marshalErr := sink.entry("ClusterCapacityMeasured", report)
+		if marshalErr != nil {
+			err = marshalErr
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	for _, breach := range Breaches(reports, sink.Thresholds) {
+		entry, marshalErr := sink.entry("ClusterCapacityLow", breach)
+		if marshalErr != nil {
+			err = marshalErr
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	for i := 0; i < len(entries); i += eventBridgeBatchSize {
+		end := i + eventBridgeBatchSize
+		if end > len(entries) {
+			end = len(entries)
+		}
+		output, putErr := sink.Snitcher.EventBridge.PutEvents(&eventbridge.PutEventsInput{Entries: entries[i:end]})
+		if putErr != nil {
+			sink.Snitcher.logger().Errorf("Failed to put %d events: %s", end-i, putErr)
+			err = putErr
+			continue
+		}
+		if failed := aws.Int64Value(output.FailedEntryCount); failed > 0 {
+			err = fmt.Errorf("%d of %d events failed", failed, end-i)
+			sink.Snitcher.logger().Errorf("Failed to put events: %s", err)
+		}
+	}
+	return
+}
+
+// entry creates an event of detailType with detail as JSON.
+func (sink *EventBridgeSink) entry(detailType string, detail interface{}) (*eventbridge.PutEventsRequestEntry, error) {
+	b, err := json.Marshal(detail)
+	if err != nil {
+		return nil, err
+	}
+	entry := &eventbridge.PutEventsRequestEntry{
+		Source:     aws.String("snitch"),
+		DetailType: aws.String(detailType),
+		Detail:     aws.String(string(b)),
+	}
+	if sink.EventBus != "" {
+		entry.EventBusName = aws.String(sink.EventBus)
+	}
+	return entry, nil
+}
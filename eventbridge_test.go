@@ -0,0 +1,68 @@
+package snitch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/eventbridge"
+	"github.com/aws/aws-sdk-go/service/eventbridge/eventbridgeiface"
+)
+
+// FakeEventBridge mocks EventBridge, keeping events put.
+type FakeEventBridge struct {
+	eventbridgeiface.EventBridgeAPI
+	errorToReturn error                                // `error` to return from fake methods.
+	failedCount   int64                                // FailedEntryCount to respond with.
+	entries       []*eventbridge.PutEventsRequestEntry // Events put, in order.
+}
+
+func (fake *FakeEventBridge) PutEvents(input *eventbridge.PutEventsInput) (*eventbridge.PutEventsOutput, error) {
+	if len(input.Entries) > eventBridgeBatchSize {
+		return nil, errors.New("fake batch too big")
+	}
+	fake.entries = append(fake.entries, input.Entries...)
+	return &eventbridge.PutEventsOutput{FailedEntryCount: aws.Int64(fake.failedCount)}, fake.errorToReturn
+}
+
+func TestEventBridgeSink(t *testing.T) {
+	fake := &FakeEventBridge{}
+	sink := SinkTypes["eventbridge"](&Snitcher{EventBridge: fake}, &SinkConfig{URL: "capacity", Thresholds: map[string]float64{"cluster-0*": 5}})
+	var metricData []*cloudwatch.MetricDatum
+	for i := 0; i < 12; i++ {
+		cr := NewClusterResources(aws.String(fmt.Sprintf("cluster-%02d", i)))
+		cr.Remaining["fake.large"] = i
+		metricData = append(metricData, cr.ToMetricData()...)
+	}
+	if err := sink.Send(metricData); err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	for _, entry := range fake.entries {
+		if aws.StringValue(entry.Source) != "snitch" || aws.StringValue(entry.EventBusName) != "capacity" {
+			t.Errorf("expected source snitch on capacity bus but got %v", entry)
+		}
+		counts[aws.StringValue(entry.DetailType)]++
+	}
+	if counts["ClusterCapacityMeasured"] != 12 || counts["ClusterCapacityLow"] != 5 {
+		t.Errorf("expected 12 measured and 5 low events but got %v", counts)
+	}
+	var breach Breach
+	if err := json.Unmarshal([]byte(aws.StringValue(fake.entries[12].Detail)), &breach); err != nil {
+		t.Fatal(err)
+	}
+	if breach.Cluster != "cluster-00" || breach.Threshold != 5 {
+		t.Errorf("expected cluster-00 below 5 but got %+v", breach)
+	}
+	fake.failedCount = 1
+	if err := sink.Send(metricData); err == nil {
+		t.Error("expected an error when events fail")
+	}
+	fake.failedCount, fake.errorToReturn = 0, errors.New("fake")
+	if err := sink.Send(metricData); err == nil {
+		t.Error("expected an error when EventBridge fails")
+	}
+}
@@ -0,0 +1,177 @@
+package snitch
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+// Explanation breaks down what limits how many containers fit in a Container
+// Instance's remaining resources, for deciding between instance types.
+type Explanation struct {
+	ContainerInstanceArn string
+	EC2InstanceID        string
+	InstanceType         string
+	// Containers each remaining resource allows. ByPorts and ByENIs are -1
+	// when ports and ENIs don't limit containers.
+	ByCPU    int
+	ByMemory int
+	ByPorts  int
+	ByENIs   int
+	// Slots is the fewest containers any resource allows, and Bottlenecks
+	// the resources allowing only that many, like "CPU" or "Memory".
+	Slots       int
+	Bottlenecks []string
+}
+
+// Explain what limits containers of ContainerSize in each of cluster's
+// Container Instances.
+//
+// Containers reserving static host ports, which are TCP ports like "80",
+// fit once per Container Instance. When awsvpc, each container takes an ENI
+// of however many its instance type allows, ignoring ENI trunking.
+//
+// Requires IAM permissions "ec2:DescribeInstances" and
+// "ec2:DescribeInstanceTypes" when awsvpc.
+func (sn *Snitcher) Explain(cluster *string, ports []string, awsvpc bool) (explanations []*Explanation) {
+	containers := sn.DescribeContainerInstances(cluster, sn.ListContainerInstances(cluster))
+	cpu, memory := sn.ContainerSize(cluster)
+	if cpu == 0 || memory == 0 {
+		sn.logger().Infof("%q runs no Tasks to size containers by", *cluster)
+		return nil
+	}
+	var freeENIs map[string]int
+	if awsvpc {
+		freeENIs = sn.DescribeFreeENIs(containers)
+	}
+	for _, container := range containers {
+		remainingCPU, remainingMemory := resources(container.RemainingResources)
+		explanation := &Explanation{
+			ContainerInstanceArn: aws.StringValue(container.ContainerInstanceArn),
+			EC2InstanceID:        aws.StringValue(container.Ec2InstanceId),
+			InstanceType:         getInstanceType(container.Attributes),
+			ByCPU:                remainingCPU / cpu,
+			ByMemory:             remainingMemory / memory,
+			ByPorts:              -1,
+			ByENIs:               -1,
+		}
+		if len(ports) > 0 {
+			explanation.ByPorts = portsFree(ports, container.RemainingResources)
+		}
+		if awsvpc {
+			explanation.ByENIs = freeENIs[explanation.EC2InstanceID]
+		}
+		explanation.bottleneck()
+		explanations = append(explanations, explanation)
+	}
+	return
+}
+
+// bottleneck finds Slots and which resources allow only that many.
+func (explanation *Explanation) bottleneck() {
+	allowed := []struct {
+		resource string
+		count    int
+	}{
+		{"CPU", explanation.ByCPU},
+		{"Memory", explanation.ByMemory},
+		{"Ports", explanation.ByPorts},
+		{"ENIs", explanation.ByENIs},
+	}
+	explanation.Slots = -1
+	for _, resource := range allowed {
+		if resource.count >= 0 && (explanation.Slots < 0 || resource.count < explanation.Slots) {
+			explanation.Slots = resource.count
+		}
+	}
+	for _, resource := range allowed {
+		if resource.count == explanation.Slots {
+			explanation.Bottlenecks = append(explanation.Bottlenecks, resource.resource)
+		}
+	}
+}
+
+// portsFree is 1 if none of ports is among resources' reserved "PORTS", or
+// else 0, since a static host port fits one container per instance.
+func portsFree(ports []string, resources []*ecs.Resource) int {
+	for _, resource := range resources {
+		if aws.StringValue(resource.Name) != "PORTS" {
+			continue
+		}
+		for _, reserved := range resource.StringSetValue {
+			for _, port := range ports {
+				if aws.StringValue(reserved) == port {
+					return 0
+				}
+			}
+		}
+	}
+	return 1
+}
+
+// DescribeFreeENIs maps EC2 Instance IDs of containers to how many more ENIs
+// each can attach: its instance type's maximum less those attached. Instances
+// that can't be described are missing, so allow none.
+//
+// Requires IAM permissions "ec2:DescribeInstances" and
+// "ec2:DescribeInstanceTypes".
+func (sn *Snitcher) DescribeFreeENIs(containers []*ecs.ContainerInstance) map[string]int {
+	free := map[string]int{}
+	var instanceIDs []*string
+	for _, container := range containers {
+		if container.Ec2InstanceId != nil {
+			instanceIDs = append(instanceIDs, container.Ec2InstanceId)
+		}
+	}
+	if len(instanceIDs) == 0 {
+		return free
+	}
+	attached := map[string]int{}
+	types := map[string]string{}
+	err := sn.EC2.DescribeInstancesPages(
+		&ec2.DescribeInstancesInput{InstanceIds: instanceIDs},
+		func(page *ec2.DescribeInstancesOutput, last bool) bool {
+			for _, reservation := range page.Reservations {
+				for _, instance := range reservation.Instances {
+					attached[aws.StringValue(instance.InstanceId)] = len(instance.NetworkInterfaces)
+					types[aws.StringValue(instance.InstanceId)] = aws.StringValue(instance.InstanceType)
+				}
+			}
+			return true
+		},
+	)
+	if err != nil {
+		sn.logger().Errorf("Failed to DescribeInstancesPages! %s", err)
+		return free
+	}
+	var instanceTypes []*string
+	seen := map[string]bool{}
+	for _, instanceType := range types {
+		if !seen[instanceType] {
+			seen[instanceType] = true
+			instanceTypes = append(instanceTypes, aws.String(instanceType))
+		}
+	}
+	maximum := map[string]int{}
+	err = sn.EC2.DescribeInstanceTypesPages(
+		&ec2.DescribeInstanceTypesInput{InstanceTypes: instanceTypes},
+		func(page *ec2.DescribeInstanceTypesOutput, last bool) bool {
+			for _, info := range page.InstanceTypes {
+				if info.NetworkInfo != nil {
+					maximum[aws.StringValue(info.InstanceType)] = int(aws.Int64Value(info.NetworkInfo.MaximumNetworkInterfaces))
+				}
+			}
+			return true
+		},
+	)
+	if err != nil {
+		sn.logger().Errorf("Failed to DescribeInstanceTypesPages! %s", err)
+		return free
+	}
+	for id, instanceType := range types {
+		if free[id] = maximum[instanceType] - attached[id]; free[id] < 0 {
+			free[id] = 0
+		}
+	}
+	return free
+}
@@ -0,0 +1,68 @@
+package snitch
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+func TestSnitcher_Explain(t *testing.T) {
+	sn, fakeECS, fakeEC2 := NewFakeSubnets(t)
+	fakeEC2.expectedInstances = map[string]*ec2.Instance{
+		"i-a": {InstanceType: aws.String("c5.large"), NetworkInterfaces: make([]*ec2.InstanceNetworkInterface, 1)},
+		"i-b": {InstanceType: aws.String("c5.large"), NetworkInterfaces: make([]*ec2.InstanceNetworkInterface, 3)},
+		"i-c": {InstanceType: aws.String("c5.large"), NetworkInterfaces: make([]*ec2.InstanceNetworkInterface, 4)},
+	}
+	fakeEC2.expectedMaxENIs = map[string]int64{"c5.large": 3}
+	fakeECS.expectedContainerInstances[1].RemainingResources = append(fakeECS.expectedContainerInstances[1].RemainingResources, &ecs.Resource{
+		Name:           aws.String("PORTS"),
+		Type:           aws.String("STRINGSET"),
+		StringSetValue: aws.StringSlice([]string{"22", "80"}),
+	})
+
+	explanations := sn.Explain(fakeECS.expectedCluster, nil, false)
+	if len(explanations) != 3 {
+		t.Fatalf("expected every Container Instance explained but got %d", len(explanations))
+	}
+	perInstance := fakeECS.expectedRemainingPossible / 3
+	for _, explanation := range explanations {
+		if explanation.Slots != perInstance || explanation.ByPorts != -1 || explanation.ByENIs != -1 {
+			t.Errorf("expected %d slots unlimited by ports or ENIs but got %+v", perInstance, explanation)
+		}
+	}
+
+	explanations = sn.Explain(fakeECS.expectedCluster, []string{"80"}, true)
+	expected := []struct {
+		byPorts, byENIs, slots int
+		bottlenecks            []string
+	}{
+		{1, 2, 1, []string{"Ports"}},
+		{0, 0, 0, []string{"Ports", "ENIs"}},
+		{1, 0, 0, []string{"ENIs"}},
+	}
+	for i, explanation := range explanations {
+		if explanation.ByPorts != expected[i].byPorts || explanation.ByENIs != expected[i].byENIs || explanation.Slots != expected[i].slots {
+			t.Errorf("expected %+v for %d but got %+v", expected[i], i, explanation)
+		}
+		if !reflect.DeepEqual(explanation.Bottlenecks, expected[i].bottlenecks) {
+			t.Errorf("expected bottlenecks %v for %d but got %v", expected[i].bottlenecks, i, explanation.Bottlenecks)
+		}
+	}
+
+	fakeEC2.errorToReturn = errors.New("fake error")
+	if free := sn.DescribeFreeENIs(fakeECS.expectedContainerInstances); len(free) != 0 {
+		t.Errorf("expected no free ENIs known but got %v", free)
+	}
+}
+
+func TestExplanation_bottleneck(t *testing.T) {
+	explanation := &Explanation{ByCPU: 4, ByMemory: 2, ByPorts: -1, ByENIs: -1}
+	explanation.bottleneck()
+	if explanation.Slots != 2 || !reflect.DeepEqual(explanation.Bottlenecks, []string{"Memory"}) {
+		t.Errorf("expected Memory to allow 2 but got %+v", explanation)
+	}
+}
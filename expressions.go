@@ -0,0 +1,73 @@
+package snitch
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Expression derives a quantity from snitch metrics with CloudWatch Metric
+// Math, so every team's dashboard needn't reinvent it. As JSON, it's ready to
+// paste among a CloudWatch Dashboard widget's "metrics."
+type Expression struct {
+	Expression string `json:"expression"`
+	Label      string `json:"label"`
+	ID         string `json:"id"` // Unique among Expressions, which refer to each other by it.
+}
+
+// search builds a SEARCH() expression for a snitch metric published per
+// instance type, in namespace, narrowed to cluster unless it's "".
+func search(namespace, cluster, metricName string, period int) string {
+	terms := fmt.Sprintf(`{%s,ClusterName,InstanceType} MetricName="%s"`, quote(namespace), metricName)
+	if cluster != "" {
+		terms += fmt.Sprintf(` ClusterName="%s"`, cluster)
+	}
+	return fmt.Sprintf("SEARCH('%s', 'Average', %d)", terms, period)
+}
+
+// quote wraps namespaces containing anything but letters and digits, like
+// "ECS/Snitch", in double quotes as SEARCH() requires.
+func quote(namespace string) string {
+	if strings.IndexFunc(namespace, func(r rune) bool {
+		return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9')
+	}) < 0 {
+		return namespace
+	}
+	return `"` + namespace + `"`
+}
+
+// Expressions generates Metric Math for utilization and aggregate headroom of
+// cluster, or every cluster together if cluster is "". period is in seconds.
+//
+// Derived Expressions refer to others by ID, so use them together.
+func Expressions(namespace, cluster string, period int) []*Expression {
+	scope := "all clusters"
+	if cluster != "" {
+		scope = cluster
+	}
+	sum := func(metricName string) string {
+		return fmt.Sprintf("SUM(%s)", search(namespace, cluster, metricName, period))
+	}
+	return []*Expression{
+		{sum("RegisteredSchedulable"), scope + " registered schedulable", "registered"},
+		{sum("RemainingSchedulable"), scope + " remaining schedulable", "headroom"},
+		{sum("RunningTaskSlots"), scope + " running Task slots", "slots"},
+		{"100*(1 - headroom/registered)", scope + " utilization percent", "utilization"},
+		{"100*slots/registered", scope + " running Task slots percent", "occupancy"},
+	}
+}
+
+// InsightsQueries generates CloudWatch Metrics Insights queries for aggregate
+// headroom and capacity by cluster, or for just cluster if it's not "".
+func InsightsQueries(namespace, cluster string) []*Expression {
+	where := ""
+	if cluster != "" {
+		where = fmt.Sprintf(" WHERE ClusterName = '%s'", cluster)
+	}
+	query := func(metricName string) string {
+		return fmt.Sprintf(`SELECT SUM(%s) FROM SCHEMA(%s, ClusterName, InstanceType)%s GROUP BY ClusterName`, metricName, quote(namespace), where)
+	}
+	return []*Expression{
+		{query("RegisteredSchedulable"), "registered schedulable by cluster", "registeredByCluster"},
+		{query("RemainingSchedulable"), "remaining schedulable by cluster", "headroomByCluster"},
+	}
+}
@@ -0,0 +1,53 @@
+package snitch
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_quote(t *testing.T) {
+	for namespace, expected := range map[string]string{
+		"Snitch":     "Snitch",
+		"ECS/Snitch": `"ECS/Snitch"`,
+		"my-space":   `"my-space"`,
+	} {
+		if actual := quote(namespace); actual != expected {
+			t.Errorf("quote(%q) = %s, want %s", namespace, actual, expected)
+		}
+	}
+}
+
+func TestExpressions(t *testing.T) {
+	expressions := Expressions("ECS/Snitch", "my-cluster", 300)
+	ids := map[string]bool{}
+	for _, expression := range expressions {
+		if ids[expression.ID] {
+			t.Errorf("expected unique IDs but %q repeats", expression.ID)
+		}
+		ids[expression.ID] = true
+	}
+	expected := `SUM(SEARCH('{"ECS/Snitch",ClusterName,InstanceType} MetricName="RemainingSchedulable" ClusterName="my-cluster"', 'Average', 300))`
+	if actual := expressions[1].Expression; actual != expected {
+		t.Errorf("expected headroom to be %s but got %s", expected, actual)
+	}
+	for _, expression := range expressions {
+		if expression.ID == "utilization" && !(strings.Contains(expression.Expression, "headroom") && strings.Contains(expression.Expression, "registered")) {
+			t.Errorf("expected utilization to refer to headroom and registered but got %s", expression.Expression)
+		}
+	}
+	for _, expression := range Expressions("Snitch", "", 60) {
+		if strings.Contains(expression.Expression, "ClusterName=") {
+			t.Errorf("expected every cluster, not one, but got %s", expression.Expression)
+		}
+	}
+}
+
+func TestInsightsQueries(t *testing.T) {
+	expected := `SELECT SUM(RemainingSchedulable) FROM SCHEMA("ECS/Snitch", ClusterName, InstanceType) WHERE ClusterName = 'my-cluster' GROUP BY ClusterName`
+	if actual := InsightsQueries("ECS/Snitch", "my-cluster")[1].Expression; actual != expected {
+		t.Errorf("expected %s but got %s", expected, actual)
+	}
+	if actual := InsightsQueries("Snitch", "")[0].Expression; strings.Contains(actual, "WHERE") {
+		t.Errorf("expected no WHERE for every cluster but got %s", actual)
+	}
+}
@@ -0,0 +1,205 @@
+package snitch
+
+import (
+	"math/rand"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+// Faults injects errors and latency into fake AWS APIs to imitate a degraded
+// AWS. Maps are keyed by API name, like "DescribeTasks".
+type Faults struct {
+	Code      string                   // AWS error code to fail with, like "ThrottlingException".
+	ErrorRate map[string]float64       // Chance, 0 through 1, an API call fails.
+	Latency   map[string]time.Duration // How long an API call takes.
+	Calls     map[string]int           // How many times each API was called.
+	Failures  map[string]int           // How many times each API failed.
+	mutex     sync.Mutex               // Snitcher calls APIs concurrently.
+	random    *rand.Rand               // Seeded so failures are repeatable.
+}
+
+// NewFaults creates fault injection with repeatable "randomness".
+func NewFaults(seed int64) *Faults {
+	return &Faults{
+		Code:      "ServiceUnavailable",
+		ErrorRate: map[string]float64{},
+		Latency:   map[string]time.Duration{},
+		Calls:     map[string]int{},
+		Failures:  map[string]int{},
+		random:    rand.New(rand.NewSource(seed)),
+	}
+}
+
+// inject sleeps for api's latency and maybe returns an error.
+func (faults *Faults) inject(api string) error {
+	faults.mutex.Lock()
+	faults.Calls[api]++
+	latency := faults.Latency[api]
+	fail := faults.random.Float64() < faults.ErrorRate[api]
+	if fail {
+		faults.Failures[api]++
+	}
+	faults.mutex.Unlock()
+	time.Sleep(latency)
+	if fail {
+		return awserr.New(faults.Code, "injected fault in "+api, nil)
+	}
+	return nil
+}
+
+// FaultyECS wraps FakeECS with injected faults.
+type FaultyECS struct {
+	*FakeECS
+	*Faults
+}
+
+func (faulty *FaultyECS) ListTasksPages(input *ecs.ListTasksInput, pager func(*ecs.ListTasksOutput, bool) bool) error {
+	if err := faulty.inject("ListTasksPages"); err != nil {
+		return err
+	}
+	return faulty.FakeECS.ListTasksPages(input, pager)
+}
+
+func (faulty *FaultyECS) DescribeTasks(input *ecs.DescribeTasksInput) (*ecs.DescribeTasksOutput, error) {
+	if err := faulty.inject("DescribeTasks"); err != nil {
+		return nil, err
+	}
+	return faulty.FakeECS.DescribeTasks(input)
+}
+
+func (faulty *FaultyECS) ListContainerInstances(input *ecs.ListContainerInstancesInput) (*ecs.ListContainerInstancesOutput, error) {
+	if err := faulty.inject("ListContainerInstances"); err != nil {
+		return nil, err
+	}
+	return faulty.FakeECS.ListContainerInstances(input)
+}
+
+func (faulty *FaultyECS) DescribeContainerInstances(input *ecs.DescribeContainerInstancesInput) (*ecs.DescribeContainerInstancesOutput, error) {
+	if err := faulty.inject("DescribeContainerInstances"); err != nil {
+		return nil, err
+	}
+	return faulty.FakeECS.DescribeContainerInstances(input)
+}
+
+func (faulty *FaultyECS) ListClustersPages(input *ecs.ListClustersInput, pager func(*ecs.ListClustersOutput, bool) bool) error {
+	if err := faulty.inject("ListClustersPages"); err != nil {
+		return err
+	}
+	return faulty.FakeECS.ListClustersPages(input, pager)
+}
+
+// FaultyCloudWatch wraps FakeCloudWatch with injected faults.
+type FaultyCloudWatch struct {
+	*FakeCloudWatch
+	*Faults
+}
+
+func (faulty *FaultyCloudWatch) PutMetricData(input *cloudwatch.PutMetricDataInput) (*cloudwatch.PutMetricDataOutput, error) {
+	if err := faulty.inject("PutMetricData"); err != nil {
+		return nil, err
+	}
+	return faulty.FakeCloudWatch.PutMetricData(input)
+}
+
+// NewFaultySnitcher creates a Snitcher whose fake AWS clients misbehave.
+func NewFaultySnitcher(t *testing.T, faults *Faults) (*Snitcher, *FaultyECS, *FaultyCloudWatch) {
+	fakeECS := NewFakeECS(t)
+	// Measure visits every cluster, not just the expected one.
+	fakeECS.checkCluster = false
+	faultyECS := &FaultyECS{FakeECS: fakeECS, Faults: faults}
+	faultyCloudWatch := &FaultyCloudWatch{FakeCloudWatch: &FakeCloudWatch{}, Faults: faults}
+	sn := &Snitcher{
+		CloudWatch:    faultyCloudWatch,
+		ECS:           faultyECS,
+		Namespace:     aws.String("Faulty/Test"),
+		ShouldPublish: aws.Bool(true),
+	}
+	return sn, faultyECS, faultyCloudWatch
+}
+
+// measureWithin fails the test if Measure hangs under faults.
+func measureWithin(t *testing.T, sn *Snitcher, timeout time.Duration) []*cloudwatch.MetricDatum {
+	com := make(chan []*cloudwatch.MetricDatum, 1)
+	go func() {
+		com <- sn.Measure()
+	}()
+	select {
+	case metricData := <-com:
+		return metricData
+	case <-time.After(timeout):
+		t.Fatalf("Measure didn't finish within %s", timeout)
+	}
+	return nil
+}
+
+// TestFaults_Measure verifies Measure finishes, without publishing bogus data,
+// when each ECS API fails outright.
+func TestFaults_Measure(t *testing.T) {
+	sn, _, _ := NewFaultySnitcher(t, NewFaults(1))
+	healthy := len(measureWithin(t, sn, time.Second))
+	if healthy == 0 {
+		t.Fatal("expected some data points without faults")
+	}
+	for api, expected := range map[string]int{
+		"ListClustersPages":          0,
+		"ListTasksPages":             0,
+		"DescribeTasks":              0,
+		"ListContainerInstances":     healthy, // FakeECS describes instances regardless.
+		"DescribeContainerInstances": 0,
+	} {
+		faults := NewFaults(1)
+		faults.ErrorRate[api] = 1
+		sn, _, _ := NewFaultySnitcher(t, faults)
+		if actual := len(measureWithin(t, sn, time.Second)); actual != expected {
+			t.Errorf("expected %d data points when %s fails but got %d", expected, api, actual)
+		}
+		if faults.Failures[api] == 0 {
+			t.Errorf("expected %s to fail at least once", api)
+		}
+	}
+}
+
+// TestFaults_MeasurePartial verifies some clusters still get measured when
+// APIs fail intermittently and slowly.
+func TestFaults_MeasurePartial(t *testing.T) {
+	sn, _, _ := NewFaultySnitcher(t, NewFaults(1))
+	healthy := len(measureWithin(t, sn, time.Second))
+	faults := NewFaults(3)
+	faults.ErrorRate["DescribeTasks"] = 0.5
+	faults.Latency["DescribeTasks"] = 10 * time.Millisecond
+	faults.Latency["DescribeContainerInstances"] = 10 * time.Millisecond
+	sn, _, _ = NewFaultySnitcher(t, faults)
+	actual := len(measureWithin(t, sn, time.Second))
+	if actual > healthy {
+		t.Errorf("expected at most %d data points but got %d", healthy, actual)
+	}
+	if actual%(healthy/3) != 0 {
+		t.Errorf("expected whole clusters' worth of data points but got %d", actual)
+	}
+}
+
+// TestFaults_Publish verifies every batch is attempted even when CloudWatch
+// fails some of them.
+func TestFaults_Publish(t *testing.T) {
+	faults := NewFaults(2)
+	faults.ErrorRate["PutMetricData"] = 0.5
+	sn, _, cw := NewFaultySnitcher(t, faults)
+	cr := NewClusterResources(aws.String("faulty-publishing-cluster"))
+	for i := 0; i < 50; i++ {
+		cr.Registered["fake.instanceType"+strconv.Itoa(i)] = i
+	}
+	sn.Publish(cr.ToMetricData())
+	if expected := 3; faults.Calls["PutMetricData"] != expected {
+		t.Errorf("expected %d PutMetricData calls but got %d", expected, faults.Calls["PutMetricData"])
+	}
+	if len(cw.payload)+faults.Failures["PutMetricData"] != faults.Calls["PutMetricData"] {
+		t.Errorf("expected %d successful plus %d failed calls to add up to %d", len(cw.payload), faults.Failures["PutMetricData"], faults.Calls["PutMetricData"])
+	}
+}
@@ -0,0 +1,56 @@
+package snitch
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/fis"
+)
+
+// ListActiveExperiments lists IDs of AWS Fault Injection Simulator experiments
+// underway, sorted, or nil if there are none or they can't be listed.
+//
+// Requires IAM permission "fis:ListExperiments".
+func (sn *Snitcher) ListActiveExperiments() (ids []string) {
+	err := sn.FIS.ListExperimentsPages(
+		&fis.ListExperimentsInput{},
+		func(page *fis.ListExperimentsOutput, last bool) bool {
+			for _, experiment := range page.Experiments {
+				if experiment.State == nil {
+					continue
+				}
+				switch aws.StringValue(experiment.State.Status) {
+				case fis.ExperimentStatusInitiating, fis.ExperimentStatusRunning, fis.ExperimentStatusStopping:
+					ids = append(ids, aws.StringValue(experiment.Id))
+				}
+			}
+			return len(page.Experiments) > 0
+		},
+	)
+	if err != nil {
+		sn.logger().Errorf("Failed to ListExperimentsPages! %s", err)
+	}
+	sort.Strings(ids)
+	return
+}
+
+// withExperiments adds a "FaultInjectionExperiment" dimension, of active
+// experiments' IDs, to metric data measured while any are underway. That keeps
+// capacity anomalies of chaos tests out of alarms and baselines on the usual
+// dimensions. Measure lists ids once per run, not per cluster.
+func (sn *Snitcher) withExperiments(metricData []*cloudwatch.MetricDatum, ids []string) []*cloudwatch.MetricDatum {
+	if len(ids) == 0 {
+		return metricData
+	}
+	sn.logger().Infof("Labeling metrics measured during experiments %v", ids)
+	experiments := strings.Join(ids, ",")
+	for _, datum := range metricData {
+		datum.Dimensions = append(datum.Dimensions, &cloudwatch.Dimension{
+			Name:  aws.String("FaultInjectionExperiment"),
+			Value: aws.String(experiments),
+		})
+	}
+	return metricData
+}
@@ -0,0 +1,89 @@
+package snitch
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/fis"
+	"github.com/aws/aws-sdk-go/service/fis/fisiface"
+)
+
+// FakeFIS mocks AWS Fault Injection Simulator for testing.
+type FakeFIS struct {
+	fisiface.FISAPI
+	errorToReturn       error             // `error` to return from fake methods.
+	expectedExperiments map[string]string // Expected experiments' status by ID.
+	listed              int               // Times experiments were listed.
+}
+
+func (fake *FakeFIS) ListExperimentsPages(input *fis.ListExperimentsInput, pager func(*fis.ListExperimentsOutput, bool) bool) error {
+	fake.listed++
+	output := &fis.ListExperimentsOutput{}
+	for id, status := range fake.expectedExperiments {
+		output.Experiments = append(output.Experiments, &fis.ExperimentSummary{
+			Id:    aws.String(id),
+			State: &fis.ExperimentState{Status: aws.String(status)},
+		})
+	}
+	if fake.errorToReturn == nil {
+		pager(output, true)
+	}
+	return fake.errorToReturn
+}
+
+func TestSnitcher_ListActiveExperiments(t *testing.T) {
+	fake := &FakeFIS{expectedExperiments: map[string]string{
+		"EXPb": "running",
+		"EXPa": "initiating",
+		"EXPc": "completed",
+	}}
+	sn := &Snitcher{FIS: fake}
+	if ids := sn.ListActiveExperiments(); len(ids) != 2 || ids[0] != "EXPa" || ids[1] != "EXPb" {
+		t.Errorf("expected [EXPa EXPb] but got %v", ids)
+	}
+	fake.errorToReturn = errors.New("there should be no experiments on error")
+	if ids := sn.ListActiveExperiments(); len(ids) != 0 {
+		t.Error(fake.errorToReturn)
+	}
+}
+
+func TestSnitcher_withExperiments(t *testing.T) {
+	sn := &Snitcher{}
+	metricData := []*cloudwatch.MetricDatum{{MetricName: aws.String("RemainingSchedulable")}}
+	if dimensions := sn.withExperiments(metricData, nil)[0].Dimensions; len(dimensions) != 0 {
+		t.Errorf("expected no dimensions without experiments underway but got %v", dimensions)
+	}
+	dimensions := sn.withExperiments(metricData, []string{"EXPb"})[0].Dimensions
+	if len(dimensions) != 1 || *dimensions[0].Name != "FaultInjectionExperiment" || *dimensions[0].Value != "EXPb" {
+		t.Errorf("expected FaultInjectionExperiment dimension but got %v", dimensions)
+	}
+}
+
+func TestSnitcher_MeasureFaultInjection_stream(t *testing.T) {
+	fake := NewFakeECS(t)
+	fake.checkCluster = false
+	fakeFIS := &FakeFIS{expectedExperiments: map[string]string{"EXPa": "running"}}
+	var mutex sync.Mutex
+	var streamed []*cloudwatch.MetricDatum
+	sn := &Snitcher{ECS: fake, FIS: fakeFIS, MeasureFaultInjection: aws.Bool(true)}
+	sn.stream = func(metricData []*cloudwatch.MetricDatum) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		streamed = append(streamed, metricData...)
+	}
+	sn.Measure()
+	if fakeFIS.listed != 1 {
+		t.Errorf("expected experiments listed once per run but got %d times", fakeFIS.listed)
+	}
+	if len(streamed) == 0 {
+		t.Fatal("expected metrics streamed")
+	}
+	for _, datum := range streamed {
+		if dimension := datum.Dimensions[len(datum.Dimensions)-1]; aws.StringValue(dimension.Value) != "EXPa" {
+			t.Errorf("expected %s labeled with EXPa but got %v", aws.StringValue(datum.MetricName), datum.Dimensions)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package snitch
+
+import (
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+// Requirements a Task needs from a Container Instance before ECS will place it
+// there.
+type Requirements struct {
+	// CPU Units and Memory (RAM in MiB) reserved by the Task.
+	CPU    int
+	Memory int
+	// Number of GPUs reserved by the Task.
+	GPU int
+	// Host ports the Task binds, like "80", which ECS reports as "PORTS".
+	Ports []string
+}
+
+// NewRequirements reads what an ECS Task Definition needs to launch.
+//
+// Task-level CPU and Memory win when they're set. Otherwise they're the sum of
+// each container's, with Memory Reservation preferred over hard Memory limit,
+// since that's what ECS reserves. Dynamic host ports and "awsvpc" networking
+// don't tie up ports on the Container Instance, so those aren't Ports.
+func NewRequirements(taskDefinition *ecs.TaskDefinition) *Requirements {
+	req := &Requirements{}
+	for _, container := range taskDefinition.ContainerDefinitions {
+		req.CPU += int(aws.Int64Value(container.Cpu))
+		if reservation := aws.Int64Value(container.MemoryReservation); reservation > 0 {
+			req.Memory += int(reservation)
+		} else {
+			req.Memory += int(aws.Int64Value(container.Memory))
+		}
+		for _, resource := range container.ResourceRequirements {
+			if aws.StringValue(resource.Type) == "GPU" {
+				gpu, err := strconv.Atoi(aws.StringValue(resource.Value))
+				if err != nil {
+					defaultLogger.Errorf("Failed to convert %q GPU to int: %s", aws.StringValue(taskDefinition.Family), err)
+				}
+				req.GPU += gpu
+			}
+		}
+		if aws.StringValue(taskDefinition.NetworkMode) == "awsvpc" {
+			continue
+		}
+		for _, mapping := range container.PortMappings {
+			if port := aws.Int64Value(mapping.HostPort); port > 0 {
+				req.Ports = append(req.Ports, strconv.FormatInt(port, 10))
+			}
+		}
+	}
+	if taskDefinition.Cpu != nil {
+		if cpu, err := strconv.Atoi(*taskDefinition.Cpu); err == nil {
+			req.CPU = cpu
+		}
+	}
+	if taskDefinition.Memory != nil {
+		if memory, err := strconv.Atoi(*taskDefinition.Memory); err == nil {
+			req.Memory = memory
+		}
+	}
+	return req
+}
+
+// Possible calculates how many copies of a Task fit into ECS Resources of one
+// Container Instance.
+//
+// Like ContainersPossible, but also counts GPUs and refuses host ports already
+// taken. A Task binding a host port fits at most once per Container Instance.
+func (req *Requirements) Possible(resources []*ecs.Resource) int {
+	if req.CPU <= 0 && req.Memory <= 0 {
+		return 0
+	}
+	named := map[string]*ecs.Resource{}
+	for _, resource := range resources {
+		named[aws.StringValue(resource.Name)] = resource
+	}
+	possible := -1
+	limit := func(n int) {
+		if possible < 0 || n < possible {
+			possible = n
+		}
+	}
+	if req.CPU > 0 {
+		limit(int(aws.Int64Value(integerValue(named["CPU"]))) / req.CPU)
+	}
+	if req.Memory > 0 {
+		limit(int(aws.Int64Value(integerValue(named["MEMORY"]))) / req.Memory)
+	}
+	if req.GPU > 0 {
+		limit(len(stringSetValue(named["GPU"])) / req.GPU)
+	}
+	for _, port := range req.Ports {
+		limit(1)
+		for _, taken := range aws.StringValueSlice(stringSetValue(named["PORTS"])) {
+			if port == taken {
+				return 0
+			}
+		}
+	}
+	return possible
+}
+
+// integerValue is a resource's IntegerValue, even when resource is missing.
+func integerValue(resource *ecs.Resource) *int64 {
+	if resource == nil {
+		return nil
+	}
+	return resource.IntegerValue
+}
+
+// stringSetValue is a resource's StringSetValue, even when resource is missing.
+func stringSetValue(resource *ecs.Resource) []*string {
+	if resource == nil {
+		return nil
+	}
+	return resource.StringSetValue
+}
+
+// DescribeRequirements reads what a Task Definition, like "my-family:42" or
+// its ARN, needs to launch. Returns nil if the Task Definition can't be read.
+//
+// Requires IAM permission "ecs:DescribeTaskDefinition".
+func (sn *Snitcher) DescribeRequirements(taskDefinition *string) *Requirements {
+	input := &ecs.DescribeTaskDefinitionInput{
+		TaskDefinition: taskDefinition,
+	}
+	output, err := sn.ECS.DescribeTaskDefinition(input)
+	if err != nil {
+		sn.logger().Errorf("Failed to DescribeTaskDefinition %q: %s", *taskDefinition, err)
+		return nil
+	}
+	req := NewRequirements(output.TaskDefinition)
+	sn.logger().Infof("%q requires %+v", *taskDefinition, *req)
+	return req
+}
+
+// Fit calculates how many copies of a Task with req Requirements an ECS Cluster
+// can schedule, in total and right now.
+//
+//	req := sn.DescribeRequirements(aws.String("my-family:42"))
+//	registered, remaining := sn.Fit(cluster, req)
+func (sn *Snitcher) Fit(cluster *string, req *Requirements) (registered, remaining int) {
+	instances := sn.ListContainerInstances(cluster)
+	if len(instances) == 0 {
+		return
+	}
+	for _, container := range sn.DescribeContainerInstances(cluster, instances) {
+		registered += req.Possible(container.RegisteredResources)
+		remaining += req.Possible(container.RemainingResources)
+	}
+	sn.logger().Infof("%q fits %d of %d Tasks requiring %+v", *cluster, remaining, registered, *req)
+	return
+}
@@ -0,0 +1,126 @@
+package snitch
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+// DescribeTaskDefinition fake-describes an ECS Task Definition.
+func (fake *FakeECS) DescribeTaskDefinition(input *ecs.DescribeTaskDefinitionInput) (*ecs.DescribeTaskDefinitionOutput, error) {
+	output := &ecs.DescribeTaskDefinitionOutput{
+		TaskDefinition: fake.expectedTaskDefinition,
+	}
+	return output, fake.errorToReturn
+}
+
+func TestNewRequirements(t *testing.T) {
+	gpu := []*ecs.ResourceRequirement{{Type: aws.String("GPU"), Value: aws.String("1")}}
+	for name, arg := range map[string]struct {
+		expected       Requirements
+		taskDefinition *ecs.TaskDefinition
+	}{
+		"sum of containers": {
+			Requirements{CPU: 768, Memory: 1536, GPU: 2, Ports: []string{"80"}},
+			&ecs.TaskDefinition{
+				ContainerDefinitions: []*ecs.ContainerDefinition{
+					{Cpu: aws.Int64(512), Memory: aws.Int64(2048), MemoryReservation: aws.Int64(1024), ResourceRequirements: gpu},
+					{
+						Cpu:                  aws.Int64(256),
+						Memory:               aws.Int64(512),
+						ResourceRequirements: gpu,
+						PortMappings: []*ecs.PortMapping{
+							{ContainerPort: aws.Int64(8080), HostPort: aws.Int64(80)},
+							{ContainerPort: aws.Int64(8081), HostPort: aws.Int64(0)},
+						},
+					},
+				},
+			},
+		},
+		"task-level size and awsvpc": {
+			Requirements{CPU: 1024, Memory: 4096},
+			&ecs.TaskDefinition{
+				Cpu:         aws.String("1024"),
+				Memory:      aws.String("4096"),
+				NetworkMode: aws.String("awsvpc"),
+				ContainerDefinitions: []*ecs.ContainerDefinition{
+					{
+						Cpu:          aws.Int64(128),
+						Memory:       aws.Int64(256),
+						PortMappings: []*ecs.PortMapping{{ContainerPort: aws.Int64(80), HostPort: aws.Int64(80)}},
+					},
+				},
+			},
+		},
+	} {
+		if actual := NewRequirements(arg.taskDefinition); !reflect.DeepEqual(arg.expected, *actual) {
+			t.Errorf("%s: expected %+v but got %+v", name, arg.expected, *actual)
+		}
+	}
+}
+
+// TestRequirements_Possible hardcodes values to ensure accuracy of logic.
+func TestRequirements_Possible(t *testing.T) {
+	resources := []*ecs.Resource{
+		{Name: aws.String("CPU"), IntegerValue: aws.Int64(4096)},
+		{Name: aws.String("MEMORY"), IntegerValue: aws.Int64(8192)},
+		{Name: aws.String("GPU"), StringSetValue: aws.StringSlice([]string{"gpu-0", "gpu-1", "gpu-2"})},
+		{Name: aws.String("PORTS"), StringSetValue: aws.StringSlice([]string{"22", "443"})},
+	}
+	for _, arg := range []struct {
+		possible int
+		req      Requirements
+	}{
+		{4, Requirements{CPU: 1024, Memory: 1024}},
+		{2, Requirements{CPU: 512, Memory: 4096}},
+		{8, Requirements{Memory: 1024}},
+		{1, Requirements{CPU: 512, Memory: 512, GPU: 2}},
+		{1, Requirements{CPU: 512, Memory: 512, Ports: []string{"80"}}},
+		{0, Requirements{CPU: 512, Memory: 512, Ports: []string{"443"}}},
+		{0, Requirements{}},
+	} {
+		if actual := arg.req.Possible(resources); actual != arg.possible {
+			t.Errorf("expected %+v Possible() = %d; got %d", arg.req, arg.possible, actual)
+		}
+	}
+	if actual := (&Requirements{CPU: 512, Memory: 512, GPU: 1}).Possible(resources[:2]); actual != 0 {
+		t.Errorf("expected no GPU Tasks without GPUs but got %d", actual)
+	}
+}
+
+func TestSnitcher_DescribeRequirements(t *testing.T) {
+	fake := NewFakeECS(t)
+	fake.expectedTaskDefinition = &ecs.TaskDefinition{
+		Cpu:    aws.String("512"),
+		Memory: aws.String("1024"),
+	}
+	sn := &Snitcher{ECS: fake}
+	req := sn.DescribeRequirements(aws.String("my-family:42"))
+	if req == nil || req.CPU != 512 || req.Memory != 1024 {
+		t.Errorf("expected 512 CPU Units and 1024 MiB but got %+v", req)
+	}
+	fake.errorToReturn = errors.New("there should be no Requirements on error")
+	if req := sn.DescribeRequirements(aws.String("my-family:42")); req != nil {
+		t.Errorf("expected nil Requirements but got %+v", req)
+	}
+}
+
+func TestSnitcher_Fit(t *testing.T) {
+	fake := NewFakeECS(t)
+	sn := &Snitcher{ECS: fake}
+	req := &Requirements{CPU: fake.expectedCPU, Memory: fake.expectedMemory}
+	registered, remaining := sn.Fit(fake.expectedCluster, req)
+	if registered != fake.expectedRegisteredPossible {
+		t.Errorf("expected %d registered but got %d", fake.expectedRegisteredPossible, registered)
+	}
+	if remaining != fake.expectedRemainingPossible {
+		t.Errorf("expected %d remaining but got %d", fake.expectedRemainingPossible, remaining)
+	}
+	fake.errorToReturn = errors.New("nothing fits when ECS errors")
+	if registered, remaining := sn.Fit(fake.expectedCluster, req); registered+remaining != 0 {
+		t.Errorf("expected 0, 0 during error but got %d, %d", registered, remaining)
+	}
+}
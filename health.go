@@ -0,0 +1,128 @@
+package snitch
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Health tracks runs of a long-running snitch, like a daemon or exporter, so
+// health checks can restart it when it's wedged. Its methods are safe to call
+// on nil Health, which tracks nothing.
+type Health struct {
+	// MaxAge is how long since the last run finished, or since one in
+	// progress started, before snitch is unhealthy, like 3 intervals.
+	MaxAge    time.Duration
+	mutex     sync.Mutex
+	started   time.Time // Of the run in progress, if any.
+	progress  *Progress // Of the run in progress, if counted.
+	finished  time.Time
+	succeeded time.Time
+	err       error
+}
+
+// NewHealth tracks health, unhealthy after maxAge without finishing a run.
+func NewHealth(maxAge time.Duration) *Health {
+	return &Health{MaxAge: maxAge, finished: time.Now()}
+}
+
+func (h *Health) update(update func()) {
+	if h == nil {
+		return
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	update()
+}
+
+func (h *Health) begin(progress *Progress) {
+	h.update(func() { h.started, h.progress = time.Now(), progress })
+}
+
+func (h *Health) done(err error) {
+	h.update(func() {
+		h.started, h.progress, h.finished, h.err = time.Time{}, nil, time.Now(), err
+		if err == nil {
+			h.succeeded = h.finished
+		}
+	})
+}
+
+// checked runs fn, returning its error, or else the first failure it
+// summarized, like measuring a cluster, so Health reflects those too.
+func (sn *Snitcher) checked(fn func() error) error {
+	if sn.Summary == nil {
+		sn.Summary = &Summary{}
+		defer func() { sn.Summary = nil }()
+	}
+	failures := sn.Summary.failures()
+	if err := fn(); err != nil {
+		return err
+	}
+	return sn.Summary.errSince(failures)
+}
+
+// HealthStatus is what /healthz and /readyz respond with, as JSON.
+type HealthStatus struct {
+	Healthy       bool       `json:"healthy"`
+	Ready         bool       `json:"ready"`
+	Running       bool       `json:"running"`
+	LastSucceeded *time.Time `json:"lastSucceeded,omitempty"`
+	LastError     string     `json:"lastError,omitempty"`
+	// Progress of the run in progress, if counted, so a long run can be told
+	// apart from a hung one.
+	Progress *ProgressStatus `json:"progress,omitempty"`
+}
+
+// ProgressStatus counts clusters measured by the run in progress.
+type ProgressStatus struct {
+	Completed  int `json:"completed"`
+	Discovered int `json:"discovered"`
+	// Listed is whether every cluster has been discovered yet.
+	Listed bool `json:"listed"`
+	// ETA, like "1m30s", once every cluster is discovered and some completed.
+	ETA string `json:"eta,omitempty"`
+}
+
+// Status reports whether snitch is healthy, finishing runs within MaxAge,
+// and ready, its last run having succeeded within MaxAge.
+func (h *Health) Status() (status HealthStatus) {
+	h.update(func() {
+		now := time.Now()
+		status.Running = !h.started.IsZero()
+		status.Healthy = now.Sub(h.finished) <= h.MaxAge && (!status.Running || now.Sub(h.started) <= h.MaxAge)
+		if !h.succeeded.IsZero() {
+			succeeded := h.succeeded
+			status.LastSucceeded = &succeeded
+			status.Ready = status.Healthy && h.err == nil && now.Sub(h.succeeded) <= h.MaxAge
+		}
+		if h.err != nil {
+			status.LastError = h.err.Error()
+		}
+		if h.progress != nil {
+			progress := &ProgressStatus{}
+			progress.Completed, progress.Discovered, progress.Listed = h.progress.Counts()
+			if eta := h.progress.ETA(); eta > 0 {
+				progress.ETA = eta.Round(time.Second).String()
+			}
+			status.Progress = progress
+		}
+	})
+	return
+}
+
+// ServeHTTP serves "/healthz", failing when unhealthy, and "/readyz" (or
+// anything else), failing when not ready, with HealthStatus as JSON.
+func (h *Health) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	status := h.Status()
+	ok := status.Ready
+	if r.URL.Path == "/healthz" {
+		ok = status.Healthy
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(status)
+}
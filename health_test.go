@@ -0,0 +1,88 @@
+package snitch
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestHealth(t *testing.T) {
+	h := NewHealth(time.Hour)
+	respond := func(path string) int {
+		recorder := httptest.NewRecorder()
+		h.ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+		return recorder.Code
+	}
+	if respond("/healthz") != http.StatusOK || respond("/readyz") != http.StatusServiceUnavailable {
+		t.Errorf("expected healthy but not ready before running but got %+v", h.Status())
+	}
+	h.begin(nil)
+	h.done(nil)
+	if respond("/healthz") != http.StatusOK || respond("/readyz") != http.StatusOK {
+		t.Errorf("expected healthy and ready after succeeding but got %+v", h.Status())
+	}
+	h.begin(nil)
+	h.done(errors.New("fake"))
+	if status := h.Status(); !status.Healthy || status.Ready || status.LastError != "fake" || status.LastSucceeded == nil {
+		t.Errorf("expected healthy, not ready, with last error and success but got %+v", status)
+	}
+	progress := NewProgress()
+	progress.started = time.Now().Add(-time.Minute)
+	h.begin(progress)
+	progress.discover()
+	progress.discover()
+	progress.complete()
+	if status := h.Status(); status.Progress == nil || *status.Progress != (ProgressStatus{Completed: 1, Discovered: 2, Listed: true, ETA: "1m0s"}) {
+		t.Errorf("expected progress of the run in progress but got %+v", status.Progress)
+	}
+	h.done(nil)
+	if status := h.Status(); status.Progress != nil {
+		t.Errorf("expected no progress between runs but got %+v", status.Progress)
+	}
+	h.begin(nil)
+	h.started = time.Now().Add(-2 * time.Hour)
+	if status := h.Status(); status.Healthy || !status.Running || respond("/healthz") != http.StatusServiceUnavailable {
+		t.Errorf("expected a run wedged for 2 hours unhealthy but got %+v", status)
+	}
+	var nilHealth *Health
+	nilHealth.begin(nil)
+	nilHealth.done(nil)
+}
+
+func TestRunEvery_Health(t *testing.T) {
+	fake := NewFakeECS(t)
+	fake.checkCluster = false
+	sn := &Snitcher{
+		ECS:           fake,
+		CloudWatch:    &FakeCloudWatch{errorToReturn: errors.New("fake")},
+		Namespace:     aws.String("Testable/Namespace"),
+		ShouldPublish: aws.Bool(true),
+		Health:        NewHealth(time.Hour),
+	}
+	stop := make(chan struct{})
+	close(stop)
+	RunEvery(sn, time.Hour, 0, stop)
+	if status := sn.Health.Status(); status.Ready || status.LastError == "" {
+		t.Errorf("expected failing to publish to be unready but got %+v", status)
+	}
+}
+
+func TestRunEvery_HealthMeasureFailed(t *testing.T) {
+	faults := NewFaults(1)
+	faults.ErrorRate["ListContainerInstances"] = 1
+	sn, _, _ := NewFaultySnitcher(t, faults)
+	sn.Health = NewHealth(time.Hour)
+	stop := make(chan struct{})
+	close(stop)
+	RunEvery(sn, time.Hour, 0, stop)
+	if status := sn.Health.Status(); status.Ready || status.LastError == "" {
+		t.Errorf("expected failing to measure to be unready but got %+v", status)
+	}
+	if sn.Summary != nil {
+		t.Errorf("expected no Summary left behind but got %+v", sn.Summary)
+	}
+}
@@ -0,0 +1,156 @@
+package snitch
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// PolicyDocument is an IAM policy, marshaling to JSON IAM accepts.
+type PolicyDocument struct {
+	Version   string
+	Statement []*PolicyStatement
+}
+
+// PolicyStatement allows Action on Resource.
+type PolicyStatement struct {
+	Sid      string
+	Effect   string
+	Action   []string
+	Resource []string
+}
+
+// IAMPolicy generates the least IAM policy Run needs, as Snitcher's
+// configured, with statements like the package documentation's. Resources
+// are "*", besides Roles to assume, so scope them as you see fit. IAM Roles
+// measured as need the same reading statements.
+func (sn *Snitcher) IAMPolicy() *PolicyDocument {
+	ecs := []string{"ecs:DescribeContainerInstances", "ecs:ListContainerInstances"}
+	if aws.IntValue(sn.CPU) == 0 || aws.IntValue(sn.Memory) == 0 || aws.BoolValue(sn.MeasurePending) {
+		ecs = append(ecs, "ecs:DescribeTasks", "ecs:ListTasks")
+	}
+	if aws.StringValue(sn.Cluster) == "" {
+		ecs = append(ecs, "ecs:ListClusters")
+	}
+	if len(sn.SelectTags) > 0 || len(sn.DimensionTags) > 0 {
+		ecs = append(ecs, "ecs:DescribeClusters")
+	}
+	if aws.BoolValue(sn.ExcludeDaemons) || aws.BoolValue(sn.MeasureScheduledScaling) {
+		ecs = append(ecs, "ecs:DescribeServices")
+	}
+	var ec2 []string
+	if aws.BoolValue(sn.MeasureSubnets) {
+		ec2 = append(ec2, "ec2:DescribeInstances", "ec2:DescribeSubnets")
+	}
+	if len(sn.Regions) == 1 && sn.Regions[0] == "all" {
+		ec2 = append(ec2, "ec2:DescribeRegions")
+	}
+	var scaling, fis, organizations, secrets, sinks, cloudwatch, tracing, state, alarms, config []string
+	if location := aws.StringValue(sn.ConfigLocation); strings.HasSuffix(location, "/") && strings.HasPrefix(location, "ssm:") {
+		config = append(config, "kms:Decrypt", "ssm:GetParametersByPath")
+	} else if strings.HasPrefix(location, "ssm:") {
+		config = append(config, "kms:Decrypt", "ssm:GetParameter")
+	}
+	if aws.BoolValue(sn.Trace) {
+		tracing = append(tracing, "xray:PutTelemetryRecords", "xray:PutTraceSegments")
+	}
+	if aws.BoolValue(sn.MeasureScheduledScaling) {
+		scaling = append(scaling, "application-autoscaling:DescribeScheduledActions")
+	}
+	if aws.BoolValue(sn.MeasureFaultInjection) {
+		fis = append(fis, "fis:ListExperiments")
+	}
+	if aws.StringValue(sn.OrganizationRole) != "" {
+		if aws.StringValue(sn.OrganizationalUnit) != "" {
+			organizations = append(organizations, "organizations:ListAccountsForParent")
+		} else {
+			organizations = append(organizations, "organizations:ListAccounts")
+		}
+		if len(sn.AccountTags) > 0 {
+			organizations = append(organizations, "organizations:ListTagsForResource")
+		}
+	}
+	toCloudWatch := !aws.BoolValue(sn.EmbeddedMetricFormat) && aws.StringValue(sn.DryRun) == ""
+	if toCloudWatch && (aws.BoolValue(sn.ShouldPublish) || aws.StringValue(sn.CanaryNamespace) != "" || aws.StringValue(sn.TelemetryNamespace) != "") {
+		cloudwatch = append(cloudwatch, "cloudwatch:PutMetricData")
+	}
+	if location := aws.StringValue(sn.DeltaState); strings.HasPrefix(location, "dynamodb:") {
+		state = append(state, "dynamodb:BatchWriteItem", "dynamodb:Scan")
+	} else if strings.HasPrefix(location, "s3://") {
+		state = append(state, "s3:GetObject", "s3:PutObject")
+	}
+	if sn.Alarms != nil {
+		alarms = append(alarms, "cloudwatch:DeleteAlarms", "cloudwatch:DescribeAlarms", "cloudwatch:PutMetricAlarm")
+	}
+	for _, config := range sn.Sinks {
+		if config.Secret != "" || secretARN.MatchString(config.URL) || secretARN.MatchString(config.Address) {
+			secrets = append(secrets, "secretsmanager:GetSecretValue")
+		}
+		switch config.Type {
+		case "cloudwatch":
+			cloudwatch = append(cloudwatch, "cloudwatch:PutMetricData")
+		case "dynamodb":
+			sinks = append(sinks, "dynamodb:PutItem")
+		case "eventbridge":
+			sinks = append(sinks, "events:PutEvents")
+		case "s3":
+			sinks = append(sinks, "s3:PutObject")
+		case "sns":
+			sinks = append(sinks, "sns:Publish")
+		case "sqs":
+			sinks = append(sinks, "sqs:SendMessage")
+		}
+	}
+	policy := &PolicyDocument{Version: "2012-10-17"}
+	policy.allow("PermitReadingConfiguration", config, "*")
+	policy.allow("PermitReadingFromECS", ecs, "*")
+	policy.allow("PermitReadingSubnets", ec2, "*")
+	policy.allow("PermitReadingScheduledScaling", scaling, "*")
+	policy.allow("PermitReadingFaultInjection", fis, "*")
+	policy.allow("PermitDiscoveringAccounts", organizations, "*")
+	policy.allow("PermitAssumingRoles", []string{"sts:AssumeRole"}, sn.assumedRoles()...)
+	policy.allow("PermitReadingSinkSecrets", secrets, "*")
+	policy.allow("PermitTracing", tracing, "*")
+	policy.allow("PermitWritingToSinks", sinks, "*")
+	policy.allow("PermitWritingToCloudWatch", cloudwatch, "*")
+	policy.allow("PermitKeepingMetricsPublished", state, "*")
+	policy.allow("PermitSyncingAlarms", alarms, "*")
+	return policy
+}
+
+// allow adds a statement allowing actions, sorted and deduplicated, on
+// resources, unless there are no actions or resources.
+func (policy *PolicyDocument) allow(sid string, actions []string, resources ...string) {
+	if len(actions) == 0 || len(resources) == 0 {
+		return
+	}
+	seen := map[string]bool{}
+	var unique []string
+	for _, action := range actions {
+		if !seen[action] {
+			seen[action] = true
+			unique = append(unique, action)
+		}
+	}
+	sort.Strings(unique)
+	policy.Statement = append(policy.Statement, &PolicyStatement{
+		Sid:      sid,
+		Effect:   "Allow",
+		Action:   unique,
+		Resource: resources,
+	})
+}
+
+// assumedRoles are ARNs of IAM Roles Snitcher assumes: Role, Roles and, in
+// every account, OrganizationRole.
+func (sn *Snitcher) assumedRoles() (roles []string) {
+	if aws.StringValue(sn.Role) != "" {
+		roles = append(roles, aws.StringValue(sn.Role))
+	}
+	roles = append(roles, sn.Roles...)
+	if aws.StringValue(sn.OrganizationRole) != "" {
+		roles = append(roles, "arn:aws:iam::*:role/"+aws.StringValue(sn.OrganizationRole))
+	}
+	return
+}
@@ -0,0 +1,98 @@
+package snitch
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestSnitcher_IAMPolicy(t *testing.T) {
+	actions := func(policy *PolicyDocument) map[string][]string {
+		bySid := map[string][]string{}
+		for _, statement := range policy.Statement {
+			bySid[statement.Sid] = statement.Action
+		}
+		return bySid
+	}
+	minimal := (&Snitcher{Cluster: aws.String("fake-cluster"), CPU: aws.Int(256), Memory: aws.Int(512)}).IAMPolicy()
+	expected := map[string][]string{
+		"PermitReadingFromECS": {"ecs:DescribeContainerInstances", "ecs:ListContainerInstances"},
+	}
+	if actual := actions(minimal); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v but got %v", expected, actual)
+	}
+
+	sn := &Snitcher{
+		ShouldPublish:    aws.Bool(true),
+		SelectTags:       map[string]string{"snitch": "enabled"},
+		MeasureSubnets:   aws.Bool(true),
+		Regions:          []string{"all"},
+		OrganizationRole: aws.String("snitch"),
+		Roles:            []string{"arn:aws:iam::123456789012:role/snitch"},
+		Sinks: []*SinkConfig{
+			{Type: "sns"},
+			{Type: "slack", Secret: "slack-webhook"},
+			{Type: "cloudwatch"},
+		},
+	}
+	policy := sn.IAMPolicy()
+	expected = map[string][]string{
+		"PermitReadingFromECS":      {"ecs:DescribeClusters", "ecs:DescribeContainerInstances", "ecs:DescribeTasks", "ecs:ListClusters", "ecs:ListContainerInstances", "ecs:ListTasks"},
+		"PermitReadingSubnets":      {"ec2:DescribeInstances", "ec2:DescribeRegions", "ec2:DescribeSubnets"},
+		"PermitDiscoveringAccounts": {"organizations:ListAccounts"},
+		"PermitAssumingRoles":       {"sts:AssumeRole"},
+		"PermitReadingSinkSecrets":  {"secretsmanager:GetSecretValue"},
+		"PermitWritingToSinks":      {"sns:Publish"},
+		"PermitWritingToCloudWatch": {"cloudwatch:PutMetricData"},
+	}
+	if actual := actions(policy); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v but got %v", expected, actual)
+	}
+	for _, statement := range policy.Statement {
+		if statement.Sid == "PermitAssumingRoles" {
+			roles := []string{"arn:aws:iam::123456789012:role/snitch", "arn:aws:iam::*:role/snitch"}
+			if !reflect.DeepEqual(statement.Resource, roles) {
+				t.Errorf("expected roles %v but got %v", roles, statement.Resource)
+			}
+		}
+	}
+	if _, err := json.Marshal(policy); err != nil {
+		t.Error(err)
+	}
+
+	sn.Sinks = []*SinkConfig{{Type: "webhook", URL: "arn:aws:secretsmanager:us-east-1:123456789012:secret:webhook"}}
+	if _, ok := actions(sn.IAMPolicy())["PermitReadingSinkSecrets"]; !ok {
+		t.Error("expected GetSecretValue of URL that's a secret ARN")
+	}
+
+	sn.EmbeddedMetricFormat = aws.Bool(true)
+	sn.Sinks = nil
+	if _, ok := actions(sn.IAMPolicy())["PermitWritingToCloudWatch"]; ok {
+		t.Error("expected no PutMetricData publishing Embedded Metric Format")
+	}
+
+	sn.ConfigLocation = aws.String("ssm:/snitch/prod")
+	if actual := actions(sn.IAMPolicy())["PermitReadingConfiguration"]; !reflect.DeepEqual(actual, []string{"kms:Decrypt", "ssm:GetParameter"}) {
+		t.Errorf("expected GetParameter and Decrypt of SSM configuration but got %v", actual)
+	}
+	sn.ConfigLocation = aws.String("ssm:/snitch/prod/")
+	if actual := actions(sn.IAMPolicy())["PermitReadingConfiguration"]; !reflect.DeepEqual(actual, []string{"kms:Decrypt", "ssm:GetParametersByPath"}) {
+		t.Errorf("expected GetParametersByPath and Decrypt of SSM configuration path but got %v", actual)
+	}
+	sn.ConfigLocation = aws.String("snitch.yaml")
+	if _, ok := actions(sn.IAMPolicy())["PermitReadingConfiguration"]; ok {
+		t.Error("expected no SSM permissions of a configuration file")
+	}
+
+	sn.DeltaState = aws.String("dynamodb:snitch-deltas")
+	if actual := actions(sn.IAMPolicy())["PermitKeepingMetricsPublished"]; !reflect.DeepEqual(actual, []string{"dynamodb:BatchWriteItem", "dynamodb:Scan"}) {
+		t.Errorf("expected BatchWriteItem and Scan of DynamoDB delta state but got %v", actual)
+	}
+
+	sn.Alarms = &AlarmConfig{Thresholds: map[string]float64{"*": 5}}
+	if actual := actions(sn.IAMPolicy())["PermitSyncingAlarms"]; !reflect.DeepEqual(actual, []string{"cloudwatch:DeleteAlarms", "cloudwatch:DescribeAlarms", "cloudwatch:PutMetricAlarm"}) {
+		t.Errorf("expected alarms described, put and deleted but got %v", actual)
+	}
+}
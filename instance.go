@@ -0,0 +1,94 @@
+package snitch
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+// InstanceReport sums up one Container Instance, for deciding whether it can
+// be drained.
+type InstanceReport struct {
+	ContainerInstanceArn string
+	EC2InstanceID        string
+	InstanceType         string
+	Status               string
+	AgentConnected       bool
+	// CPU Units and Memory (MiB) registered and remaining.
+	RegisteredCPU    int
+	RegisteredMemory int
+	RemainingCPU     int
+	RemainingMemory  int
+	// Lowest common multiple container size of the cluster, and how many such
+	// containers fit in registered and remaining resources.
+	CPU                   int
+	Memory                int
+	RegisteredSchedulable int
+	RemainingSchedulable  int
+	RunningTasks          int
+	PendingTasks          int
+	// ARNs of Tasks running on the Container Instance.
+	Tasks []string
+}
+
+// MeasureInstance reports on one Container Instance of cluster, or returns nil
+// if it can't be described.
+//
+// Schedulable counts are of containers sized like ContainerSize, unweighed by
+// InstanceTypeWeights, since they're about this one box.
+func (sn *Snitcher) MeasureInstance(cluster, instance *string) *InstanceReport {
+	containers := sn.DescribeContainerInstances(cluster, []*string{instance})
+	if len(containers) == 0 {
+		sn.logger().Infof("%q has no Container Instance %q", *cluster, *instance)
+		return nil
+	}
+	container := containers[0]
+	report := &InstanceReport{
+		ContainerInstanceArn: aws.StringValue(container.ContainerInstanceArn),
+		EC2InstanceID:        aws.StringValue(container.Ec2InstanceId),
+		InstanceType:         getInstanceType(container.Attributes),
+		Status:               aws.StringValue(container.Status),
+		AgentConnected:       !isDisconnected(container),
+		RunningTasks:         int(aws.Int64Value(container.RunningTasksCount)),
+		PendingTasks:         int(aws.Int64Value(container.PendingTasksCount)),
+		Tasks:                sn.ListInstanceTasks(cluster, container.ContainerInstanceArn),
+	}
+	report.RegisteredCPU, report.RegisteredMemory = resources(container.RegisteredResources)
+	report.RemainingCPU, report.RemainingMemory = resources(container.RemainingResources)
+	report.CPU, report.Memory = sn.ContainerSize(cluster)
+	if report.CPU > 0 && report.Memory > 0 {
+		report.RegisteredSchedulable = ContainersPossible(report.CPU, report.Memory, container.RegisteredResources)
+		report.RemainingSchedulable = ContainersPossible(report.CPU, report.Memory, container.RemainingResources)
+	}
+	return report
+}
+
+// resources sums CPU Units and Memory (MiB) among ECS Resources.
+func resources(resources []*ecs.Resource) (cpu, memory int) {
+	for _, resource := range resources {
+		switch aws.StringValue(resource.Name) {
+		case "CPU":
+			cpu += int(aws.Int64Value(resource.IntegerValue))
+		case "MEMORY":
+			memory += int(aws.Int64Value(resource.IntegerValue))
+		}
+	}
+	return
+}
+
+// ListInstanceTasks lists ARNs of Tasks on a Container Instance of cluster.
+//
+// Requires IAM permission "ecs:ListTasks".
+func (sn *Snitcher) ListInstanceTasks(cluster, instance *string) (tasks []string) {
+	input := &ecs.ListTasksInput{
+		Cluster:           cluster,
+		ContainerInstance: instance,
+	}
+	err := sn.ECS.ListTasksPages(input, func(page *ecs.ListTasksOutput, last bool) bool {
+		tasks = append(tasks, aws.StringValueSlice(page.TaskArns)...)
+		return true
+	})
+	if err != nil {
+		sn.logger().Errorf("Failed to ListTasksPages for %q on %q: %s", *cluster, *instance, err)
+	}
+	return
+}
@@ -0,0 +1,43 @@
+package snitch
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestSnitcher_MeasureInstance(t *testing.T) {
+	fake := NewFakeECS(t)
+	fake.expectedContainerInstances[0].RunningTasksCount = aws.Int64(3)
+	sn := &Snitcher{ECS: fake, CPU: aws.Int(1024), Memory: aws.Int(1024)}
+	report := sn.MeasureInstance(fake.expectedCluster, aws.String(fake.expectedContainerInstanceArns[0]))
+	if report == nil {
+		t.Fatal("expected a report")
+	}
+	if !reflect.DeepEqual(report.Tasks, fake.expectedTaskArns) {
+		t.Errorf("expected Tasks %v but got %v", fake.expectedTaskArns, report.Tasks)
+	}
+	expected := InstanceReport{
+		InstanceType:          "fake.2xlarge",
+		AgentConnected:        true,
+		RegisteredCPU:         8192,
+		RegisteredMemory:      15468,
+		RemainingCPU:          8192 - fake.expectedCPU,
+		RemainingMemory:       15468 - fake.expectedMemory,
+		CPU:                   1024,
+		Memory:                1024,
+		RegisteredSchedulable: 8,
+		RemainingSchedulable:  5,
+		RunningTasks:          3,
+	}
+	report.Tasks = nil // Compared already.
+	if !reflect.DeepEqual(*report, expected) {
+		t.Errorf("expected %+v but got %+v", expected, *report)
+	}
+	fake.errorToReturn = errors.New("fake")
+	if report := sn.MeasureInstance(fake.expectedCluster, aws.String(fake.expectedContainerInstanceArns[0])); report != nil {
+		t.Errorf("expected no report on error but got %+v", report)
+	}
+}
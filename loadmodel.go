@@ -0,0 +1,78 @@
+package snitch
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+// Workload is a hypothetical set of ECS Services, like:
+//
+//	{"services": [{"name": "api", "cpu": 512, "memory": 1024, "count": 10}]}
+type Workload struct {
+	Services []*WorkloadService `json:"services"`
+}
+
+// WorkloadService is a hypothetical ECS Service of count Tasks, each needing
+// CPU Units and Memory (RAM in MiB).
+type WorkloadService struct {
+	Name   string `json:"name"`
+	CPU    int    `json:"cpu"`
+	Memory int    `json:"memory"`
+	Count  int    `json:"count"`
+}
+
+// Place figures out how many of each Service's Tasks fit, by name, into
+// Container Instances' remaining resources. Tasks are placed first-fit, in
+// order of Services, each taking room from the rest, like ECS would place the
+// whole Workload at once. Instances with disconnected agents take nothing.
+func (workload *Workload) Place(containers []*ecs.ContainerInstance) map[string]int {
+	type room struct{ cpu, memory int }
+	var rooms []*room
+	for _, container := range containers {
+		if isDisconnected(container) {
+			continue
+		}
+		r := &room{}
+		for _, resource := range container.RemainingResources {
+			switch aws.StringValue(resource.Name) {
+			case "CPU":
+				r.cpu = int(aws.Int64Value(resource.IntegerValue))
+			case "MEMORY":
+				r.memory = int(aws.Int64Value(resource.IntegerValue))
+			}
+		}
+		rooms = append(rooms, r)
+	}
+	placed := map[string]int{}
+	for _, service := range workload.Services {
+		for task := 0; task < service.Count; task++ {
+			for _, r := range rooms {
+				if r.cpu >= service.CPU && r.memory >= service.Memory {
+					r.cpu -= service.CPU
+					r.memory -= service.Memory
+					placed[service.Name]++
+					break
+				}
+			}
+		}
+	}
+	return placed
+}
+
+// Fits tells whether every Task of the Workload was placed.
+func (workload *Workload) Fits(placed map[string]int) bool {
+	for _, service := range workload.Services {
+		if placed[service.Name] < service.Count {
+			return false
+		}
+	}
+	return true
+}
+
+// PlaceWorkload figures out how many of each Workload Service's Tasks fit, by
+// name, into an ECS Cluster right now.
+func (sn *Snitcher) PlaceWorkload(cluster *string, workload *Workload) map[string]int {
+	placed := workload.Place(sn.DescribeContainerInstances(cluster, sn.ListContainerInstances(cluster)))
+	sn.logger().Infof("%q fits %v of Workload", *cluster, placed)
+	return placed
+}
@@ -0,0 +1,54 @@
+package snitch
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+// newRoom fakes a Container Instance with cpu and memory remaining.
+func newRoom(cpu, memory int64) *ecs.ContainerInstance {
+	return NewFakeContainerInstance(nil, []*ecs.Resource{
+		{Name: aws.String("CPU"), IntegerValue: aws.Int64(cpu)},
+		{Name: aws.String("MEMORY"), IntegerValue: aws.Int64(memory)},
+	})
+}
+
+func TestWorkload_Place(t *testing.T) {
+	workload := &Workload{Services: []*WorkloadService{
+		{Name: "api", CPU: 1024, Memory: 2048, Count: 3},
+		{Name: "worker", CPU: 512, Memory: 512, Count: 4},
+		{Name: "huge", CPU: 8192, Memory: 1024, Count: 1},
+	}}
+	disconnected := newRoom(4096, 8192)
+	disconnected.AgentConnected = aws.Bool(false)
+	placed := workload.Place([]*ecs.ContainerInstance{
+		newRoom(2048, 4096),
+		newRoom(2048, 4096),
+		disconnected,
+	})
+	for name, expected := range map[string]int{"api": 3, "worker": 2, "huge": 0} {
+		if placed[name] != expected {
+			t.Errorf("expected %d %s Tasks placed but got %d", expected, name, placed[name])
+		}
+	}
+	if workload.Fits(placed) {
+		t.Error("expected Workload not to fit")
+	}
+	small := &Workload{Services: []*WorkloadService{{Name: "api", CPU: 1024, Memory: 2048, Count: 2}}}
+	if !small.Fits(small.Place([]*ecs.ContainerInstance{newRoom(2048, 4096)})) {
+		t.Error("expected small Workload to fit")
+	}
+}
+
+func TestSnitcher_PlaceWorkload(t *testing.T) {
+	fake := NewFakeECS(t)
+	sn := &Snitcher{ECS: fake}
+	workload := &Workload{Services: []*WorkloadService{
+		{Name: "lcm", CPU: fake.expectedCPU, Memory: fake.expectedMemory, Count: 100},
+	}}
+	if placed := sn.PlaceWorkload(fake.expectedCluster, workload); placed["lcm"] != fake.expectedRemainingPossible {
+		t.Errorf("expected %d Tasks placed, like RemainingSchedulable, but got %d", fake.expectedRemainingPossible, placed["lcm"])
+	}
+}
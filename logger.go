@@ -0,0 +1,123 @@
+package snitch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// Logger logs what snitch does, by level, like zap's SugaredLogger. Package
+// functions without a Snitcher, like ValidateMetricData, log like the
+// standard library's log package.
+type Logger interface {
+	Debugf(format string, v ...interface{})
+	Infof(format string, v ...interface{})
+	Warnf(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
+}
+
+// defaultLogger logs every level with the standard library's log package,
+// like snitch always has.
+var defaultLogger Logger = stdLogger{}
+
+type stdLogger struct{}
+
+func (stdLogger) Debugf(format string, v ...interface{}) { log.Output(2, fmt.Sprintf(format, v...)) }
+func (stdLogger) Infof(format string, v ...interface{})  { log.Output(2, fmt.Sprintf(format, v...)) }
+func (stdLogger) Warnf(format string, v ...interface{})  { log.Output(2, fmt.Sprintf(format, v...)) }
+func (stdLogger) Errorf(format string, v ...interface{}) { log.Output(2, fmt.Sprintf(format, v...)) }
+
+// JSONLogger logs a JSON object per line to Writer, like
+// {"time":"2006-01-02T15:04:05Z","level":"error","message":"Failed to..."},
+// for log pipelines to route and filter.
+type JSONLogger struct {
+	Writer io.Writer
+	mutex  sync.Mutex
+}
+
+// jsonLine is a line JSONLogger logs.
+type jsonLine struct {
+	Time    string `json:"time"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+func (logger *JSONLogger) log(level, format string, v ...interface{}) {
+	line, err := json.Marshal(&jsonLine{
+		Time:    time.Now().UTC().Format(time.RFC3339Nano),
+		Level:   level,
+		Message: fmt.Sprintf(format, v...),
+	})
+	if err != nil {
+		return
+	}
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+	logger.Writer.Write(append(line, '\n'))
+}
+
+func (logger *JSONLogger) Debugf(format string, v ...interface{}) { logger.log("debug", format, v...) }
+func (logger *JSONLogger) Infof(format string, v ...interface{})  { logger.log("info", format, v...) }
+func (logger *JSONLogger) Warnf(format string, v ...interface{})  { logger.log("warn", format, v...) }
+func (logger *JSONLogger) Errorf(format string, v ...interface{}) { logger.log("error", format, v...) }
+
+// logLevels are LogLevels, from most to least verbose.
+var logLevels = map[string]int{"debug": 0, "info": 1, "warn": 2, "error": 3}
+
+// leveledLogger logs to Logger only what's at least as severe as level.
+type leveledLogger struct {
+	Logger
+	level int
+}
+
+func (logger leveledLogger) Debugf(format string, v ...interface{}) {
+	if logger.level <= logLevels["debug"] {
+		logger.Logger.Debugf(format, v...)
+	}
+}
+
+func (logger leveledLogger) Infof(format string, v ...interface{}) {
+	if logger.level <= logLevels["info"] {
+		logger.Logger.Infof(format, v...)
+	}
+}
+
+func (logger leveledLogger) Warnf(format string, v ...interface{}) {
+	if logger.level <= logLevels["warn"] {
+		logger.Logger.Warnf(format, v...)
+	}
+}
+
+func (logger leveledLogger) Errorf(format string, v ...interface{}) {
+	logger.Logger.Errorf(format, v...)
+}
+
+// logger is Logger, or else, by LogFormat, a JSONLogger to stderr or the
+// standard library's log package, logging only what's at least LogLevel, by
+// default "info". Logger, if set, logs everything unless LogLevel is set.
+func (sn *Snitcher) logger() Logger {
+	if sn.Logger != nil && sn.LogLevel == nil {
+		return sn.Logger
+	}
+	var logger Logger = defaultLogger
+	if sn.Logger != nil {
+		logger = sn.Logger
+	} else if aws.StringValue(sn.LogFormat) == "json" {
+		logger = stderrJSON
+	}
+	level, ok := logLevels[aws.StringValue(sn.LogLevel)]
+	if !ok {
+		level = logLevels["info"]
+	}
+	return leveledLogger{Logger: logger, level: level}
+}
+
+// stderrJSON is the JSONLogger of LogFormat "json", shared so lines from
+// concurrent measurements don't interleave.
+var stderrJSON = &JSONLogger{Writer: os.Stderr}
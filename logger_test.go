@@ -0,0 +1,127 @@
+package snitch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// RecordingLogger records what's logged, by level, like "error: Failed to...".
+type RecordingLogger struct {
+	mutex sync.Mutex
+	lines []string
+}
+
+func (logger *RecordingLogger) record(level, format string, v ...interface{}) {
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+	logger.lines = append(logger.lines, level+": "+fmt.Sprintf(format, v...))
+}
+
+func (logger *RecordingLogger) Debugf(format string, v ...interface{}) {
+	logger.record("debug", format, v...)
+}
+func (logger *RecordingLogger) Infof(format string, v ...interface{}) {
+	logger.record("info", format, v...)
+}
+func (logger *RecordingLogger) Warnf(format string, v ...interface{}) {
+	logger.record("warn", format, v...)
+}
+func (logger *RecordingLogger) Errorf(format string, v ...interface{}) {
+	logger.record("error", format, v...)
+}
+
+func TestJSONLogger(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := &JSONLogger{Writer: &buffer}
+	logger.Errorf("Failed to %s", "test")
+	logger.Infof("Published %d metrics", 2)
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines but got %q", buffer.String())
+	}
+	var line jsonLine
+	if err := json.Unmarshal([]byte(lines[0]), &line); err != nil {
+		t.Fatal(err)
+	}
+	if line.Level != "error" || line.Message != "Failed to test" || line.Time == "" {
+		t.Errorf("expected an error line but got %+v", line)
+	}
+}
+
+func TestSnitcher_logger(t *testing.T) {
+	logger := &RecordingLogger{}
+	tests := []struct {
+		sn       *Snitcher
+		expected Logger
+	}{
+		{&Snitcher{}, leveledLogger{defaultLogger, 1}},
+		{&Snitcher{LogFormat: aws.String("text"), LogLevel: aws.String("debug")}, leveledLogger{defaultLogger, 0}},
+		{&Snitcher{LogFormat: aws.String("json"), LogLevel: aws.String("bogus")}, leveledLogger{stderrJSON, 1}},
+		{&Snitcher{Logger: logger, LogFormat: aws.String("json")}, logger},
+		{&Snitcher{Logger: logger, LogLevel: aws.String("error")}, leveledLogger{logger, 3}},
+	}
+	for _, test := range tests {
+		if actual := test.sn.logger(); actual != test.expected {
+			t.Errorf("expected %+v but got %+v", test.expected, actual)
+		}
+	}
+}
+
+func TestSnitcher_LogLevel(t *testing.T) {
+	tests := map[string][]string{
+		"debug": {"debug: d", "info: i", "warn: w", "error: e"},
+		"info":  {"info: i", "warn: w", "error: e"},
+		"":      {"info: i", "warn: w", "error: e"},
+		"warn":  {"warn: w", "error: e"},
+		"error": {"error: e"},
+	}
+	for level, expected := range tests {
+		logger := &RecordingLogger{}
+		sn := &Snitcher{Logger: logger, LogLevel: aws.String(level)}
+		sn.logger().Debugf("d")
+		sn.logger().Infof("i")
+		sn.logger().Warnf("w")
+		sn.logger().Errorf("e")
+		if strings.Join(logger.lines, ",") != strings.Join(expected, ",") {
+			t.Errorf("%q: expected %q but got %q", level, expected, logger.lines)
+		}
+	}
+}
+
+func TestSnitcher_publish_debug(t *testing.T) {
+	logger := &RecordingLogger{}
+	sn := &Snitcher{CloudWatch: &FakeCloudWatch{}, Logger: logger, LogLevel: aws.String("info")}
+	metricData := (&ClusterResources{Cluster: aws.String("c"), Totals: map[string]float64{"PendingTasks": 2}}).ToMetricData()
+	if err := sn.publish(aws.String("ECS/Snitch"), metricData); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range logger.lines {
+		if strings.Contains(line, "MetricData") {
+			t.Errorf("expected no metrics dumped at info but got %q", line)
+		}
+	}
+	sn.LogLevel = aws.String("debug")
+	logger.lines = nil
+	sn.publish(aws.String("ECS/Snitch"), metricData)
+	if last := logger.lines[len(logger.lines)-1]; !strings.HasPrefix(last, "debug: Published metrics:") {
+		t.Errorf("expected published metrics at debug but got %q", last)
+	}
+}
+
+func TestSnitcher_Logger(t *testing.T) {
+	fake := NewFakeECS(t)
+	fake.errorToReturn = fmt.Errorf("no")
+	logger := &RecordingLogger{}
+	sn := &Snitcher{ECS: fake, Logger: logger}
+	for range sn.DiscoverClusters() {
+	}
+	if len(logger.lines) != 1 || !strings.HasPrefix(logger.lines[0], "error: Failed to ListClustersPages!") {
+		t.Errorf("expected a logged error but got %q", logger.lines)
+	}
+}
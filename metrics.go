@@ -0,0 +1,104 @@
+package snitch
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// Metric describes a metric snitch can publish: the contract dashboards and
+// alarms are built against.
+type Metric struct {
+	Name        string
+	Unit        string
+	Dimensions  []string
+	Description string
+}
+
+var (
+	clusterDimensions      = []string{"ClusterName"}
+	instanceTypeDimensions = []string{"ClusterName", "InstanceType"}
+	subnetDimensions       = []string{"ClusterName", "SubnetId"}
+	serviceDimensions      = []string{"Service"}
+)
+
+// Metrics registers every metric snitch can publish. Renaming or removing one
+// breaks somebody's alarms, so do it here, deliberately, and TestMetrics will
+// remind you.
+var Metrics = []*Metric{
+	{"APICalls", "Count", serviceDimensions, "Calls, including retries, snitch made to an AWS service in a run, published to TelemetryNamespace."},
+	{"ActiveInstanceCount", "Count", instanceTypeDimensions, "ACTIVE Container Instances."},
+	{"Canary", "Count", nil, "Always 1, published to a separate namespace every run snitch publishes."},
+	{"ClusterFullPercent", "Percent", clusterDimensions, "How full the cluster is: 100*(1 - RemainingSchedulable/RegisteredSchedulable)."},
+	{"ClustersMeasured", "Count", nil, "Clusters snitch measured in a run, published to TelemetryNamespace."},
+	{"ClustersThrottled", "Count", nil, "Clusters not published since ECS throttled measuring them, even after retrying, published to TelemetryNamespace."},
+	{"DisconnectedAgents", "Count", clusterDimensions, "Container Instances whose ECS agent is disconnected, whose capacity isn't RemainingSchedulable."},
+	{"Failures", "Count", nil, "Failures, like calls to AWS, in a run, published to TelemetryNamespace."},
+	{"LowestCommonMultipleCPU", "None", instanceTypeDimensions, "CPU Units of the container size measured against."},
+	{"LowestCommonMultipleMemory", "Megabytes", instanceTypeDimensions, "Memory (MiB) of the container size measured against."},
+	{"MetricsPublished", "Count", nil, "Metrics snitch published in a run, published to TelemetryNamespace."},
+	{"PendingTasks", "Count", clusterDimensions, "Tasks provisioning or waiting to start."},
+	{"PublishFailures", "Count", nil, "Failures publishing to CloudWatch or sinks in a run, published to TelemetryNamespace."},
+	{"RegisteredSchedulable", "Count", instanceTypeDimensions, "Containers that fit in registered capacity."},
+	{"RemainingSchedulable", "Count", instanceTypeDimensions, "Containers that fit in remaining capacity."},
+	{"RemainingSchedulableAwsvpc", "Count", subnetDimensions, "Containers using \"awsvpc\" networking that fit in remaining capacity and free IPs."},
+	{"RunDuration", "Seconds", nil, "How long a run took, published to TelemetryNamespace."},
+	{"RunningTaskSlots", "Count", instanceTypeDimensions, "Containers' worth of capacity occupied by running Tasks."},
+	{"RunningTasks", "Count", instanceTypeDimensions, "Running Tasks."},
+	{"ScheduledScalingShortfall", "Count", clusterDimensions, "Containers the worst upcoming scheduled scale-out lacks room for."},
+	{"SubnetAvailableIPs", "Count", subnetDimensions, "Free IP addresses in Container Instances' subnet."},
+	{"Throttles", "Count", serviceDimensions, "Calls to an AWS service throttled in a run, published to TelemetryNamespace."},
+}
+
+// LookupMetric finds a registered Metric by name, or nil if there's none.
+func LookupMetric(name string) *Metric {
+	for _, metric := range Metrics {
+		if metric.Name == name {
+			return metric
+		}
+	}
+	return nil
+}
+
+// Validate checks datum matches the registered Metric's unit and has its
+// dimensions. Extra dimensions, like DimensionAttributes, are fine, and
+// StatisticSets, aggregated across instance types, lack "InstanceType".
+func (metric *Metric) Validate(datum *cloudwatch.MetricDatum) error {
+	if unit := aws.StringValue(datum.Unit); unit != metric.Unit {
+		return fmt.Errorf("%s has unit %q instead of %q", metric.Name, unit, metric.Unit)
+	}
+	names := map[string]bool{}
+	for _, dimension := range datum.Dimensions {
+		names[aws.StringValue(dimension.Name)] = true
+	}
+	for _, name := range metric.Dimensions {
+		if !names[name] && !(name == "InstanceType" && datum.StatisticValues != nil) {
+			return fmt.Errorf("%s lacks dimension %q", metric.Name, name)
+		}
+	}
+	return nil
+}
+
+// ValidateMetricData keeps only data points matching registered Metrics,
+// logging the rest.
+func ValidateMetricData(metricData []*cloudwatch.MetricDatum) []*cloudwatch.MetricDatum {
+	return validateMetricData(defaultLogger, metricData)
+}
+
+// validateMetricData is ValidateMetricData logging to logger.
+func validateMetricData(logger Logger, metricData []*cloudwatch.MetricDatum) (valid []*cloudwatch.MetricDatum) {
+	for _, datum := range metricData {
+		metric := LookupMetric(aws.StringValue(datum.MetricName))
+		if metric == nil {
+			logger.Warnf("Dropping unregistered metric %q", aws.StringValue(datum.MetricName))
+			continue
+		}
+		if err := metric.Validate(datum); err != nil {
+			logger.Warnf("Dropping invalid metric: %s", err)
+			continue
+		}
+		valid = append(valid, datum)
+	}
+	return
+}
@@ -0,0 +1,92 @@
+package snitch
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// TestMetrics guards against accidental renames: if you meant to change this
+// list, people's dashboards and alarms need changing too.
+func TestMetrics(t *testing.T) {
+	expected := []string{
+		"APICalls",
+		"ActiveInstanceCount",
+		"Canary",
+		"ClusterFullPercent",
+		"ClustersMeasured",
+		"ClustersThrottled",
+		"DisconnectedAgents",
+		"Failures",
+		"LowestCommonMultipleCPU",
+		"LowestCommonMultipleMemory",
+		"MetricsPublished",
+		"PendingTasks",
+		"PublishFailures",
+		"RegisteredSchedulable",
+		"RemainingSchedulable",
+		"RemainingSchedulableAwsvpc",
+		"RunDuration",
+		"RunningTaskSlots",
+		"RunningTasks",
+		"ScheduledScalingShortfall",
+		"SubnetAvailableIPs",
+		"Throttles",
+	}
+	if len(Metrics) != len(expected) {
+		t.Fatalf("expected %d registered metrics but got %d", len(expected), len(Metrics))
+	}
+	for index, name := range expected {
+		if Metrics[index].Name != name {
+			t.Errorf("expected metric %q at %d but got %q", name, index, Metrics[index].Name)
+		}
+		if Metrics[index].Description == "" {
+			t.Errorf("expected %q to be described", name)
+		}
+	}
+}
+
+// TestMetrics_ToMetricData ensures everything ClusterResources formats is
+// registered and valid.
+func TestMetrics_ToMetricData(t *testing.T) {
+	cr := NewClusterResources(aws.String("registered-cluster"))
+	for _, counts := range cr.Resources {
+		counts["fake.2xlarge"] = 1
+	}
+	cr.Totals["ClusterFullPercent"] = 50
+	cr.Totals["DisconnectedAgents"] = 1
+	cr.Totals["PendingTasks"] = 1
+	cr.Totals["ScheduledScalingShortfall"] = 1
+	cr.Subnets["RemainingSchedulableAwsvpc"] = map[string]int{"subnet-1": 1}
+	cr.Subnets["SubnetAvailableIPs"] = map[string]int{"subnet-1": 1}
+	metricData := cr.ToMetricData()
+	if valid := ValidateMetricData(metricData); len(valid) != len(metricData) {
+		t.Errorf("expected all %d data points to be valid but got %d", len(metricData), len(valid))
+	}
+}
+
+func TestLookupMetric(t *testing.T) {
+	if metric := LookupMetric("RemainingSchedulable"); metric == nil || metric.Unit != "Count" {
+		t.Errorf("expected RemainingSchedulable Count but got %+v", metric)
+	}
+	if metric := LookupMetric("NotAMetric"); metric != nil {
+		t.Errorf("expected nil but got %+v", metric)
+	}
+}
+
+func TestValidateMetricData(t *testing.T) {
+	cluster := &cloudwatch.Dimension{Name: aws.String("ClusterName"), Value: aws.String("c")}
+	instanceType := &cloudwatch.Dimension{Name: aws.String("InstanceType"), Value: aws.String("i")}
+	stack := &cloudwatch.Dimension{Name: aws.String("stack"), Value: aws.String("s")}
+	metricData := []*cloudwatch.MetricDatum{
+		{MetricName: aws.String("RemainingSchedulable"), Unit: aws.String("Count"), Dimensions: []*cloudwatch.Dimension{instanceType, cluster}},
+		{MetricName: aws.String("RemainingSchedulable"), Unit: aws.String("Count"), Dimensions: []*cloudwatch.Dimension{cluster}},
+		{MetricName: aws.String("RemainingSchedulable"), Unit: aws.String("Percent"), Dimensions: []*cloudwatch.Dimension{cluster, instanceType}},
+		{MetricName: aws.String("RenamedSchedulable"), Unit: aws.String("Count"), Dimensions: []*cloudwatch.Dimension{cluster, instanceType}},
+		{MetricName: aws.String("RemainingSchedulable"), Unit: aws.String("Count"), Dimensions: []*cloudwatch.Dimension{cluster, instanceType, stack}},
+	}
+	if valid := ValidateMetricData(metricData); len(valid) != 2 || valid[0] != metricData[0] || valid[1] != metricData[4] {
+		t.Errorf("expected only first and last data points to be valid but got %v", valid)
+	}
+}
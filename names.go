@@ -0,0 +1,49 @@
+package snitch
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// publishedName is metricName as published: renamed by MetricNames, if it's
+// there, and prefixed by MetricPrefix.
+func (sn *Snitcher) publishedName(metricName string) string {
+	if renamed, ok := sn.MetricNames[metricName]; ok {
+		metricName = renamed
+	}
+	return aws.StringValue(sn.MetricPrefix) + metricName
+}
+
+// renamed copies metricData with their published names, leaving metricData,
+// which sinks may share, as it is.
+func (sn *Snitcher) renamed(metricData []*cloudwatch.MetricDatum) []*cloudwatch.MetricDatum {
+	if len(sn.MetricNames) == 0 && aws.StringValue(sn.MetricPrefix) == "" {
+		return metricData
+	}
+	renamed := make([]*cloudwatch.MetricDatum, len(metricData))
+	for i, datum := range metricData {
+		copied := *datum
+		copied.MetricName = aws.String(sn.publishedName(aws.StringValue(datum.MetricName)))
+		renamed[i] = &copied
+	}
+	return renamed
+}
+
+// countUnits copies metricData with the units of LowestCommonMultipleCPU and
+// LowestCommonMultipleMemory back to "Count", as published before metrics had
+// their own units, leaving metricData, which sinks may share, as it is. Other
+// metrics, like ClusterFullPercent, were never Counts, so keep theirs.
+func countUnits(metricData []*cloudwatch.MetricDatum) []*cloudwatch.MetricDatum {
+	counted := make([]*cloudwatch.MetricDatum, len(metricData))
+	for i, datum := range metricData {
+		switch aws.StringValue(datum.MetricName) {
+		case "LowestCommonMultipleCPU", "LowestCommonMultipleMemory":
+			copied := *datum
+			copied.Unit = aws.String("Count")
+			counted[i] = &copied
+		default:
+			counted[i] = datum
+		}
+	}
+	return counted
+}
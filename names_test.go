@@ -0,0 +1,84 @@
+package snitch
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+func TestSnitcher_publishedName(t *testing.T) {
+	sn := &Snitcher{MetricNames: map[string]string{"RemainingSchedulable": "remaining_slots"}, MetricPrefix: aws.String("ecs.capacity.")}
+	tests := map[string]string{
+		"RemainingSchedulable":  "ecs.capacity.remaining_slots",
+		"RegisteredSchedulable": "ecs.capacity.RegisteredSchedulable",
+	}
+	for metricName, expected := range tests {
+		if actual := sn.publishedName(metricName); actual != expected {
+			t.Errorf("expected %q published as %q but got %q", metricName, expected, actual)
+		}
+	}
+}
+
+func TestSnitcher_publishRenamed(t *testing.T) {
+	cr := NewClusterResources(aws.String("cluster"))
+	cr.Remaining["c5.large"] = 2
+	metricData := cr.ToMetricData()
+	cloudWatch := &FakeCloudWatch{}
+	sn := &Snitcher{CloudWatch: cloudWatch, Namespace: aws.String("Testable/Namespace"), MetricNames: map[string]string{"RemainingSchedulable": "ecs.capacity.remaining_slots"}}
+	if err := sn.publish(sn.Namespace, metricData); err != nil {
+		t.Fatal(err)
+	}
+	published := cloudWatch.payload[0].MetricData
+	if len(published) != 1 || aws.StringValue(published[0].MetricName) != "ecs.capacity.remaining_slots" {
+		t.Errorf("expected RemainingSchedulable published renamed but got %v", published)
+	}
+	if aws.StringValue(metricData[0].MetricName) != "RemainingSchedulable" {
+		t.Errorf("expected measured metrics left as they were but got %v", metricData[0])
+	}
+	if renamed := (&Snitcher{}).renamed(metricData); &renamed[0] != &metricData[0] {
+		t.Error("expected metrics not copied without customized names")
+	}
+	var none []*cloudwatch.MetricDatum
+	if renamed := sn.renamed(none); len(renamed) != 0 {
+		t.Errorf("expected nothing renamed but got %v", renamed)
+	}
+}
+
+func TestSnitcher_publishCountUnits(t *testing.T) {
+	cr := NewClusterResources(aws.String("cluster"))
+	cr.Memory["c5.large"] = 1024
+	cr.Registered["c5.large"] = 4
+	cr.Remaining["c5.large"] = 1
+	cr.Totals["ClusterFullPercent"] = cr.FullPercent()
+	metricData := cr.ToMetricData()
+	units := map[string]string{}
+	for _, datum := range metricData {
+		units[aws.StringValue(datum.MetricName)] = aws.StringValue(datum.Unit)
+	}
+	if units["LowestCommonMultipleMemory"] != "Megabytes" || units["ClusterFullPercent"] != "Percent" {
+		t.Fatalf("expected LowestCommonMultipleMemory in Megabytes and ClusterFullPercent in Percent but got %v", units)
+	}
+	cloudWatch := &FakeCloudWatch{}
+	sn := &Snitcher{CloudWatch: cloudWatch, Namespace: aws.String("Testable/Namespace"), CountUnits: aws.Bool(true), MetricPrefix: aws.String("ECS")}
+	if err := sn.publish(sn.Namespace, metricData); err != nil {
+		t.Fatal(err)
+	}
+	published := map[string]string{}
+	for _, input := range cloudWatch.payload {
+		for _, datum := range input.MetricData {
+			published[aws.StringValue(datum.MetricName)] = aws.StringValue(datum.Unit)
+		}
+	}
+	if published["ECSLowestCommonMultipleMemory"] != "Count" {
+		t.Errorf("expected LowestCommonMultipleMemory published as a Count but got %v", published)
+	}
+	if published["ECSClusterFullPercent"] != "Percent" || published["ECSRemainingSchedulable"] != "Count" {
+		t.Errorf("expected other metrics published in their units but got %v", published)
+	}
+	for _, datum := range metricData {
+		if aws.StringValue(datum.Unit) != units[aws.StringValue(datum.MetricName)] {
+			t.Errorf("expected measured metrics left as they were but got %v", datum)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package snitch
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// placeholder is a part of a namespace template, like "{cluster}" or
+// "{team}", resolved per metric.
+var placeholder = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// resolveNamespace resolves template's placeholders for datum: "{cluster}" to
+// its cluster's name, and any other, like "{team}", to its dimension of that
+// name, like of DimensionTags. It's false if datum lacks any.
+func resolveNamespace(template string, datum *cloudwatch.MetricDatum) (namespace string, ok bool) {
+	ok = true
+	namespace = placeholder.ReplaceAllStringFunc(template, func(match string) string {
+		name := match[1 : len(match)-1]
+		if name == "cluster" {
+			name = "ClusterName"
+		}
+		for _, dimension := range datum.Dimensions {
+			if aws.StringValue(dimension.Name) == name {
+				return aws.StringValue(dimension.Value)
+			}
+		}
+		ok = false
+		return match
+	})
+	return
+}
+
+// publishTemplated publishes metricData to the namespaces template resolves
+// to for each of them, like "ECS/{cluster}", returning the last error, if
+// any. Metrics it can't be resolved for are dropped.
+func (sn *Snitcher) publishTemplated(template string, metricData []*cloudwatch.MetricDatum) (err error) {
+	var namespaces []string
+	byNamespace := map[string][]*cloudwatch.MetricDatum{}
+	for _, datum := range metricData {
+		namespace, ok := resolveNamespace(template, datum)
+		if !ok {
+			sn.logger().Warnf("Dropping metric %q lacking dimensions of namespace %q", aws.StringValue(datum.MetricName), template)
+			continue
+		}
+		if _, seen := byNamespace[namespace]; !seen {
+			namespaces = append(namespaces, namespace)
+		}
+		byNamespace[namespace] = append(byNamespace[namespace], datum)
+	}
+	for _, namespace := range namespaces {
+		if publishErr := sn.publishTo(aws.String(namespace), byNamespace[namespace]); publishErr != nil {
+			err = publishErr
+		}
+	}
+	return
+}
+
+// isTemplate tells whether namespace has placeholders to resolve per metric.
+func isTemplate(namespace *string) bool {
+	return strings.Contains(aws.StringValue(namespace), "{")
+}
@@ -0,0 +1,57 @@
+package snitch
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+func TestResolveNamespace(t *testing.T) {
+	datum := &cloudwatch.MetricDatum{Dimensions: []*cloudwatch.Dimension{
+		{Name: aws.String("ClusterName"), Value: aws.String("prod")},
+		{Name: aws.String("team"), Value: aws.String("capacity")},
+	}}
+	tests := []struct {
+		template, expected string
+		ok                 bool
+	}{
+		{"ECS/{cluster}", "ECS/prod", true},
+		{"{team}/ECS", "capacity/ECS", true},
+		{"{team}/{cluster}", "capacity/prod", true},
+		{"ECS/Snitch", "ECS/Snitch", true},
+		{"{stack}/ECS", "{stack}/ECS", false},
+	}
+	for _, test := range tests {
+		if namespace, ok := resolveNamespace(test.template, datum); namespace != test.expected || ok != test.ok {
+			t.Errorf("expected %q to resolve to %q, %t but got %q, %t", test.template, test.expected, test.ok, namespace, ok)
+		}
+	}
+}
+
+func TestSnitcher_publishTemplated(t *testing.T) {
+	var metricData []*cloudwatch.MetricDatum
+	for _, cluster := range []string{"a", "b", "a"} {
+		cr := NewClusterResources(aws.String(cluster))
+		cr.Totals["PendingTasks"] = 1
+		metricData = append(metricData, cr.ToMetricData()...)
+	}
+	cloudWatch := &FakeCloudWatch{}
+	sn := &Snitcher{CloudWatch: cloudWatch, Namespace: aws.String("ECS/{cluster}")}
+	if err := sn.publish(sn.Namespace, metricData); err != nil {
+		t.Fatal(err)
+	}
+	if len(cloudWatch.payload) != 2 {
+		t.Fatalf("expected a batch per cluster but got %v", cloudWatch.payload)
+	}
+	if aws.StringValue(cloudWatch.payload[0].Namespace) != "ECS/a" || len(cloudWatch.payload[0].MetricData) != 2 || aws.StringValue(cloudWatch.payload[1].Namespace) != "ECS/b" {
+		t.Errorf("expected cluster a's metrics in ECS/a and b's in ECS/b but got %v", cloudWatch.payload)
+	}
+
+	cloudWatch.payload = nil
+	sn.Namespace = aws.String("{team}/ECS")
+	sn.publish(sn.Namespace, metricData)
+	if len(cloudWatch.payload) != 0 {
+		t.Errorf("expected metrics lacking team dropped but got %v", cloudWatch.payload)
+	}
+}
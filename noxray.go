@@ -0,0 +1,28 @@
+//go:build !xray
+// +build !xray
+
+package snitch
+
+import (
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+)
+
+// untraced warns, once, that Trace is set in a build that can't trace.
+var untraced sync.Once
+
+// instrument does nothing, since snitch is built without AWS X-Ray, which
+// build tag "xray" builds in.
+func (sn *Snitcher) instrument(c *client.Client) {}
+
+// trace just runs fn, since snitch is built without AWS X-Ray.
+func (sn *Snitcher) trace(name string, fn func(sn *Snitcher) error) error {
+	if aws.BoolValue(sn.Trace) {
+		untraced.Do(func() {
+			sn.logger().Warnf("Not tracing, since snitch is built without tag \"xray\"")
+		})
+	}
+	return fn(sn)
+}
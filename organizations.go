@@ -0,0 +1,77 @@
+package snitch
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/organizations"
+)
+
+// DiscoverAccountRoles lists ARNs of OrganizationRole in every ACTIVE account
+// of the organization, or only those directly in OrganizationalUnit, if set,
+// and tagged with all of AccountTags.
+//
+// Requires IAM permissions "organizations:ListAccounts" (or
+// "organizations:ListAccountsForParent") and, with AccountTags,
+// "organizations:ListTagsForResource".
+func (sn *Snitcher) DiscoverAccountRoles() (roles []string) {
+	var accounts []*organizations.Account
+	var err error
+	if parent := aws.StringValue(sn.OrganizationalUnit); parent != "" {
+		err = sn.Organizations.ListAccountsForParentPages(
+			&organizations.ListAccountsForParentInput{ParentId: sn.OrganizationalUnit},
+			func(page *organizations.ListAccountsForParentOutput, last bool) bool {
+				accounts = append(accounts, page.Accounts...)
+				return true
+			},
+		)
+	} else {
+		err = sn.Organizations.ListAccountsPages(
+			&organizations.ListAccountsInput{},
+			func(page *organizations.ListAccountsOutput, last bool) bool {
+				accounts = append(accounts, page.Accounts...)
+				return true
+			},
+		)
+	}
+	if err != nil {
+		sn.logger().Errorf("Failed to list accounts! %s", err)
+		return
+	}
+	for _, account := range accounts {
+		if aws.StringValue(account.Status) != organizations.AccountStatusActive || !sn.accountTagged(account.Id) {
+			continue
+		}
+		roles = append(roles, fmt.Sprintf("arn:aws:iam::%s:role/%s", aws.StringValue(account.Id), aws.StringValue(sn.OrganizationRole)))
+	}
+	sn.logger().Infof("Discovered %d accounts to measure", len(roles))
+	return
+}
+
+// accountTagged tells whether an account has all of AccountTags. Accounts
+// whose tags can't be read don't.
+func (sn *Snitcher) accountTagged(account *string) bool {
+	if len(sn.AccountTags) == 0 {
+		return true
+	}
+	tags := map[string]string{}
+	err := sn.Organizations.ListTagsForResourcePages(
+		&organizations.ListTagsForResourceInput{ResourceId: account},
+		func(page *organizations.ListTagsForResourceOutput, last bool) bool {
+			for _, tag := range page.Tags {
+				tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+			}
+			return true
+		},
+	)
+	if err != nil {
+		sn.logger().Errorf("Failed to ListTagsForResource of account %q: %s", *account, err)
+		return false
+	}
+	for key, value := range sn.AccountTags {
+		if tags[key] != value {
+			return false
+		}
+	}
+	return true
+}
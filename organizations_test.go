@@ -0,0 +1,107 @@
+package snitch
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/aws/aws-sdk-go/service/organizations/organizationsiface"
+)
+
+// FakeOrganizations mocks AWS Organizations for testing.
+type FakeOrganizations struct {
+	organizationsiface.OrganizationsAPI
+	errorToReturn    error                        // `error` to return from fake methods.
+	expectedAccounts []*organizations.Account     // Expected accounts of the organization.
+	expectedParents  map[string]string            // Expected OU by account ID.
+	expectedTags     map[string]map[string]string // Expected tags by account ID.
+}
+
+func (fake *FakeOrganizations) ListAccountsPages(input *organizations.ListAccountsInput, pager func(*organizations.ListAccountsOutput, bool) bool) error {
+	// Organizations may return an empty page before the last.
+	if fake.errorToReturn == nil && pager(&organizations.ListAccountsOutput{}, false) {
+		pager(&organizations.ListAccountsOutput{Accounts: fake.expectedAccounts}, true)
+	}
+	return fake.errorToReturn
+}
+
+func (fake *FakeOrganizations) ListAccountsForParentPages(input *organizations.ListAccountsForParentInput, pager func(*organizations.ListAccountsForParentOutput, bool) bool) error {
+	output := &organizations.ListAccountsForParentOutput{}
+	for _, account := range fake.expectedAccounts {
+		if fake.expectedParents[*account.Id] == *input.ParentId {
+			output.Accounts = append(output.Accounts, account)
+		}
+	}
+	if fake.errorToReturn == nil && pager(&organizations.ListAccountsForParentOutput{}, false) {
+		pager(output, true)
+	}
+	return fake.errorToReturn
+}
+
+func (fake *FakeOrganizations) ListTagsForResourcePages(input *organizations.ListTagsForResourceInput, pager func(*organizations.ListTagsForResourceOutput, bool) bool) error {
+	output := &organizations.ListTagsForResourceOutput{}
+	for key, value := range fake.expectedTags[*input.ResourceId] {
+		output.Tags = append(output.Tags, &organizations.Tag{Key: aws.String(key), Value: aws.String(value)})
+	}
+	if pager(&organizations.ListTagsForResourceOutput{}, false) {
+		pager(output, true)
+	}
+	return nil
+}
+
+func NewFakeOrganizations() *FakeOrganizations {
+	return &FakeOrganizations{
+		expectedAccounts: []*organizations.Account{
+			{Id: aws.String("111111111111"), Status: aws.String("ACTIVE")},
+			{Id: aws.String("222222222222"), Status: aws.String("ACTIVE")},
+			{Id: aws.String("333333333333"), Status: aws.String("SUSPENDED")},
+		},
+		expectedParents: map[string]string{
+			"111111111111": "ou-prod",
+			"222222222222": "ou-dev",
+			"333333333333": "ou-prod",
+		},
+		expectedTags: map[string]map[string]string{
+			"222222222222": {"snitch": "enabled"},
+		},
+	}
+}
+
+func TestSnitcher_DiscoverAccountRoles(t *testing.T) {
+	for _, test := range []struct {
+		unit     string
+		tags     map[string]string
+		expected []string
+	}{
+		{"", nil, []string{"arn:aws:iam::111111111111:role/snitch", "arn:aws:iam::222222222222:role/snitch"}},
+		{"ou-prod", nil, []string{"arn:aws:iam::111111111111:role/snitch"}},
+		{"", map[string]string{"snitch": "enabled"}, []string{"arn:aws:iam::222222222222:role/snitch"}},
+	} {
+		sn := &Snitcher{
+			Organizations:      NewFakeOrganizations(),
+			OrganizationRole:   aws.String("snitch"),
+			OrganizationalUnit: aws.String(test.unit),
+			AccountTags:        test.tags,
+		}
+		roles := sn.DiscoverAccountRoles()
+		if len(roles) != len(test.expected) {
+			t.Errorf("expected %v but got %v", test.expected, roles)
+			continue
+		}
+		for index, role := range roles {
+			if role != test.expected[index] {
+				t.Errorf("expected %v but got %v", test.expected, roles)
+			}
+		}
+	}
+}
+
+func TestSnitcher_DiscoverAccountRolesError(t *testing.T) {
+	fake := NewFakeOrganizations()
+	fake.errorToReturn = errors.New("there should be no roles on error")
+	sn := &Snitcher{Organizations: fake, OrganizationRole: aws.String("snitch")}
+	if roles := sn.DiscoverAccountRoles(); len(roles) != 0 {
+		t.Error(fake.errorToReturn)
+	}
+}
@@ -0,0 +1,174 @@
+package snitch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// OTLPSink exports metrics to an OpenTelemetry collector as gauges, in OTLP's
+// JSON encoding over HTTP, with dimensions as attributes like "cluster" and
+// "instance_type".
+//
+// BUG(shatil): OTLPSink doesn't speak OTLP over gRPC, which would need gRPC and
+// OpenTelemetry's protobufs as dependencies. Collectors take HTTP on 4318.
+type OTLPSink struct {
+	// URL of the collector, like "http://localhost:4318", to which
+	// "/v1/metrics" is added.
+	URL string
+	// Headers sent with every export, like "Authorization".
+	Headers map[string]string
+	// Name of the "service.name" resource attribute.
+	ServiceName string
+	Client      *http.Client
+}
+
+// NewOTLPSink configures an OTLPSink with config's URL, or else that of
+// environment variable OTEL_EXPORTER_OTLP_ENDPOINT, or else a local
+// collector's. Headers come from OTEL_EXPORTER_OTLP_HEADERS, like
+// "api-key=secret,team=capacity". Namespace, if set, names the service.
+func NewOTLPSink(config *SinkConfig) *OTLPSink {
+	sink := &OTLPSink{
+		URL:         config.URL,
+		Headers:     map[string]string{},
+		ServiceName: config.Namespace,
+		Client:      config.client(),
+	}
+	if sink.URL == "" {
+		sink.URL = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	}
+	if sink.URL == "" {
+		sink.URL = "http://localhost:4318"
+	}
+	if sink.ServiceName == "" {
+		sink.ServiceName = "snitch"
+	}
+	for _, pair := range strings.Split(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), ",") {
+		if parts := strings.SplitN(pair, "=", 2); len(parts) == 2 {
+			sink.Headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+	return sink
+}
+
+// OTLP's JSON encoding of what OTLPSink exports.
+type (
+	otlpAttribute struct {
+		Key   string `json:"key"`
+		Value struct {
+			StringValue string `json:"stringValue"`
+		} `json:"value"`
+	}
+	otlpDataPoint struct {
+		Attributes   []*otlpAttribute `json:"attributes,omitempty"`
+		TimeUnixNano string           `json:"timeUnixNano"`
+		AsDouble     float64          `json:"asDouble"`
+	}
+	otlpMetric struct {
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+		Unit        string `json:"unit,omitempty"`
+		Gauge       struct {
+			DataPoints []*otlpDataPoint `json:"dataPoints"`
+		} `json:"gauge"`
+	}
+)
+
+// newOTLPAttribute creates an OTLP string attribute.
+func newOTLPAttribute(key, value string) *otlpAttribute {
+	attribute := &otlpAttribute{Key: key}
+	attribute.Value.StringValue = value
+	return attribute
+}
+
+// otlpUnit translates CloudWatch units to UCUM ones OpenTelemetry uses.
+func otlpUnit(unit string) string {
+	switch unit {
+	case "Count", "None":
+		return "1"
+	case "Megabytes":
+		return "MiBy"
+	case "Percent":
+		return "%"
+	}
+	return unit
+}
+
+// Send metrics to the collector in one export request.
+func (sink *OTLPSink) Send(metricData []*cloudwatch.MetricDatum) error {
+	metrics := map[string]*otlpMetric{}
+	var names []string
+	for _, datum := range metricData {
+		name := aws.StringValue(datum.MetricName)
+		metric, ok := metrics[name]
+		if !ok {
+			metric = &otlpMetric{Name: name, Unit: otlpUnit(aws.StringValue(datum.Unit))}
+			if registered := LookupMetric(name); registered != nil {
+				metric.Description = registered.Description
+			}
+			metrics[name] = metric
+			names = append(names, name)
+		}
+		timestamp := time.Now()
+		if datum.Timestamp != nil {
+			timestamp = *datum.Timestamp
+		}
+		point := &otlpDataPoint{
+			TimeUnixNano: strconv.FormatInt(timestamp.UnixNano(), 10),
+			AsDouble:     aws.Float64Value(datum.Value),
+		}
+		for _, dimension := range datum.Dimensions {
+			point.Attributes = append(point.Attributes, newOTLPAttribute(label(aws.StringValue(dimension.Name)), aws.StringValue(dimension.Value)))
+		}
+		metric.Gauge.DataPoints = append(metric.Gauge.DataPoints, point)
+	}
+	sort.Strings(names)
+	var exported []*otlpMetric
+	for _, name := range names {
+		exported = append(exported, metrics[name])
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"resourceMetrics": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{
+					"attributes": []*otlpAttribute{newOTLPAttribute("service.name", sink.ServiceName)},
+				},
+				"scopeMetrics": []interface{}{
+					map[string]interface{}{
+						"scope":   map[string]string{"name": "github.com/shatil/snitch"},
+						"metrics": exported,
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest("POST", strings.TrimSuffix(sink.URL, "/")+"/v1/metrics", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for key, value := range sink.Headers {
+		request.Header.Set(key, value)
+	}
+	response, err := sink.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		return fmt.Errorf("OpenTelemetry collector responded %s", response.Status)
+	}
+	return nil
+}
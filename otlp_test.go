@@ -0,0 +1,55 @@
+package snitch
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestOTLPSink_Send(t *testing.T) {
+	var received struct {
+		ResourceMetrics []struct {
+			ScopeMetrics []struct {
+				Metrics []*otlpMetric `json:"metrics"`
+			} `json:"scopeMetrics"`
+		} `json:"resourceMetrics"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/metrics" || r.Header.Get("api-key") != "fake" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+	defer os.Setenv("OTEL_EXPORTER_OTLP_HEADERS", os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+	os.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "api-key=fake")
+	sink := NewOTLPSink(&SinkConfig{URL: server.URL})
+	cr := NewClusterResources(aws.String("fake-cluster"))
+	cr.Registered["fake.large"] = 4
+	cr.Remaining["fake.large"] = 2
+	if err := sink.Send(cr.ToMetricData()); err != nil {
+		t.Fatal(err)
+	}
+	metrics := received.ResourceMetrics[0].ScopeMetrics[0].Metrics
+	if len(metrics) != 2 || metrics[0].Name != "RegisteredSchedulable" || metrics[1].Name != "RemainingSchedulable" {
+		t.Fatalf("expected Registered and RemainingSchedulable but got %+v", metrics)
+	}
+	point := metrics[1].Gauge.DataPoints[0]
+	if point.AsDouble != 2 || len(point.Attributes) != 2 || point.Attributes[1].Key != "instance_type" || point.Attributes[1].Value.StringValue != "fake.large" {
+		t.Errorf("expected 2 with instance_type attribute but got %+v", point)
+	}
+	if metrics[1].Unit != "1" {
+		t.Errorf("expected Count as unit 1 but got %q", metrics[1].Unit)
+	}
+	sink.Headers = nil
+	if err := sink.Send(cr.ToMetricData()); err == nil {
+		t.Error("expected an error when the collector refuses")
+	}
+}
@@ -0,0 +1,72 @@
+package snitch
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Progress counts clusters measured so far, so a long run can be told apart
+// from a hung one. Its methods are safe to call on nil Progress, which
+// counts nothing.
+type Progress struct {
+	mutex      sync.Mutex
+	started    time.Time
+	listing    int // Measure calls still discovering clusters.
+	discovered int
+	completed  int
+}
+
+// NewProgress starts counting progress now.
+func NewProgress() *Progress {
+	return &Progress{started: time.Now()}
+}
+
+// restart counts progress anew from now, like for every run of a daemon.
+func (p *Progress) restart() {
+	p.update(func() { p.started, p.listing, p.discovered, p.completed = time.Now(), 0, 0, 0 })
+}
+
+func (p *Progress) update(update func()) {
+	if p == nil {
+		return
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	update()
+}
+
+func (p *Progress) startListing() { p.update(func() { p.listing++ }) }
+func (p *Progress) doneListing()  { p.update(func() { p.listing-- }) }
+func (p *Progress) discover()     { p.update(func() { p.discovered++ }) }
+func (p *Progress) complete()     { p.update(func() { p.completed++ }) }
+
+// Counts reports clusters completed and discovered so far, and whether every
+// cluster has been discovered yet.
+func (p *Progress) Counts() (completed, discovered int, listed bool) {
+	p.update(func() {
+		completed, discovered, listed = p.completed, p.discovered, p.listing == 0
+	})
+	return
+}
+
+// ETA estimates time remaining from the pace of clusters completed so far.
+// It's zero until every cluster has been discovered and some completed.
+func (p *Progress) ETA() (eta time.Duration) {
+	p.update(func() {
+		if p.listing > 0 || p.completed == 0 {
+			return
+		}
+		perCluster := time.Since(p.started) / time.Duration(p.completed)
+		eta = perCluster * time.Duration(p.discovered-p.completed)
+	})
+	return
+}
+
+func (p *Progress) String() string {
+	completed, discovered, listed := p.Counts()
+	if !listed {
+		return fmt.Sprintf("%d of %d+ clusters measured", completed, discovered)
+	}
+	return fmt.Sprintf("%d of %d clusters measured, ETA %s", completed, discovered, p.ETA().Round(time.Second))
+}
@@ -0,0 +1,57 @@
+package snitch
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestProgress(t *testing.T) {
+	var nothing *Progress
+	nothing.discover()
+	if completed, discovered, _ := nothing.Counts(); completed+discovered != 0 {
+		t.Error("expected nil Progress to count nothing")
+	}
+	p := NewProgress()
+	p.started = time.Now().Add(-time.Minute)
+	p.startListing()
+	for i := 0; i < 4; i++ {
+		p.discover()
+	}
+	p.complete()
+	if eta := p.ETA(); eta != 0 {
+		t.Errorf("expected no ETA while listing but got %s", eta)
+	}
+	if actual := p.String(); actual != "1 of 4+ clusters measured" {
+		t.Errorf("unexpected progress %q", actual)
+	}
+	p.doneListing()
+	if eta := p.ETA(); eta < 2*time.Minute || eta > 4*time.Minute {
+		t.Errorf("expected about 3m ETA but got %s", eta)
+	}
+	if actual := p.String(); !strings.HasPrefix(actual, "1 of 4 clusters measured, ETA 3m") {
+		t.Errorf("unexpected progress %q", actual)
+	}
+	p.restart()
+	if completed, discovered, listed := p.Counts(); completed+discovered != 0 || !listed || p.ETA() != 0 {
+		t.Errorf("expected restarting to count anew but got %d of %d", completed, discovered)
+	}
+}
+
+func TestSnitcher_MeasureProgress(t *testing.T) {
+	fake := NewFakeECS(t)
+	fake.checkCluster = false
+	sn := &Snitcher{ECS: fake, Progress: NewProgress()}
+	sn.Measure()
+	completed, discovered, listed := sn.Progress.Counts()
+	if expected := len(fake.expectedClusterArns); completed != expected || discovered != expected || !listed {
+		t.Errorf("expected %d of %d clusters done but got %d of %d (listed: %t)", expected, expected, completed, discovered, listed)
+	}
+	sn.Cluster = aws.String("fake-ecs-cluster")
+	sn.Measure()
+	if completed, _, _ := sn.Progress.Counts(); completed != len(fake.expectedClusterArns)+1 {
+		t.Errorf("expected progress to add up across Measure calls but got %d", completed)
+	}
+}
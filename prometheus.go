@@ -0,0 +1,172 @@
+package snitch
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+var (
+	wordBoundary = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+	unsafe       = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+)
+
+// snakeCase turns names like "RemainingSchedulable" or "InstanceType" into
+// Prometheus-style "remaining_schedulable" or "instance_type".
+func snakeCase(name string) string {
+	return strings.ToLower(unsafe.ReplaceAllString(wordBoundary.ReplaceAllString(name, "${1}_${2}"), "_"))
+}
+
+// label names a Prometheus label after a CloudWatch dimension. ClusterName is
+// plain "cluster".
+func label(dimension string) string {
+	if dimension == "ClusterName" {
+		return "cluster"
+	}
+	return snakeCase(dimension)
+}
+
+// escape a Prometheus label value.
+var escape = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace
+
+// WritePrometheus writes metric data as Prometheus gauges, like
+// "snitch_remaining_schedulable{cluster="foo",instance_type="c5.large"} 3",
+// in Prometheus' text exposition format.
+func WritePrometheus(w io.Writer, metricData []*cloudwatch.MetricDatum) error {
+	samples := map[string][]string{}
+	for _, datum := range metricData {
+		name := "snitch_" + snakeCase(aws.StringValue(datum.MetricName))
+		var labels []string
+		for _, dimension := range datum.Dimensions {
+			labels = append(labels, fmt.Sprintf(`%s="%s"`, label(aws.StringValue(dimension.Name)), escape(aws.StringValue(dimension.Value))))
+		}
+		sort.Strings(labels)
+		samples[name] = append(samples[name], fmt.Sprintf("%s{%s} %g", name, strings.Join(labels, ","), aws.Float64Value(datum.Value)))
+	}
+	var names []string
+	for name := range samples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sort.Strings(samples[name])
+		if metric := LookupMetric(metricName(name)); metric != nil {
+			if _, err := fmt.Fprintf(w, "# HELP %s %s\n", name, metric.Description); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "# TYPE %s gauge\n%s\n", name, strings.Join(samples[name], "\n")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// metricName finds the registered Metric name of a Prometheus metric name.
+func metricName(name string) string {
+	for _, metric := range Metrics {
+		if "snitch_"+snakeCase(metric.Name) == name {
+			return metric.Name
+		}
+	}
+	return ""
+}
+
+// Exporter serves metrics last collected by Snitcher to Prometheus:
+//
+//	exporter := snitch.NewExporter(sn)
+//	go exporter.CollectEvery(time.Minute)
+//	http.Handle("/metrics", exporter)
+type Exporter struct {
+	Snitcher   *Snitcher
+	mutex      sync.RWMutex
+	metricData []*cloudwatch.MetricDatum
+}
+
+// NewExporter creates an Exporter of what sn collects.
+func NewExporter(sn *Snitcher) *Exporter {
+	return &Exporter{Snitcher: sn.WithAWS()}
+}
+
+// Collect measures and keeps the metrics for serving, sending them to the
+// Snitcher's Sinks, too.
+func (exporter *Exporter) Collect() {
+	sn := exporter.Snitcher
+	sn.Progress.restart()
+	sn.Health.begin(sn.Progress)
+	sn.Health.done(sn.checked(func() (err error) {
+		metricData := sn.Collect()
+		exporter.Send(metricData)
+		if len(sn.Sinks) > 0 {
+			if failed := sn.Send(metricData); failed > 0 {
+				err = fmt.Errorf("%d of %d sinks failed", failed, len(sn.Sinks))
+			}
+		}
+		return
+	}))
+}
+
+// exporters are Exporters of "prometheus" sinks, by address they serve on,
+// kept across runs.
+var exporters = struct {
+	sync.Mutex
+	byAddress map[string]*Exporter
+}{byAddress: map[string]*Exporter{}}
+
+// defaultExporterAddress is where "prometheus" sinks serve without Address.
+const defaultExporterAddress = ":9100"
+
+// exporterAt is the Exporter serving /metrics at address, starting one if
+// none is yet.
+func (sn *Snitcher) exporterAt(address string) *Exporter {
+	if address == "" {
+		address = defaultExporterAddress
+	}
+	exporters.Lock()
+	defer exporters.Unlock()
+	if exporter, ok := exporters.byAddress[address]; ok {
+		return exporter
+	}
+	exporter := &Exporter{Snitcher: sn}
+	exporters.byAddress[address] = exporter
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exporter)
+	go func() {
+		if err := http.ListenAndServe(address, mux); err != nil {
+			sn.logger().Errorf("Failed to serve Prometheus metrics on %q: %s", address, err)
+		}
+	}()
+	return exporter
+}
+
+// Send keeps metrics for serving, replacing those kept before.
+func (exporter *Exporter) Send(metricData []*cloudwatch.MetricDatum) error {
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+	exporter.metricData = metricData
+	return nil
+}
+
+// CollectEvery collects now and every interval after, forever.
+func (exporter *Exporter) CollectEvery(interval time.Duration) {
+	for {
+		exporter.Collect()
+		time.Sleep(interval)
+	}
+}
+
+// ServeHTTP writes metrics last collected as Prometheus gauges.
+func (exporter *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	exporter.mutex.RLock()
+	defer exporter.mutex.RUnlock()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	WritePrometheus(w, exporter.metricData)
+}
@@ -0,0 +1,82 @@
+package snitch
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func Test_snakeCase(t *testing.T) {
+	for name, expected := range map[string]string{
+		"RemainingSchedulable":    "remaining_schedulable",
+		"LowestCommonMultipleCPU": "lowest_common_multiple_cpu",
+		"InstanceType":            "instance_type",
+		"snitch:enabled":          "snitch_enabled",
+	} {
+		if actual := snakeCase(name); actual != expected {
+			t.Errorf("expected %q as %q but got %q", name, expected, actual)
+		}
+	}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	cr := NewClusterResources(aws.String(`my"cluster`))
+	cr.Remaining["c5.large"] = 3
+	cr.Totals["PendingTasks"] = 1.5
+	var b bytes.Buffer
+	if err := WritePrometheus(&b, cr.ToMetricData()); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"# HELP snitch_remaining_schedulable Containers that fit in remaining capacity.\n# TYPE snitch_remaining_schedulable gauge\n",
+		`snitch_remaining_schedulable{cluster="my\"cluster",instance_type="c5.large"} 3` + "\n",
+		`snitch_pending_tasks{cluster="my\"cluster"} 1.5` + "\n",
+	} {
+		if !strings.Contains(b.String(), expected) {
+			t.Errorf("expected %q in:\n%s", expected, b.String())
+		}
+	}
+}
+
+func TestExporter(t *testing.T) {
+	fake := NewFakeECS(t)
+	fake.checkCluster = false
+	exporter := NewExporter(&Snitcher{ECS: fake})
+	recorder := httptest.NewRecorder()
+	exporter.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	if recorder.Body.Len() != 0 {
+		t.Errorf("expected nothing before collecting but got %s", recorder.Body)
+	}
+	exporter.Collect()
+	recorder = httptest.NewRecorder()
+	exporter.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.Contains(recorder.Body.String(), `snitch_remaining_schedulable{cluster="fake-ecs-cluster",instance_type="fake.2xlarge"}`) {
+		t.Errorf("expected collected metrics but got %s", recorder.Body)
+	}
+}
+
+func TestPrometheusSink(t *testing.T) {
+	sn := &Snitcher{Sinks: []*SinkConfig{{Type: "prometheus", Address: "127.0.0.1:0"}}}
+	cr := NewClusterResources(aws.String("fake-cluster"))
+	cr.Remaining["fake.large"] = 2
+	if failed := sn.Send(cr.ToMetricData()); failed != 0 {
+		t.Errorf("expected no failed sinks but got %d", failed)
+	}
+	exporter := sn.exporterAt("127.0.0.1:0")
+	recorder := httptest.NewRecorder()
+	exporter.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.Contains(recorder.Body.String(), `snitch_remaining_schedulable{cluster="fake-cluster",instance_type="fake.large"} 2`) {
+		t.Errorf("expected metrics sent kept across runs but got %s", recorder.Body)
+	}
+}
+
+func Test_metricName(t *testing.T) {
+	for _, metric := range Metrics {
+		if actual := metricName("snitch_" + snakeCase(metric.Name)); actual != metric.Name {
+			t.Errorf("expected %q back but got %q", metric.Name, actual)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package snitch
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// DescribeRegions lists AWS Regions enabled in the account, like "us-east-1".
+//
+// Requires IAM permission "ec2:DescribeRegions".
+func (sn *Snitcher) DescribeRegions() (regions []string) {
+	output, err := sn.EC2.DescribeRegions(&ec2.DescribeRegionsInput{})
+	if err != nil {
+		sn.logger().Errorf("Failed to DescribeRegions! %s", err)
+		return
+	}
+	for _, region := range output.Regions {
+		regions = append(regions, aws.StringValue(region.RegionName))
+	}
+	return
+}
+
+// InRegion copies Snitcher with AWS clients of another region.
+func (sn *Snitcher) InRegion(region string) *Snitcher {
+	regional := *sn
+	regional.Region = aws.String(region)
+	regional.ApplicationAutoScaling = nil
+	regional.CloudWatch = nil
+	regional.DynamoDB = nil
+	regional.EC2 = nil
+	regional.ECS = nil
+	regional.EventBridge = nil
+	regional.FIS = nil
+	regional.Organizations = nil
+	regional.S3 = nil
+	regional.SecretsManager = nil
+	regional.SNS = nil
+	regional.SQS = nil
+	regional.OrganizationRole = nil
+	if stream := sn.stream; stream != nil {
+		regional.stream = func(metricData []*cloudwatch.MetricDatum) {
+			stream(withRegion(metricData, region))
+		}
+	}
+	return regional.WithAWS()
+}
+
+// MeasureRegions measures clusters of every one of Regions at once, adding a
+// "Region" dimension to their metrics. "all" means every region enabled.
+func (sn *Snitcher) MeasureRegions() (metricData []*cloudwatch.MetricDatum) {
+	regions := sn.Regions
+	if len(regions) == 1 && regions[0] == "all" {
+		regions = sn.DescribeRegions()
+	}
+	com := make(chan []*cloudwatch.MetricDatum, len(regions))
+	for _, region := range regions {
+		go func(region string) {
+			com <- withRegion(sn.InRegion(region).Measure(), region)
+		}(region)
+	}
+	for range regions {
+		metricData = append(metricData, <-com...)
+	}
+	sortMetricData(metricData)
+	return
+}
+
+// withRegion adds a "Region" dimension to metric data.
+func withRegion(metricData []*cloudwatch.MetricDatum, region string) []*cloudwatch.MetricDatum {
+	for _, datum := range metricData {
+		datum.Dimensions = append(datum.Dimensions, &cloudwatch.Dimension{
+			Name:  aws.String("Region"),
+			Value: aws.String(region),
+		})
+	}
+	return metricData
+}
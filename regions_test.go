@@ -0,0 +1,55 @@
+package snitch
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+func (fake *FakeEC2) DescribeRegions(input *ec2.DescribeRegionsInput) (*ec2.DescribeRegionsOutput, error) {
+	output := &ec2.DescribeRegionsOutput{}
+	for _, region := range fake.expectedRegions {
+		output.Regions = append(output.Regions, &ec2.Region{RegionName: aws.String(region)})
+	}
+	return output, fake.errorToReturn
+}
+
+func TestSnitcher_DescribeRegions(t *testing.T) {
+	fake := &FakeEC2{expectedRegions: []string{"ca-central-1", "us-east-1"}}
+	sn := &Snitcher{EC2: fake}
+	if regions := sn.DescribeRegions(); len(regions) != 2 || regions[0] != "ca-central-1" {
+		t.Errorf("expected %v but got %v", fake.expectedRegions, regions)
+	}
+	fake.errorToReturn = errors.New("there should be no regions on error")
+	if regions := sn.DescribeRegions(); len(regions) != 0 {
+		t.Error(fake.errorToReturn)
+	}
+}
+
+func TestSnitcher_InRegion(t *testing.T) {
+	sn := &Snitcher{ECS: NewFakeECS(t), Namespace: aws.String("Regional")}
+	regional := sn.InRegion("ca-central-1")
+	if regional == sn || *regional.Namespace != "Regional" {
+		t.Error("expected a copy of Snitcher")
+	}
+	if client, ok := regional.ECS.(*ecs.ECS); !ok || *client.Config.Region != "ca-central-1" {
+		t.Errorf("expected ECS client in ca-central-1 but got %v", regional.ECS)
+	}
+	if _, ok := sn.ECS.(*FakeECS); !ok {
+		t.Error("expected original Snitcher's clients untouched")
+	}
+}
+
+func Test_withRegion(t *testing.T) {
+	metricData := []*cloudwatch.MetricDatum{
+		{MetricName: aws.String("RemainingSchedulable"), Dimensions: []*cloudwatch.Dimension{{Name: aws.String("ClusterName"), Value: aws.String("c")}}},
+	}
+	dimensions := withRegion(metricData, "ca-central-1")[0].Dimensions
+	if len(dimensions) != 2 || *dimensions[1].Name != "Region" || *dimensions[1].Value != "ca-central-1" {
+		t.Errorf("expected Region dimension but got %v", dimensions)
+	}
+}
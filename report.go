@@ -0,0 +1,216 @@
+package snitch
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// ClusterReport is a cluster's measurements, structured for tooling like jq
+// rather than CloudWatch.
+type ClusterReport struct {
+	Cluster string `json:"cluster"`
+	// When the cluster was measured.
+	Timestamp time.Time `json:"timestamp"`
+	// Dimensions every metric of the cluster has, besides ClusterName, like
+	// AccountId, Region or DimensionTags.
+	Dimensions map[string]string `json:"dimensions,omitempty"`
+	// Metrics by name per instance type, like "c5.large", or per partition,
+	// like "c5.large,stack=blue", per subnet, and cluster-wide.
+	InstanceTypes map[string]map[string]float64 `json:"instanceTypes,omitempty"`
+	Subnets       map[string]map[string]float64 `json:"subnets,omitempty"`
+	Totals        map[string]float64            `json:"totals,omitempty"`
+	// Units of metrics by name, like "Count" or "Percent".
+	Units map[string]string `json:"units"`
+}
+
+// Report structures metrics by cluster, ordered by cluster name. Clusters of
+// different accounts or regions are reported separately.
+func Report(metricData []*cloudwatch.MetricDatum) (reports []*ClusterReport) {
+	grouped := map[string][]*cloudwatch.MetricDatum{}
+	var keys []string
+	for _, datum := range metricData {
+		dimensions := dimensionMap(datum.Dimensions)
+		key := strings.Join([]string{dimensions["ClusterName"], dimensions["AccountId"], dimensions["Region"]}, "\x00")
+		if _, ok := grouped[key]; !ok {
+			keys = append(keys, key)
+		}
+		grouped[key] = append(grouped[key], datum)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		reports = append(reports, newClusterReport(grouped[key]))
+	}
+	return
+}
+
+// newClusterReport structures metrics of one cluster.
+func newClusterReport(metricData []*cloudwatch.MetricDatum) *ClusterReport {
+	report := &ClusterReport{
+		InstanceTypes: map[string]map[string]float64{},
+		Subnets:       map[string]map[string]float64{},
+		Totals:        map[string]float64{},
+		Units:         map[string]string{},
+	}
+	// Dimensions shared by all of the cluster's metrics.
+	for i, datum := range metricData {
+		dimensions := dimensionMap(datum.Dimensions)
+		if i == 0 {
+			report.Cluster = dimensions["ClusterName"]
+			report.Timestamp = aws.TimeValue(datum.Timestamp)
+			report.Dimensions = dimensions
+			continue
+		}
+		for name, value := range report.Dimensions {
+			if dimensions[name] != value {
+				delete(report.Dimensions, name)
+			}
+		}
+	}
+	delete(report.Dimensions, "ClusterName")
+	for _, datum := range metricData {
+		name, value := aws.StringValue(datum.MetricName), aws.Float64Value(datum.Value)
+		report.Units[name] = aws.StringValue(datum.Unit)
+		dimensions := dimensionMap(datum.Dimensions)
+		var key string
+		var metrics map[string]map[string]float64
+		switch {
+		case dimensions["SubnetId"] != "":
+			key, metrics = dimensions["SubnetId"], report.Subnets
+		case dimensions["InstanceType"] != "":
+			key, metrics = dimensions["InstanceType"], report.InstanceTypes
+			var partition []string
+			for dimension, value := range dimensions {
+				if _, shared := report.Dimensions[dimension]; !shared && dimension != "ClusterName" && dimension != "InstanceType" {
+					partition = append(partition, dimension+"="+value)
+				}
+			}
+			sort.Strings(partition)
+			key = strings.Join(append([]string{key}, partition...), ",")
+		default:
+			report.Totals[name] = value
+			continue
+		}
+		if metrics[key] == nil {
+			metrics[key] = map[string]float64{}
+		}
+		metrics[key][name] = value
+	}
+	return report
+}
+
+// dimensionMap maps dimensions' names to their values.
+func dimensionMap(dimensions []*cloudwatch.Dimension) map[string]string {
+	mapped := map[string]string{}
+	for _, dimension := range dimensions {
+		mapped[aws.StringValue(dimension.Name)] = aws.StringValue(dimension.Value)
+	}
+	return mapped
+}
+
+// JSONSink writes metrics as an indented JSON list of ClusterReport.
+type JSONSink struct {
+	// Writer of JSON. Nil means stdout.
+	Writer io.Writer
+}
+
+// Send metrics as JSON.
+func (sink *JSONSink) Send(metricData []*cloudwatch.MetricDatum) error {
+	w := sink.Writer
+	if w == nil {
+		w = os.Stdout
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(Report(metricData))
+}
+
+// csvHeader names the columns of ClusterReport's Rows.
+var csvHeader = []string{"timestamp", "cluster", "dimensions", "instance_type", "subnet", "metric", "value", "unit"}
+
+// Rows flattens a report into a row per instance type, subnet or cluster-wide
+// metric, ordered by metric name within each, then instance type or subnet.
+// Shared dimensions are like "AccountId=123456789012;Team=capacity".
+func (report *ClusterReport) Rows() (rows [][]string) {
+	var dimensions []string
+	for name, value := range report.Dimensions {
+		dimensions = append(dimensions, name+"="+value)
+	}
+	sort.Strings(dimensions)
+	row := func(instanceType, subnet, metric string, value float64) []string {
+		return []string{
+			report.Timestamp.UTC().Format(time.RFC3339),
+			report.Cluster,
+			strings.Join(dimensions, ";"),
+			instanceType,
+			subnet,
+			metric,
+			strconv.FormatFloat(value, 'f', -1, 64),
+			report.Units[metric],
+		}
+	}
+	for _, instanceType := range sortedKeys(report.InstanceTypes) {
+		metrics := report.InstanceTypes[instanceType]
+		for _, metric := range sortedNames(metrics) {
+			rows = append(rows, row(instanceType, "", metric, metrics[metric]))
+		}
+	}
+	for _, subnet := range sortedKeys(report.Subnets) {
+		metrics := report.Subnets[subnet]
+		for _, metric := range sortedNames(metrics) {
+			rows = append(rows, row("", subnet, metric, metrics[metric]))
+		}
+	}
+	for _, metric := range sortedNames(report.Totals) {
+		rows = append(rows, row("", "", metric, report.Totals[metric]))
+	}
+	return
+}
+
+// sortedKeys orders keys of metrics per instance type or subnet.
+func sortedKeys(metrics map[string]map[string]float64) (keys []string) {
+	for key := range metrics {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return
+}
+
+// sortedNames orders names of metrics.
+func sortedNames(metrics map[string]float64) (names []string) {
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+// CSVSink writes metrics as CSV, a row per cluster, instance type or subnet,
+// and metric, after a header row.
+type CSVSink struct {
+	// Writer of CSV. Nil means stdout.
+	Writer io.Writer
+}
+
+// Send metrics as CSV.
+func (sink *CSVSink) Send(metricData []*cloudwatch.MetricDatum) error {
+	w := sink.Writer
+	if w == nil {
+		w = os.Stdout
+	}
+	writer := csv.NewWriter(w)
+	writer.Write(csvHeader)
+	for _, report := range Report(metricData) {
+		writer.WriteAll(report.Rows())
+	}
+	writer.Flush()
+	return writer.Error()
+}
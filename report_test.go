@@ -0,0 +1,95 @@
+package snitch
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+func TestReport(t *testing.T) {
+	cr := NewClusterResources(aws.String("fake-cluster"))
+	cr.CPU["fake.large"] = 512
+	cr.Registered["fake.large"] = 4
+	cr.Remaining["fake.large"] = 2
+	cr.Remaining["fake.large,stack=blue"] = 1
+	cr.Dimensions["fake.large,stack=blue"] = []*cloudwatch.Dimension{
+		{Name: aws.String("InstanceType"), Value: aws.String("fake.large")},
+		{Name: aws.String("stack"), Value: aws.String("blue")},
+	}
+	cr.Subnets["SubnetAvailableIPs"] = map[string]int{"subnet-1": 10}
+	cr.Totals["PendingTasks"] = 3
+	cr.ClusterDimensions = []*cloudwatch.Dimension{{Name: aws.String("Team"), Value: aws.String("capacity")}}
+	other := NewClusterResources(aws.String("another-cluster"))
+	other.Totals["PendingTasks"] = 0
+	reports := Report(append(cr.ToMetricData(), other.ToMetricData()...))
+	if len(reports) != 2 || reports[0].Cluster != "another-cluster" || reports[1].Cluster != "fake-cluster" {
+		t.Fatalf("expected reports ordered by cluster but got %+v", reports)
+	}
+	expected := &ClusterReport{
+		Cluster:    "fake-cluster",
+		Timestamp:  reports[1].Timestamp,
+		Dimensions: map[string]string{"Team": "capacity"},
+		InstanceTypes: map[string]map[string]float64{
+			"fake.large":            {"LowestCommonMultipleCPU": 512, "RegisteredSchedulable": 4, "RemainingSchedulable": 2},
+			"fake.large,stack=blue": {"RemainingSchedulable": 1},
+		},
+		Subnets: map[string]map[string]float64{"subnet-1": {"SubnetAvailableIPs": 10}},
+		Totals:  map[string]float64{"PendingTasks": 3},
+		Units: map[string]string{
+			"LowestCommonMultipleCPU": "None",
+			"PendingTasks":            "Count",
+			"RegisteredSchedulable":   "Count",
+			"RemainingSchedulable":    "Count",
+			"SubnetAvailableIPs":      "Count",
+		},
+	}
+	if !reflect.DeepEqual(reports[1], expected) {
+		t.Errorf("expected %+v but got %+v", expected, reports[1])
+	}
+}
+
+func TestJSONSink(t *testing.T) {
+	cr := NewClusterResources(aws.String("fake-cluster"))
+	cr.Remaining["fake.large"] = 2
+	var b bytes.Buffer
+	if err := (&JSONSink{Writer: &b}).Send(cr.ToMetricData()); err != nil {
+		t.Fatal(err)
+	}
+	var reports []*ClusterReport
+	if err := json.Unmarshal(b.Bytes(), &reports); err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 || reports[0].InstanceTypes["fake.large"]["RemainingSchedulable"] != 2 {
+		t.Errorf("expected fake-cluster's report but got %s", b.String())
+	}
+}
+
+func TestCSVSink(t *testing.T) {
+	cr := NewClusterResources(aws.String("fake-cluster"))
+	cr.Registered["fake.large"] = 4
+	cr.Remaining["fake.large"] = 2
+	cr.Totals["ClusterFullPercent"] = 37.5
+	cr.ClusterDimensions = []*cloudwatch.Dimension{{Name: aws.String("Team"), Value: aws.String("capacity")}}
+	metricData := cr.ToMetricData()
+	timestamp := time.Date(2018, 10, 1, 12, 0, 0, 0, time.UTC)
+	for _, datum := range metricData {
+		datum.Timestamp = aws.Time(timestamp)
+	}
+	var b bytes.Buffer
+	if err := (&CSVSink{Writer: &b}).Send(metricData); err != nil {
+		t.Fatal(err)
+	}
+	expected := `timestamp,cluster,dimensions,instance_type,subnet,metric,value,unit
+2018-10-01T12:00:00Z,fake-cluster,Team=capacity,fake.large,,RegisteredSchedulable,4,Count
+2018-10-01T12:00:00Z,fake-cluster,Team=capacity,fake.large,,RemainingSchedulable,2,Count
+2018-10-01T12:00:00Z,fake-cluster,Team=capacity,,,ClusterFullPercent,37.5,Percent
+`
+	if b.String() != expected {
+		t.Errorf("expected:\n%s\nbut got:\n%s", expected, b.String())
+	}
+}
@@ -0,0 +1,109 @@
+package snitch
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// S3Sink writes each run's ClusterReports as an S3 object of JSON lines, for
+// history beyond CloudWatch's retention and for Athena to query. Objects are
+// partitioned by date and named by when, to the nanosecond, and in which
+// account and region they were written, like:
+//
+//	s3://my-bucket/snitch/dt=2018-10-01/20181001T120000.000000000Z-123456789012-us-east-1.json.gz
+type S3Sink struct {
+	Snitcher *Snitcher
+	Bucket   string
+	Prefix   string
+	Gzip     bool
+	// Now tells time, for naming objects. Nil means time.Now.
+	Now func() time.Time
+}
+
+// newS3Sink configures an S3Sink of config's URL, like "s3://my-bucket/snitch".
+func (sn *Snitcher) newS3Sink(config *SinkConfig) *S3Sink {
+	sink := &S3Sink{Snitcher: sn, Gzip: config.Gzip}
+	if location, err := url.Parse(config.URL); err == nil {
+		sink.Bucket, sink.Prefix = location.Host, strings.Trim(location.Path, "/")
+	}
+	return sink
+}
+
+// Key names the object written at now, so that runs writing at once, like
+// of other accounts or regions, or streamed clusters, don't overwrite it.
+// Account and region are left out if Snitcher has no Role or Region.
+func (sink *S3Sink) Key(now time.Time) string {
+	now = now.UTC()
+	name := now.Format("20060102T150405.000000000Z")
+	if sn := sink.Snitcher; sn != nil {
+		if role := aws.StringValue(sn.Role); role != "" {
+			name += "-" + accountID(role)
+		}
+		if region := aws.StringValue(sn.Region); region != "" {
+			name += "-" + region
+		}
+	}
+	key := fmt.Sprintf("dt=%s/%s.json", now.Format("2006-01-02"), name)
+	if sink.Prefix != "" {
+		key = sink.Prefix + "/" + key
+	}
+	if sink.Gzip {
+		key += ".gz"
+	}
+	return key
+}
+
+// Send metrics to S3 as a ClusterReport per line.
+//
+// Requires IAM permission "s3:PutObject".
+func (sink *S3Sink) Send(metricData []*cloudwatch.MetricDatum) error {
+	if sink.Bucket == "" {
+		return errors.New("no S3 bucket in sink's URL")
+	}
+	var b bytes.Buffer
+	if sink.Gzip {
+		compressor := gzip.NewWriter(&b)
+		if err := writeJSONLines(compressor, metricData); err != nil {
+			return err
+		}
+		if err := compressor.Close(); err != nil {
+			return err
+		}
+	} else if err := writeJSONLines(&b, metricData); err != nil {
+		return err
+	}
+	now := time.Now
+	if sink.Now != nil {
+		now = sink.Now
+	}
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(sink.Bucket),
+		Key:         aws.String(sink.Key(now())),
+		Body:        bytes.NewReader(b.Bytes()),
+		ContentType: aws.String("application/x-ndjson"),
+	}
+	_, err := sink.Snitcher.S3.PutObject(input)
+	return err
+}
+
+// writeJSONLines writes a ClusterReport of metrics per line.
+func writeJSONLines(w io.Writer, metricData []*cloudwatch.MetricDatum) error {
+	encoder := json.NewEncoder(w)
+	for _, report := range Report(metricData) {
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package snitch
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// FakeS3 mocks S3, keeping objects put.
+type FakeS3 struct {
+	s3iface.S3API
+	errorToReturn error                // `error` to return from fake methods.
+	objects       map[string][]byte    // Objects put by key.
+	inputs        []*s3.PutObjectInput // Stores supplied `*PutObjectInput`.
+}
+
+func (fake *FakeS3) PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	fake.inputs = append(fake.inputs, input)
+	if fake.objects == nil {
+		fake.objects = map[string][]byte{}
+	}
+	body, err := ioutil.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	fake.objects[aws.StringValue(input.Bucket)+"/"+aws.StringValue(input.Key)] = body
+	return &s3.PutObjectOutput{}, fake.errorToReturn
+}
+
+func TestS3Sink_Send(t *testing.T) {
+	fake := &FakeS3{}
+	sn := &Snitcher{S3: fake}
+	sink := sn.newS3Sink(&SinkConfig{URL: "s3://my-bucket/snitch/", Gzip: true})
+	sink.Now = func() time.Time { return time.Date(2018, 10, 1, 12, 0, 0, 0, time.UTC) }
+	cr := NewClusterResources(aws.String("fake-cluster"))
+	cr.Remaining["fake.large"] = 2
+	other := NewClusterResources(aws.String("another-cluster"))
+	other.Remaining["fake.large"] = 5
+	if err := sink.Send(append(cr.ToMetricData(), other.ToMetricData()...)); err != nil {
+		t.Fatal(err)
+	}
+	object, ok := fake.objects["my-bucket/snitch/dt=2018-10-01/20181001T120000.000000000Z.json.gz"]
+	if !ok {
+		t.Fatalf("expected object partitioned by date but got %v", fake.inputs)
+	}
+	gunzipped, err := gzip.NewReader(bytes.NewReader(object))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var clusters []string
+	scanner := bufio.NewScanner(gunzipped)
+	for scanner.Scan() {
+		var report ClusterReport
+		if err := json.Unmarshal(scanner.Bytes(), &report); err != nil {
+			t.Fatal(err)
+		}
+		clusters = append(clusters, report.Cluster)
+	}
+	if len(clusters) != 2 || clusters[0] != "another-cluster" || clusters[1] != "fake-cluster" {
+		t.Errorf("expected a line per cluster but got %v", clusters)
+	}
+	fake.errorToReturn = errors.New("fake")
+	if err := sink.Send(cr.ToMetricData()); err == nil {
+		t.Error("expected an error when S3 fails")
+	}
+	if err := (&S3Sink{}).Send(cr.ToMetricData()); err == nil {
+		t.Error("expected an error without a bucket")
+	}
+}
+
+func TestS3Sink_Key(t *testing.T) {
+	now := time.Date(2018, 10, 1, 12, 0, 0, 42, time.FixedZone("EDT", -4*60*60))
+	if actual := (&S3Sink{}).Key(now); actual != "dt=2018-10-01/20181001T160000.000000042Z.json" {
+		t.Errorf("expected UTC key without prefix but got %q", actual)
+	}
+	sink := &S3Sink{Snitcher: &Snitcher{Role: aws.String("arn:aws:iam::123456789012:role/snitch"), Region: aws.String("us-east-1")}}
+	if actual := sink.Key(now); actual != "dt=2018-10-01/20181001T160000.000000042Z-123456789012-us-east-1.json" {
+		t.Errorf("expected key of account and region but got %q", actual)
+	}
+}
@@ -0,0 +1,131 @@
+package snitch
+
+import (
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/applicationautoscaling"
+)
+
+// ScheduledScaleOut is an Application Auto Scaling scheduled action that will
+// raise an ECS Service's desired count.
+type ScheduledScaleOut struct {
+	Action   string // Scheduled Action name.
+	Service  string // ECS Service name.
+	Schedule string // Like "cron(0 9 * * ? *)" or "at(2019-05-01T09:00:00)".
+	Tasks    int    // How many Tasks beyond the Service's current desired count.
+}
+
+// DescribeScheduledActions reads upcoming Application Auto Scaling scheduled
+// actions for ECS Services in a cluster, keyed by ECS Service name.
+//
+// Actions past their end time, or one-time "at()" actions already run, aren't
+// upcoming so they're skipped. Recurring ones are always upcoming.
+//
+// Requires IAM permission "application-autoscaling:DescribeScheduledActions".
+func (sn *Snitcher) DescribeScheduledActions(cluster *string) map[string][]*applicationautoscaling.ScheduledAction {
+	actions := map[string][]*applicationautoscaling.ScheduledAction{}
+	input := &applicationautoscaling.DescribeScheduledActionsInput{
+		ScalableDimension: aws.String("ecs:service:DesiredCount"),
+		ServiceNamespace:  aws.String("ecs"),
+	}
+	prefix := "service/" + *cluster + "/"
+	now := time.Now()
+	err := sn.ApplicationAutoScaling.DescribeScheduledActionsPages(
+		input,
+		func(page *applicationautoscaling.DescribeScheduledActionsOutput, last bool) bool {
+			for _, action := range page.ScheduledActions {
+				resourceID := aws.StringValue(action.ResourceId)
+				if !strings.HasPrefix(resourceID, prefix) || !isUpcoming(action, now) {
+					continue
+				}
+				service := strings.TrimPrefix(resourceID, prefix)
+				actions[service] = append(actions[service], action)
+			}
+			return len(page.ScheduledActions) > 0
+		},
+	)
+	if err != nil {
+		sn.logger().Errorf("Failed to DescribeScheduledActionsPages for %q: %s", *cluster, err)
+	}
+	return actions
+}
+
+// isUpcoming figures out whether a scheduled action will run after now.
+func isUpcoming(action *applicationautoscaling.ScheduledAction, now time.Time) bool {
+	if action.EndTime != nil && action.EndTime.Before(now) {
+		return false
+	}
+	schedule := aws.StringValue(action.Schedule)
+	if !strings.HasPrefix(schedule, "at(") {
+		return true
+	}
+	location, err := time.LoadLocation(aws.StringValue(action.Timezone))
+	if err != nil {
+		location = time.UTC
+	}
+	at, err := time.ParseInLocation("2006-01-02T15:04:05", strings.TrimSuffix(strings.TrimPrefix(schedule, "at("), ")"), location)
+	if err != nil {
+		defaultLogger.Errorf("Failed to parse %q schedule %q: %s", aws.StringValue(action.ScheduledActionName), schedule, err)
+		return true
+	}
+	return at.After(now)
+}
+
+// DescribeScheduledScaleOuts finds upcoming scheduled actions that raise ECS
+// Services' minimum capacity above their current desired count.
+func (sn *Snitcher) DescribeScheduledScaleOuts(cluster *string) (scaleOuts []*ScheduledScaleOut) {
+	actions := sn.DescribeScheduledActions(cluster)
+	if len(actions) == 0 {
+		return
+	}
+	var names []*string
+	for name := range actions {
+		names = append(names, aws.String(name))
+	}
+	for _, service := range sn.DescribeServices(cluster, names) {
+		name := aws.StringValue(service.ServiceName)
+		for _, action := range actions[name] {
+			if action.ScalableTargetAction == nil || action.ScalableTargetAction.MinCapacity == nil {
+				continue
+			}
+			tasks := int(*action.ScalableTargetAction.MinCapacity - aws.Int64Value(service.DesiredCount))
+			if tasks <= 0 {
+				continue
+			}
+			scaleOuts = append(scaleOuts, &ScheduledScaleOut{
+				Action:   aws.StringValue(action.ScheduledActionName),
+				Service:  name,
+				Schedule: aws.StringValue(action.Schedule),
+				Tasks:    tasks,
+			})
+		}
+	}
+	return
+}
+
+// MeasureScheduledScalingShortfall adds "ScheduledScalingShortfall" to cluster
+// Totals: how many more "lowest common multiple" containers the worst upcoming
+// scheduled scale-out needs than the cluster has remaining. Zero means every
+// scale-out fits.
+//
+// Each scale-out that won't fit is logged, so you get hours of warning instead
+// of a failed scale-out at 9am.
+func (sn *Snitcher) MeasureScheduledScalingShortfall(cr *ClusterResources) {
+	remaining := 0
+	for _, count := range cr.Remaining {
+		remaining += count
+	}
+	shortfall := 0
+	for _, scaleOut := range sn.DescribeScheduledScaleOuts(cr.Cluster) {
+		if scaleOut.Tasks <= remaining {
+			continue
+		}
+		sn.logger().Warnf("%q can't fit %q scaling %q out by %d Tasks on %q; only %d remaining", *cr.Cluster, scaleOut.Action, scaleOut.Service, scaleOut.Tasks, scaleOut.Schedule, remaining)
+		if scaleOut.Tasks-remaining > shortfall {
+			shortfall = scaleOut.Tasks - remaining
+		}
+	}
+	cr.Totals["ScheduledScalingShortfall"] = float64(shortfall)
+}
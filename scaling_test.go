@@ -0,0 +1,134 @@
+package snitch
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/applicationautoscaling"
+	"github.com/aws/aws-sdk-go/service/applicationautoscaling/applicationautoscalingiface"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+// FakeApplicationAutoScaling mocks Application Auto Scaling for testing.
+type FakeApplicationAutoScaling struct {
+	applicationautoscalingiface.ApplicationAutoScalingAPI
+	errorToReturn            error                                     // `error` to return from fake methods.
+	expectedScheduledActions []*applicationautoscaling.ScheduledAction // Expected scheduled actions across clusters.
+}
+
+// DescribeScheduledActionsPages fake-paginates scheduled actions, one per page.
+func (fake *FakeApplicationAutoScaling) DescribeScheduledActionsPages(input *applicationautoscaling.DescribeScheduledActionsInput, pager func(*applicationautoscaling.DescribeScheduledActionsOutput, bool) bool) error {
+	for i := range fake.expectedScheduledActions {
+		output := &applicationautoscaling.DescribeScheduledActionsOutput{
+			ScheduledActions: fake.expectedScheduledActions[i : i+1],
+		}
+		pager(output, i+1 == len(fake.expectedScheduledActions))
+	}
+	return fake.errorToReturn
+}
+
+// newScheduledAction creates a scheduled action setting a Service's minimum.
+func newScheduledAction(cluster, service, schedule string, min int64) *applicationautoscaling.ScheduledAction {
+	return &applicationautoscaling.ScheduledAction{
+		ResourceId:           aws.String("service/" + cluster + "/" + service),
+		ScalableTargetAction: &applicationautoscaling.ScalableTargetAction{MinCapacity: aws.Int64(min)},
+		Schedule:             aws.String(schedule),
+		ScheduledActionName:  aws.String(service + "-" + schedule),
+	}
+}
+
+// NewFakeScheduledScaling fakes a cluster with "web" at 2 Tasks, scaling out
+// to 10 every morning, to 50 once long ago, and "api" scaling out to 6.
+func NewFakeScheduledScaling(t *testing.T) (*Snitcher, *FakeECS, *FakeApplicationAutoScaling) {
+	fakeECS := NewFakeECS(t)
+	fakeECS.expectedServices = []*ecs.Service{
+		{DesiredCount: aws.Int64(2), ServiceName: aws.String("web")},
+		{DesiredCount: aws.Int64(4), ServiceName: aws.String("api")},
+	}
+	cluster := *fakeECS.expectedCluster
+	fake := &FakeApplicationAutoScaling{
+		expectedScheduledActions: []*applicationautoscaling.ScheduledAction{
+			newScheduledAction(cluster, "web", "cron(0 9 * * ? *)", 10),
+			newScheduledAction(cluster, "web", "at(2019-05-01T09:00:00)", 50),
+			newScheduledAction(cluster, "api", "cron(0 9 * * ? *)", 6),
+			newScheduledAction(cluster, "api", "cron(0 18 * * ? *)", 1),
+			newScheduledAction("another-fake-ecs-cluster", "web", "cron(0 9 * * ? *)", 100),
+		},
+	}
+	sn := &Snitcher{ApplicationAutoScaling: fake, ECS: fakeECS}
+	return sn, fakeECS, fake
+}
+
+func Test_isUpcoming(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, arg := range []struct {
+		upcoming bool
+		action   *applicationautoscaling.ScheduledAction
+	}{
+		{true, &applicationautoscaling.ScheduledAction{Schedule: aws.String("cron(0 9 * * ? *)")}},
+		{false, &applicationautoscaling.ScheduledAction{Schedule: aws.String("rate(1 day)"), EndTime: aws.Time(now.Add(-time.Hour))}},
+		{true, &applicationautoscaling.ScheduledAction{Schedule: aws.String("at(2020-01-01T09:00:00)")}},
+		{false, &applicationautoscaling.ScheduledAction{Schedule: aws.String("at(2019-12-31T09:00:00)")}},
+		{false, &applicationautoscaling.ScheduledAction{Schedule: aws.String("at(2019-12-31T18:00:00)"), Timezone: aws.String("America/Toronto")}},
+		{true, &applicationautoscaling.ScheduledAction{Schedule: aws.String("at(2019-12-31T18:00:00)"), Timezone: aws.String("America/Vancouver")}},
+	} {
+		if actual := isUpcoming(arg.action, now); actual != arg.upcoming {
+			t.Errorf("isUpcoming(%s) = %t, want %t", arg.action, actual, arg.upcoming)
+		}
+	}
+}
+
+func TestSnitcher_DescribeScheduledActions(t *testing.T) {
+	sn, fakeECS, fake := NewFakeScheduledScaling(t)
+	actions := sn.DescribeScheduledActions(fakeECS.expectedCluster)
+	if len(actions["web"]) != 1 || len(actions["api"]) != 2 || len(actions) != 2 {
+		t.Errorf("expected 1 upcoming web and 2 api actions in cluster but got %v", actions)
+	}
+	fake.expectedScheduledActions = nil
+	fake.errorToReturn = errors.New("there should be no scheduled actions on error")
+	if actions := sn.DescribeScheduledActions(fakeECS.expectedCluster); len(actions) != 0 {
+		t.Errorf("expected no scheduled actions but got %v", actions)
+	}
+}
+
+func TestSnitcher_DescribeScheduledScaleOuts(t *testing.T) {
+	sn, fakeECS, _ := NewFakeScheduledScaling(t)
+	expected := map[string]int{"web": 8, "api": 2}
+	scaleOuts := sn.DescribeScheduledScaleOuts(fakeECS.expectedCluster)
+	if len(scaleOuts) != len(expected) {
+		t.Errorf("expected %d scale-outs but got %d", len(expected), len(scaleOuts))
+	}
+	for _, scaleOut := range scaleOuts {
+		if expected[scaleOut.Service] != scaleOut.Tasks {
+			t.Errorf("expected %q to scale out by %d but got %+v", scaleOut.Service, expected[scaleOut.Service], *scaleOut)
+		}
+	}
+}
+
+func TestSnitcher_MeasureScheduledScalingShortfall(t *testing.T) {
+	sn, fakeECS, _ := NewFakeScheduledScaling(t)
+	for remaining, shortfall := range map[int]float64{0: 8, 5: 3, 8: 0, 20: 0} {
+		cr := NewClusterResources(fakeECS.expectedCluster)
+		cr.Remaining["fake.2xlarge"] = remaining
+		sn.MeasureScheduledScalingShortfall(cr)
+		if actual := cr.Totals["ScheduledScalingShortfall"]; actual != shortfall {
+			t.Errorf("expected shortfall %v with %d remaining but got %v", shortfall, remaining, actual)
+		}
+	}
+}
+
+func TestSnitcher_MeasureClusterScheduledScaling(t *testing.T) {
+	sn, fakeECS, _ := NewFakeScheduledScaling(t)
+	sn.MeasureScheduledScaling = aws.Bool(true)
+	found := false
+	for _, datum := range sn.MeasureCluster(fakeECS.expectedCluster) {
+		if *datum.MetricName == "ScheduledScalingShortfall" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected ScheduledScalingShortfall among metric data")
+	}
+}
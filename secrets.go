@@ -0,0 +1,78 @@
+package snitch
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+)
+
+// secretARN matches ARNs of Secrets Manager secrets, in any partition.
+var secretARN = regexp.MustCompile(`^arn:aws[a-z-]*:secretsmanager:`)
+
+// GetSecretString reads a Secrets Manager secret, by ID or ARN, as a string,
+// or "" if it can't.
+//
+// Requires IAM permission "secretsmanager:GetSecretValue".
+func (sn *Snitcher) GetSecretString(id string) string {
+	secret, err := sn.getSecretString(id)
+	if err != nil {
+		sn.logger().Errorf("Failed to GetSecretValue of %q: %s", id, err)
+	}
+	return secret
+}
+
+// getSecretString is GetSecretString, returning why it can't.
+func (sn *Snitcher) getSecretString(id string) (string, error) {
+	input := &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(id),
+	}
+	output, err := sn.SecretsManager.GetSecretValue(input)
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(output.SecretString), nil
+}
+
+// ResolveSecrets reads every Sink's secrets once, so they're neither read
+// every run nor written in configuration: Secret, and URL or Address that's a
+// Secrets Manager secret ARN, like a Slack webhook's
+// "arn:aws:secretsmanager:us-east-1:123456789012:secret:snitch/slack-AbCdEf".
+//
+// ResolveSecrets returns why any secret can't be read, leaving its reference
+// in place, after resolving what it can.
+//
+// Requires IAM permission "secretsmanager:GetSecretValue".
+func (sn *Snitcher) ResolveSecrets() (err error) {
+	for _, config := range sn.Sinks {
+		if config.Secret != "" && config.secret == "" {
+			secret, secretErr := sn.getSecretString(config.Secret)
+			if secretErr != nil {
+				err = fmt.Errorf("secret %q of %q sink: %s", config.Secret, config.Type, secretErr)
+				continue
+			}
+			config.secret = secret
+		}
+		for _, field := range []*string{&config.URL, &config.Address} {
+			if !secretARN.MatchString(*field) {
+				continue
+			}
+			secret, secretErr := sn.getSecretString(*field)
+			if secretErr != nil {
+				err = fmt.Errorf("secret %q of %q sink: %s", *field, config.Type, secretErr)
+				continue
+			}
+			*field = secret
+		}
+	}
+	return
+}
+
+// secretOf config reads its Secret, unless ResolveSecrets already has.
+func (sn *Snitcher) secretOf(config *SinkConfig) string {
+	if config.secret != "" {
+		return config.secret
+	}
+	return sn.GetSecretString(config.Secret)
+}
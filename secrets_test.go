@@ -0,0 +1,74 @@
+package snitch
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+)
+
+// FakeSecretsManager mocks Secrets Manager with secrets by ID.
+type FakeSecretsManager struct {
+	secretsmanageriface.SecretsManagerAPI
+	errorToReturn error             // `error` to return from fake methods.
+	secrets       map[string]string // Secret strings by ID.
+}
+
+func (fake *FakeSecretsManager) GetSecretValue(input *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+	if fake.errorToReturn != nil {
+		return nil, fake.errorToReturn
+	}
+	secret, ok := fake.secrets[aws.StringValue(input.SecretId)]
+	if !ok {
+		return nil, errors.New("fake secret not found")
+	}
+	return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(secret)}, nil
+}
+
+func TestSnitcher_GetSecretString(t *testing.T) {
+	fake := &FakeSecretsManager{secrets: map[string]string{"snitch/datadog": "fake-key"}}
+	sn := &Snitcher{SecretsManager: fake}
+	if actual := sn.GetSecretString("snitch/datadog"); actual != "fake-key" {
+		t.Errorf("expected fake-key but got %q", actual)
+	}
+	if actual := sn.GetSecretString("snitch/missing"); actual != "" {
+		t.Errorf("expected nothing for missing secret but got %q", actual)
+	}
+}
+
+func TestSnitcher_ResolveSecrets(t *testing.T) {
+	slackARN := "arn:aws:secretsmanager:us-east-1:123456789012:secret:snitch/slack-AbCdEf"
+	fake := &FakeSecretsManager{secrets: map[string]string{
+		"snitch/datadog": "fake-key",
+		slackARN:         "https://hooks.slack.example/fake",
+	}}
+	datadog := &SinkConfig{Type: "datadog", Secret: "snitch/datadog"}
+	slack := &SinkConfig{Type: "slack", URL: slackARN}
+	statsd := &SinkConfig{Type: "statsd", Address: "localhost:8125"}
+	sn := &Snitcher{SecretsManager: fake, Sinks: []*SinkConfig{datadog, slack, statsd}}
+	if err := sn.ResolveSecrets(); err != nil {
+		t.Fatal(err)
+	}
+	if datadog.secret != "fake-key" || datadog.Secret != "snitch/datadog" {
+		t.Errorf("expected Secret read once, kept by ID, but got %+v", datadog)
+	}
+	if slack.URL != "https://hooks.slack.example/fake" || statsd.Address != "localhost:8125" {
+		t.Errorf("expected secret ARNs, and only them, replaced but got %q and %q", slack.URL, statsd.Address)
+	}
+	// Resolved secrets aren't read again.
+	fake.errorToReturn = errors.New("fake")
+	if err := sn.ResolveSecrets(); err != nil {
+		t.Errorf("expected resolved secrets not read again but got %s", err)
+	}
+	if actual := sn.secretOf(datadog); actual != "fake-key" {
+		t.Errorf("expected resolved fake-key but got %q", actual)
+	}
+
+	missing := &SinkConfig{Type: "webhook", URL: "arn:aws:secretsmanager:us-east-1:123456789012:secret:missing"}
+	sn.Sinks = append(sn.Sinks, missing)
+	if err := sn.ResolveSecrets(); err == nil || missing.URL == "" {
+		t.Errorf("expected unreadable secret to fail, leaving its ARN, but got %v and %q", err, missing.URL)
+	}
+}
@@ -0,0 +1,293 @@
+package snitch
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// Sink receives measured metrics, like CloudWatch does when publishing.
+type Sink interface {
+	Send(metricData []*cloudwatch.MetricDatum) error
+}
+
+// runSink is a Sink keeping state between runs of RunEvery, told when each
+// begins and is done, like API.
+type runSink interface {
+	begin()
+	done()
+}
+
+// SinkConfig declares a Sink, by type, and which metrics it's sent, like:
+//
+//	{"type": "cloudwatch", "namespace": "Team/Capacity",
+//	 "metrics": ["Remaining*"], "dimensions": {"InstanceType": ""}}
+type SinkConfig struct {
+	// Type of Sink, among SinkTypes, like "cloudwatch".
+	Type string `json:"type"`
+	// Namespace of CloudWatch metrics. Other types may ignore it.
+	Namespace string `json:"namespace,omitempty"`
+	// Names of metrics sent, like "RemainingSchedulable", glob "Remaining*" or
+	// regexp "/^Remaining/". Empty means all.
+	Metrics []string `json:"metrics,omitempty"`
+	// Dimensions renamed, like "ClusterName" to "Cluster", or dropped if
+	// renamed to "".
+	Dimensions map[string]string `json:"dimensions,omitempty"`
+	// Address, like "localhost:8125", of sinks sent over the network.
+	Address string `json:"address,omitempty"`
+	// URL of sinks sent over HTTP, if not their default. "sns" sinks take
+	// their topic's ARN here instead, like
+	// "arn:aws:sns:us-east-1:123456789012:capacity", and "eventbridge" sinks
+	// their event bus's name or ARN, or "" for the default bus.
+	URL string `json:"url,omitempty"`
+	// DynamoDB table of history, and how long history lasts, like "720h".
+	// Empty TTL means forever.
+	Table string `json:"table,omitempty"`
+	TTL   string `json:"ttl,omitempty"`
+	// Thresholds of headroom, by cluster name, glob or /regexp/, below which
+	// alerting sinks notify. "*" is every cluster's unless named otherwise.
+	Thresholds map[string]float64 `json:"thresholds,omitempty"`
+	// Whether to gzip what the sink writes, like S3 snapshots.
+	Gzip bool `json:"gzip,omitempty"`
+	// Secrets Manager secret ID of the sink's API key, webhook URL or signing
+	// key, if not in the environment. URL and Address may be secret ARNs too.
+	Secret string `json:"secret,omitempty"`
+	// secret is Secret's value, once ResolveSecrets has read it.
+	secret string
+	// How many times to retry failed requests, for sinks sent over HTTP.
+	Retries int `json:"retries,omitempty"`
+	// How long requests of sinks sent over HTTP take at most, like "30s".
+	// Default is 10 seconds.
+	Timeout string `json:"timeout,omitempty"`
+	// Sink, if set, is sent metrics instead of a new one of Type, like when
+	// the sink keeps state between runs.
+	Sink Sink `json:"-"`
+}
+
+// defaultSinkTimeout is how long requests of sinks sent over HTTP take at
+// most, unless their SinkConfig's Timeout says otherwise.
+const defaultSinkTimeout = 10 * time.Second
+
+// sinkClient is shared by sinks sent over HTTP without their own Timeout.
+var sinkClient = &http.Client{Timeout: defaultSinkTimeout}
+
+// client sends config's sink's requests over HTTP, timing out after Timeout,
+// which send has checked parses.
+func (config *SinkConfig) client() *http.Client {
+	timeout, err := time.ParseDuration(config.Timeout)
+	if config.Timeout == "" || err != nil {
+		return sinkClient
+	}
+	return &http.Client{Timeout: timeout}
+}
+
+// SinkTypes creates Sinks by SinkConfig.Type.
+var SinkTypes = map[string]func(sn *Snitcher, config *SinkConfig) Sink{
+	"cloudwatch": func(sn *Snitcher, config *SinkConfig) Sink {
+		return &CloudWatchSink{Snitcher: sn, Namespace: aws.String(config.Namespace)}
+	},
+	"s3": func(sn *Snitcher, config *SinkConfig) Sink {
+		return sn.newS3Sink(config)
+	},
+	"slack": func(sn *Snitcher, config *SinkConfig) Sink {
+		sink := &SlackSink{URL: config.URL, Thresholds: config.Thresholds, Client: config.client()}
+		if config.Secret != "" {
+			sink.URL = sn.secretOf(config)
+		}
+		return sink
+	},
+	"sns": func(sn *Snitcher, config *SinkConfig) Sink {
+		return &SNSSink{Snitcher: sn, TopicARN: config.URL, Thresholds: config.Thresholds}
+	},
+	"sqs": func(sn *Snitcher, config *SinkConfig) Sink {
+		return &SQSSink{Snitcher: sn, QueueURL: config.URL}
+	},
+	"statsd": func(sn *Snitcher, config *SinkConfig) Sink {
+		return &StatsDSink{Address: config.Address, Prefix: config.Namespace}
+	},
+	"dogstatsd": func(sn *Snitcher, config *SinkConfig) Sink {
+		return &StatsDSink{Address: config.Address, Prefix: config.Namespace, Tags: true}
+	},
+	"csv": func(sn *Snitcher, config *SinkConfig) Sink {
+		return &CSVSink{}
+	},
+	"datadog": func(sn *Snitcher, config *SinkConfig) Sink {
+		return sn.newDatadogSink(config)
+	},
+	"dynamodb": func(sn *Snitcher, config *SinkConfig) Sink {
+		var ttl time.Duration
+		if config.TTL != "" {
+			var err error
+			if ttl, err = time.ParseDuration(config.TTL); err != nil {
+				return invalidSink{fmt.Errorf("failed to parse TTL %q of %q: %s", config.TTL, config.Table, err)}
+			}
+		}
+		return &DynamoDBSink{Snitcher: sn, Table: config.Table, TTL: ttl}
+	},
+	"emf": func(sn *Snitcher, config *SinkConfig) Sink {
+		return &EMFSink{Namespace: config.Namespace}
+	},
+	"eventbridge": func(sn *Snitcher, config *SinkConfig) Sink {
+		return &EventBridgeSink{Snitcher: sn, EventBus: config.URL, Thresholds: config.Thresholds}
+	},
+	"webhook": func(sn *Snitcher, config *SinkConfig) Sink {
+		sink := &WebhookSink{URL: config.URL, Retries: config.Retries, Backoff: time.Second, Client: config.client(), Logger: sn.logger()}
+		if config.Secret != "" {
+			// Posting unsigned would be refused, or worse, trusted.
+			if sink.SigningKey = sn.secretOf(config); sink.SigningKey == "" {
+				return invalidSink{fmt.Errorf("no signing key in secret %q", config.Secret)}
+			}
+		}
+		return sink
+	},
+	"json": func(sn *Snitcher, config *SinkConfig) Sink {
+		return &JSONSink{}
+	},
+	"otlp": func(sn *Snitcher, config *SinkConfig) Sink {
+		return NewOTLPSink(config)
+	},
+	"prometheus": func(sn *Snitcher, config *SinkConfig) Sink {
+		return sn.exporterAt(config.Address)
+	},
+}
+
+// reportSinkTypes are SinkTypes reporting on whole clusters, like headroom
+// summed across instance types, which metrics or dimensions left out would
+// get wrong. They take neither SinkConfig.Metrics nor Dimensions.
+var reportSinkTypes = map[string]bool{
+	"csv":         true,
+	"dynamodb":    true,
+	"eventbridge": true,
+	"json":        true,
+	"s3":          true,
+	"slack":       true,
+	"sns":         true,
+	"sqs":         true,
+	"webhook":     true,
+}
+
+// streamlessSinkTypes are SinkTypes writing, or keeping, one document per run,
+// which streaming a cluster at a time would split or overwrite.
+var streamlessSinkTypes = map[string]bool{
+	"csv":        true,
+	"json":       true,
+	"prometheus": true,
+}
+
+// invalidSink is of a SinkConfig that can't be sent to, failing every Send
+// with err.
+type invalidSink struct {
+	err error
+}
+
+func (sink invalidSink) Send(metricData []*cloudwatch.MetricDatum) error {
+	return sink.err
+}
+
+// CloudWatchSink publishes metrics to Namespace like Publish does.
+type CloudWatchSink struct {
+	Snitcher  *Snitcher
+	Namespace *string
+}
+
+// Send publishes metrics to CloudWatch.
+func (sink *CloudWatchSink) Send(metricData []*cloudwatch.MetricDatum) error {
+	return sink.Snitcher.publish(sink.Namespace, metricData)
+}
+
+// filter copies the metrics config sends, with dimensions renamed.
+func (config *SinkConfig) filter(metricData []*cloudwatch.MetricDatum) (filtered []*cloudwatch.MetricDatum) {
+	for _, datum := range metricData {
+		if !config.sends(aws.StringValue(datum.MetricName)) {
+			continue
+		}
+		copied := *datum
+		if len(config.Dimensions) > 0 {
+			copied.Dimensions = nil
+			for _, dimension := range datum.Dimensions {
+				name, ok := config.Dimensions[aws.StringValue(dimension.Name)]
+				if !ok {
+					copied.Dimensions = append(copied.Dimensions, dimension)
+				} else if name != "" {
+					copied.Dimensions = append(copied.Dimensions, &cloudwatch.Dimension{Name: aws.String(name), Value: dimension.Value})
+				}
+			}
+		}
+		filtered = append(filtered, &copied)
+	}
+	return
+}
+
+// sends determines whether config sends metricName.
+func (config *SinkConfig) sends(metricName string) bool {
+	if len(config.Metrics) == 0 {
+		return true
+	}
+	for _, pattern := range config.Metrics {
+		if matches(pattern, metricName) {
+			return true
+		}
+	}
+	return false
+}
+
+// Send metrics to every one of Sinks concurrently. One Sink failing, even by
+// panicking, doesn't keep metrics from the others.
+//
+// Send returns how many Sinks failed.
+func (sn *Snitcher) Send(metricData []*cloudwatch.MetricDatum) (failed int) {
+	if sn.sinkRole != "" {
+		metricData = withAccountCopied(metricData, sn.sinkRole)
+	}
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	for _, config := range sn.Sinks {
+		wg.Add(1)
+		go func(config *SinkConfig) {
+			defer wg.Done()
+			if err := sn.send(config, metricData); err != nil {
+				sn.logger().Errorf("Failed to send metrics to %q sink: %s", config.Type, err)
+				sn.Summary.publishFailed("Failed to send metrics to %q sink: %s", config.Type, err)
+				mutex.Lock()
+				defer mutex.Unlock()
+				failed++
+			}
+		}(config)
+	}
+	wg.Wait()
+	return
+}
+
+// send metrics config sends to the Sink it declares.
+func (sn *Snitcher) send(config *SinkConfig, metricData []*cloudwatch.MetricDatum) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panicked: %v", r)
+		}
+	}()
+	if reportSinkTypes[config.Type] && (len(config.Metrics) > 0 || len(config.Dimensions) > 0) {
+		return fmt.Errorf("%q sinks report whole clusters, so take neither metrics nor dimensions", config.Type)
+	}
+	if config.Timeout != "" {
+		if _, err := time.ParseDuration(config.Timeout); err != nil {
+			return fmt.Errorf("failed to parse timeout %q: %s", config.Timeout, err)
+		}
+	}
+	sink := config.Sink
+	if sink == nil {
+		newSink, ok := SinkTypes[config.Type]
+		if !ok {
+			return fmt.Errorf("unknown sink type %q", config.Type)
+		}
+		sink = newSink(sn, config)
+	}
+	filtered := config.filter(metricData)
+	if len(filtered) == 0 {
+		return nil
+	}
+	return sink.Send(filtered)
+}
@@ -0,0 +1,115 @@
+package snitch
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// FakeSink records metrics it's sent, unless it fails or panics.
+type FakeSink struct {
+	sent          []*cloudwatch.MetricDatum
+	errorToReturn error
+	panics        bool
+}
+
+func (fake *FakeSink) Send(metricData []*cloudwatch.MetricDatum) error {
+	if fake.panics {
+		panic("fake sink panicked")
+	}
+	fake.sent = metricData
+	return fake.errorToReturn
+}
+
+// withFakeSinks registers fakes as SinkTypes by name until calling unregister.
+func withFakeSinks(fakes map[string]*FakeSink) (unregister func()) {
+	for name, fake := range fakes {
+		fake := fake
+		SinkTypes[name] = func(*Snitcher, *SinkConfig) Sink { return fake }
+	}
+	return func() {
+		for name := range fakes {
+			delete(SinkTypes, name)
+		}
+	}
+}
+
+func TestSnitcher_Send(t *testing.T) {
+	good, failing, panicking := &FakeSink{}, &FakeSink{errorToReturn: errors.New("fake")}, &FakeSink{panics: true}
+	defer withFakeSinks(map[string]*FakeSink{"good": good, "failing": failing, "panicking": panicking})()
+	sn := &Snitcher{Sinks: []*SinkConfig{
+		{Type: "good", Metrics: []string{"Remaining*"}, Dimensions: map[string]string{"ClusterName": "Cluster", "InstanceType": ""}},
+		{Type: "failing"},
+		{Type: "panicking"},
+		{Type: "missing"},
+	}}
+	cr := NewClusterResources(aws.String("fake-cluster"))
+	cr.Registered["fake.large"] = 4
+	cr.Remaining["fake.large"] = 2
+	metricData := cr.ToMetricData()
+	if failed := sn.Send(metricData); failed != 3 {
+		t.Errorf("expected 3 failed sinks but got %d", failed)
+	}
+	if len(good.sent) != 1 {
+		t.Fatalf("expected only RemainingSchedulable sent but got %v", good.sent)
+	}
+	dimensions := good.sent[0].Dimensions
+	if len(dimensions) != 1 || aws.StringValue(dimensions[0].Name) != "Cluster" || aws.StringValue(dimensions[0].Value) != "fake-cluster" {
+		t.Errorf("expected ClusterName renamed and InstanceType dropped but got %v", dimensions)
+	}
+	for _, datum := range metricData {
+		if len(datum.Dimensions) != 2 || aws.StringValue(datum.Dimensions[0].Name) != "ClusterName" {
+			t.Errorf("expected measured metrics unchanged but got %v", datum)
+		}
+	}
+}
+
+func TestSnitcher_SendReportFiltered(t *testing.T) {
+	fake := &FakeSink{}
+	sn := &Snitcher{Sinks: []*SinkConfig{
+		{Type: "slack", Metrics: []string{"RemainingSchedulable"}, Sink: fake},
+		{Type: "json", Dimensions: map[string]string{"InstanceType": ""}, Sink: fake},
+		{Type: "sns", Sink: fake},
+	}}
+	cr := NewClusterResources(aws.String("fake-cluster"))
+	cr.Remaining["fake.large"] = 2
+	if failed := sn.Send(cr.ToMetricData()); failed != 2 {
+		t.Errorf("expected report sinks with filters to fail but got %d failed", failed)
+	}
+	if len(fake.sent) != len(cr.ToMetricData()) {
+		t.Errorf("expected every metric sent to unfiltered report sink but got %v", fake.sent)
+	}
+}
+
+func TestSinkConfig_client(t *testing.T) {
+	if client := (&SinkConfig{}).client(); client != sinkClient || client.Timeout != defaultSinkTimeout {
+		t.Errorf("expected the shared client timing out by default but got %+v", client)
+	}
+	if client := (&SinkConfig{Timeout: "30s"}).client(); client == sinkClient || client.Timeout != 30*time.Second {
+		t.Errorf("expected a client timing out after 30s but got %+v", client)
+	}
+	sn := &Snitcher{Sinks: []*SinkConfig{{Type: "webhook", Timeout: "soon", Sink: &FakeSink{}}}}
+	if failed := sn.Send([]*cloudwatch.MetricDatum{{MetricName: aws.String("RemainingSchedulable")}}); failed != 1 {
+		t.Errorf("expected a sink with an unparsable timeout to fail but got %d failed", failed)
+	}
+}
+
+func TestCloudWatchSink(t *testing.T) {
+	fake := &FakeCloudWatch{}
+	sn := &Snitcher{CloudWatch: fake, Sinks: []*SinkConfig{{Type: "cloudwatch", Namespace: "Team/Capacity"}}}
+	cr := NewClusterResources(aws.String("fake-cluster"))
+	cr.Remaining["fake.large"] = 2
+	if failed := sn.Send(cr.ToMetricData()); failed != 0 {
+		t.Errorf("expected no failed sinks but got %d", failed)
+	}
+	if len(fake.payload) != 1 || aws.StringValue(fake.payload[0].Namespace) != "Team/Capacity" {
+		t.Errorf("expected metrics published to Team/Capacity but got %v", fake.payload)
+	}
+	fake.errorToReturn = errors.New("fake")
+	if failed := sn.Send(cr.ToMetricData()); failed != 1 {
+		t.Errorf("expected failed sink but got %d", failed)
+	}
+}
@@ -0,0 +1,84 @@
+package snitch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// SlackSink posts a summary of clusters below their Thresholds, with their
+// headroom per instance type, to a Slack incoming webhook at URL. Nothing's
+// posted when every cluster has room.
+type SlackSink struct {
+	URL        string
+	Thresholds map[string]float64
+	Client     *http.Client
+}
+
+// Send a summary of Breaches to Slack, if any.
+func (sink *SlackSink) Send(metricData []*cloudwatch.MetricDatum) error {
+	if sink.URL == "" {
+		return errors.New("no Slack webhook URL")
+	}
+	breaches := Breaches(Report(metricData), sink.Thresholds)
+	if len(breaches) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(map[string]string{"text": SlackSummary(breaches)})
+	if err != nil {
+		return err
+	}
+	response, err := sink.Client.Post(sink.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		return fmt.Errorf("Slack responded %s", response.Status)
+	}
+	return nil
+}
+
+// SlackSummary formats breaches in Slack's mrkdwn, like:
+//
+//	:warning: *1 ECS cluster below its headroom target*
+//	• *prod-api* (123456789012/us-east-1): 6 schedulable, target 10
+//	    c5.large: 4, c5.xlarge: 2
+func SlackSummary(breaches []*Breach) string {
+	clusters := "clusters below their headroom targets"
+	if len(breaches) == 1 {
+		clusters = "cluster below its headroom target"
+	}
+	lines := []string{fmt.Sprintf(":warning: *%d ECS %s*", len(breaches), clusters)}
+	for _, breach := range breaches {
+		where := ""
+		var identity []string
+		for _, dimension := range []string{"AccountId", "Region"} {
+			if value, ok := breach.Dimensions[dimension]; ok {
+				identity = append(identity, value)
+			}
+		}
+		if len(identity) > 0 {
+			where = " (" + strings.Join(identity, "/") + ")"
+		}
+		lines = append(lines, fmt.Sprintf("• *%s*%s: %g schedulable, target %g", breach.Cluster, where, breach.Value, breach.Threshold))
+		var instanceTypes []string
+		for instanceType := range breach.InstanceTypes {
+			instanceTypes = append(instanceTypes, instanceType)
+		}
+		sort.Strings(instanceTypes)
+		for i, instanceType := range instanceTypes {
+			instanceTypes[i] = fmt.Sprintf("%s: %g", instanceType, breach.InstanceTypes[instanceType])
+		}
+		if len(instanceTypes) > 0 {
+			lines = append(lines, "    "+strings.Join(instanceTypes, ", "))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,57 @@
+package snitch
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestSlackSummary(t *testing.T) {
+	breaches := []*Breach{{
+		Cluster:       "prod-api",
+		Dimensions:    map[string]string{"AccountId": "123456789012", "Region": "us-east-1"},
+		Metric:        "RemainingSchedulable",
+		Value:         6,
+		Threshold:     10,
+		InstanceTypes: map[string]float64{"c5.xlarge": 2, "c5.large": 4},
+	}}
+	expected := ":warning: *1 ECS cluster below its headroom target*\n" +
+		"• *prod-api* (123456789012/us-east-1): 6 schedulable, target 10\n" +
+		"    c5.large: 4, c5.xlarge: 2"
+	if actual := SlackSummary(breaches); actual != expected {
+		t.Errorf("expected:\n%s\nbut got:\n%s", expected, actual)
+	}
+}
+
+func TestSlackSink(t *testing.T) {
+	var posted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			t.Error(err)
+		}
+		posted = append(posted, message["text"])
+	}))
+	defer server.Close()
+	sn := &Snitcher{SecretsManager: &FakeSecretsManager{secrets: map[string]string{"snitch/slack": server.URL}}}
+	sink := SinkTypes["slack"](sn, &SinkConfig{Secret: "snitch/slack", Thresholds: map[string]float64{"*": 5}})
+	cr := NewClusterResources(aws.String("fake-cluster"))
+	cr.Remaining["fake.large"] = 10
+	if err := sink.Send(cr.ToMetricData()); err != nil || len(posted) != 0 {
+		t.Errorf("expected nothing posted with room to spare but got %v, %v", posted, err)
+	}
+	cr.Remaining["fake.large"] = 1
+	metricData := cr.ToMetricData()
+	if err := sink.Send(metricData); err != nil {
+		t.Fatal(err)
+	}
+	if len(posted) != 1 {
+		t.Errorf("expected a summary posted but got %v", posted)
+	}
+	if err := (&SlackSink{}).Send(metricData); err == nil {
+		t.Error("expected an error without a webhook URL")
+	}
+}
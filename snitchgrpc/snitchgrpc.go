@@ -0,0 +1,70 @@
+// Package snitchgrpc serves an API's capacity queries over gRPC, as
+// snitchpb.SnitchServer:
+//
+//	api := &snitch.API{}
+//	sn.Sinks = append(sn.Sinks, &snitch.SinkConfig{Type: "api", Sink: api})
+//	go snitchgrpc.NewServer(api).Serve(listener)
+package snitchgrpc
+
+import (
+	"context"
+
+	"github.com/shatil/snitch"
+	"github.com/shatil/snitch/snitchpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server answers capacity queries with reports API is sent.
+type Server struct {
+	snitchpb.UnimplementedSnitchServer
+	API *snitch.API
+}
+
+// NewServer serves api's capacity queries over gRPC.
+func NewServer(api *snitch.API) *grpc.Server {
+	server := grpc.NewServer()
+	snitchpb.RegisterSnitchServer(server, &Server{API: api})
+	return server
+}
+
+// ListClusterCapacity responds with every cluster's latest report.
+func (server *Server) ListClusterCapacity(ctx context.Context, request *snitchpb.ListClusterCapacityRequest) (*snitchpb.ListClusterCapacityResponse, error) {
+	response := &snitchpb.ListClusterCapacityResponse{}
+	for _, report := range server.API.Reports() {
+		response.Clusters = append(response.Clusters, capacity(report))
+	}
+	return response, nil
+}
+
+// GetClusterCapacity responds with a cluster's latest report, by its Key.
+func (server *Server) GetClusterCapacity(ctx context.Context, request *snitchpb.GetClusterCapacityRequest) (*snitchpb.ClusterCapacity, error) {
+	report := server.API.Report(request.GetKey())
+	if report == nil {
+		return nil, status.Errorf(codes.NotFound, "no such cluster: %s", request.GetKey())
+	}
+	return capacity(report), nil
+}
+
+// capacity converts report to its gRPC message.
+func capacity(report *snitch.ClusterReport) *snitchpb.ClusterCapacity {
+	metrics := func(byName map[string]map[string]float64) map[string]*snitchpb.Metrics {
+		converted := map[string]*snitchpb.Metrics{}
+		for name, values := range byName {
+			converted[name] = &snitchpb.Metrics{Metrics: values}
+		}
+		return converted
+	}
+	return &snitchpb.ClusterCapacity{
+		Key:           report.Key(),
+		Cluster:       report.Cluster,
+		Timestamp:     timestamppb.New(report.Timestamp),
+		Dimensions:    report.Dimensions,
+		InstanceTypes: metrics(report.InstanceTypes),
+		Subnets:       metrics(report.Subnets),
+		Totals:        report.Totals,
+		Units:         report.Units,
+	}
+}
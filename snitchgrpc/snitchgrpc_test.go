@@ -0,0 +1,58 @@
+package snitchgrpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/shatil/snitch"
+	"github.com/shatil/snitch/snitchpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestNewServer(t *testing.T) {
+	api := &snitch.API{}
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(api)
+	go server.Serve(listener)
+	defer server.Stop()
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := snitchpb.NewSnitchClient(conn)
+	ctx := context.Background()
+
+	cr := snitch.NewClusterResources(aws.String("fake-cluster"))
+	cr.Remaining["fake.large"] = 2
+	cr.ClusterDimensions = []*cloudwatch.Dimension{{Name: aws.String("Region"), Value: aws.String("us-east-1")}}
+	api.Send(cr.ToMetricData())
+
+	list, err := client.ListClusterCapacity(ctx, &snitchpb.ListClusterCapacityRequest{})
+	if err != nil || len(list.GetClusters()) != 1 {
+		t.Fatalf("expected fake-cluster listed but got %v: %s", list, err)
+	}
+	capacity, err := client.GetClusterCapacity(ctx, &snitchpb.GetClusterCapacityRequest{Key: "us-east-1/fake-cluster"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if capacity.GetCluster() != "fake-cluster" || capacity.GetInstanceTypes()["fake.large"].GetMetrics()["RemainingSchedulable"] != 2 {
+		t.Errorf("expected fake-cluster's capacity but got %v", capacity)
+	}
+	if capacity.GetDimensions()["Region"] != "us-east-1" || capacity.GetTimestamp().AsTime().IsZero() {
+		t.Errorf("expected dimensions and timestamp but got %v", capacity)
+	}
+	_, err = client.GetClusterCapacity(ctx, &snitchpb.GetClusterCapacityRequest{Key: "missing-cluster"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound but got %s", err)
+	}
+}
@@ -0,0 +1,3 @@
+// Package snitchpb is the gRPC contract of snitch's capacity queries,
+// generated from snitch.proto by `make proto`.
+package snitchpb
@@ -0,0 +1,171 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: snitchpb/snitch.proto
+
+package snitchpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Snitch_ListClusterCapacity_FullMethodName = "/snitch.v1.Snitch/ListClusterCapacity"
+	Snitch_GetClusterCapacity_FullMethodName  = "/snitch.v1.Snitch/GetClusterCapacity"
+)
+
+// SnitchClient is the client API for Snitch service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Snitch answers capacity queries from the measurements of `snitch daemon`.
+type SnitchClient interface {
+	// ListClusterCapacity returns every measured cluster's capacity, ordered by
+	// cluster name.
+	ListClusterCapacity(ctx context.Context, in *ListClusterCapacityRequest, opts ...grpc.CallOption) (*ListClusterCapacityResponse, error)
+	// GetClusterCapacity returns a cluster's capacity, or NOT_FOUND if it hasn't
+	// been measured.
+	GetClusterCapacity(ctx context.Context, in *GetClusterCapacityRequest, opts ...grpc.CallOption) (*ClusterCapacity, error)
+}
+
+type snitchClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSnitchClient(cc grpc.ClientConnInterface) SnitchClient {
+	return &snitchClient{cc}
+}
+
+func (c *snitchClient) ListClusterCapacity(ctx context.Context, in *ListClusterCapacityRequest, opts ...grpc.CallOption) (*ListClusterCapacityResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListClusterCapacityResponse)
+	err := c.cc.Invoke(ctx, Snitch_ListClusterCapacity_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *snitchClient) GetClusterCapacity(ctx context.Context, in *GetClusterCapacityRequest, opts ...grpc.CallOption) (*ClusterCapacity, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ClusterCapacity)
+	err := c.cc.Invoke(ctx, Snitch_GetClusterCapacity_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SnitchServer is the server API for Snitch service.
+// All implementations must embed UnimplementedSnitchServer
+// for forward compatibility.
+//
+// Snitch answers capacity queries from the measurements of `snitch daemon`.
+type SnitchServer interface {
+	// ListClusterCapacity returns every measured cluster's capacity, ordered by
+	// cluster name.
+	ListClusterCapacity(context.Context, *ListClusterCapacityRequest) (*ListClusterCapacityResponse, error)
+	// GetClusterCapacity returns a cluster's capacity, or NOT_FOUND if it hasn't
+	// been measured.
+	GetClusterCapacity(context.Context, *GetClusterCapacityRequest) (*ClusterCapacity, error)
+	mustEmbedUnimplementedSnitchServer()
+}
+
+// UnimplementedSnitchServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSnitchServer struct{}
+
+func (UnimplementedSnitchServer) ListClusterCapacity(context.Context, *ListClusterCapacityRequest) (*ListClusterCapacityResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListClusterCapacity not implemented")
+}
+func (UnimplementedSnitchServer) GetClusterCapacity(context.Context, *GetClusterCapacityRequest) (*ClusterCapacity, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetClusterCapacity not implemented")
+}
+func (UnimplementedSnitchServer) mustEmbedUnimplementedSnitchServer() {}
+func (UnimplementedSnitchServer) testEmbeddedByValue()                {}
+
+// UnsafeSnitchServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SnitchServer will
+// result in compilation errors.
+type UnsafeSnitchServer interface {
+	mustEmbedUnimplementedSnitchServer()
+}
+
+func RegisterSnitchServer(s grpc.ServiceRegistrar, srv SnitchServer) {
+	// If the following call pancis, it indicates UnimplementedSnitchServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Snitch_ServiceDesc, srv)
+}
+
+func _Snitch_ListClusterCapacity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListClusterCapacityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SnitchServer).ListClusterCapacity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Snitch_ListClusterCapacity_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SnitchServer).ListClusterCapacity(ctx, req.(*ListClusterCapacityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Snitch_GetClusterCapacity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetClusterCapacityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SnitchServer).GetClusterCapacity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Snitch_GetClusterCapacity_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SnitchServer).GetClusterCapacity(ctx, req.(*GetClusterCapacityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Snitch_ServiceDesc is the grpc.ServiceDesc for Snitch service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Snitch_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "snitch.v1.Snitch",
+	HandlerType: (*SnitchServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListClusterCapacity",
+			Handler:    _Snitch_ListClusterCapacity_Handler,
+		},
+		{
+			MethodName: "GetClusterCapacity",
+			Handler:    _Snitch_GetClusterCapacity_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "snitchpb/snitch.proto",
+}
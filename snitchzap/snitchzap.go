@@ -0,0 +1,18 @@
+// Package snitchzap logs snitch's logs with zap, at their levels:
+//
+//	sn.Logger = snitchzap.New(logger)
+package snitchzap
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/shatil/snitch"
+)
+
+// SugaredLogger already has snitch.Logger's methods.
+var _ snitch.Logger = (*zap.SugaredLogger)(nil)
+
+// New is a snitch.Logger logging to logger.
+func New(logger *zap.Logger) snitch.Logger {
+	return logger.WithOptions(zap.AddCallerSkip(1)).Sugar()
+}
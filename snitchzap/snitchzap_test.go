@@ -0,0 +1,23 @@
+package snitchzap
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNew(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := New(zap.New(core))
+	logger.Debugf("hidden")
+	logger.Warnf("Dropping %q", "metric")
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry but got %+v", entries)
+	}
+	if entries[0].Level != zapcore.WarnLevel || entries[0].Message != `Dropping "metric"` {
+		t.Errorf("expected a warning but got %+v", entries[0])
+	}
+}
@@ -0,0 +1,38 @@
+// Package snitchzerolog logs snitch's logs with zerolog, at their levels:
+//
+//	sn.Logger = snitchzerolog.New(log.Logger)
+package snitchzerolog
+
+import (
+	"github.com/rs/zerolog"
+
+	"github.com/shatil/snitch"
+)
+
+// Logger is a snitch.Logger logging to zerolog.Logger.
+type Logger struct {
+	zerolog.Logger
+}
+
+var _ snitch.Logger = (*Logger)(nil)
+
+// New is a snitch.Logger logging to logger.
+func New(logger zerolog.Logger) *Logger {
+	return &Logger{Logger: logger}
+}
+
+func (logger *Logger) Debugf(format string, v ...interface{}) {
+	logger.Logger.Debug().Msgf(format, v...)
+}
+
+func (logger *Logger) Infof(format string, v ...interface{}) {
+	logger.Logger.Info().Msgf(format, v...)
+}
+
+func (logger *Logger) Warnf(format string, v ...interface{}) {
+	logger.Logger.Warn().Msgf(format, v...)
+}
+
+func (logger *Logger) Errorf(format string, v ...interface{}) {
+	logger.Logger.Error().Msgf(format, v...)
+}
@@ -0,0 +1,19 @@
+package snitchzerolog
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestNew(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New(zerolog.New(&buffer).Level(zerolog.InfoLevel))
+	logger.Debugf("hidden")
+	logger.Errorf("Failed to %s", "publish")
+	expected := `{"level":"error","message":"Failed to publish"}` + "\n"
+	if buffer.String() != expected {
+		t.Errorf("expected %q but got %q", expected, buffer.String())
+	}
+}
@@ -0,0 +1,55 @@
+package snitch
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/sns"
+)
+
+// snsSubjectLength is the most characters SNS takes in a Subject.
+const snsSubjectLength = 99
+
+// SNSSink notifies TopicARN of each cluster whose headroom is below its
+// threshold, as a Breach in JSON with a "Cluster" attribute to filter on.
+type SNSSink struct {
+	Snitcher   *Snitcher
+	TopicARN   string
+	Thresholds map[string]float64
+}
+
+// Send notifications of Breaches, if any. All are tried even if some fail,
+// and the last failure is returned.
+//
+// Requires IAM permission "sns:Publish".
+func (sink *SNSSink) Send(metricData []*cloudwatch.MetricDatum) (err error) {
+	if sink.TopicARN == "" {
+		return errors.New("no SNS topic ARN")
+	}
+	for _, breach := range Breaches(Report(metricData), sink.Thresholds) {
+		message, marshalErr := json.Marshal(breach)
+		if marshalErr != nil {
+			err = marshalErr
+			continue
+		}
+		subject := "snitch: " + breach.String()
+		if runes := []rune(subject); len(runes) > snsSubjectLength {
+			subject = string(runes[:snsSubjectLength])
+		}
+		input := &sns.PublishInput{
+			TopicArn: aws.String(sink.TopicARN),
+			Subject:  aws.String(subject),
+			Message:  aws.String(string(message)),
+			MessageAttributes: map[string]*sns.MessageAttributeValue{
+				"Cluster": {DataType: aws.String("String"), StringValue: aws.String(breach.Cluster)},
+			},
+		}
+		if _, publishErr := sink.Snitcher.SNS.Publish(input); publishErr != nil {
+			sink.Snitcher.logger().Errorf("Failed to notify %q of %s: %s", sink.TopicARN, breach, publishErr)
+			err = publishErr
+		}
+	}
+	return
+}
@@ -0,0 +1,69 @@
+package snitch
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+)
+
+// FakeSNS mocks SNS, keeping notifications published.
+type FakeSNS struct {
+	snsiface.SNSAPI
+	errorToReturn error               // `error` to return from fake methods.
+	published     []*sns.PublishInput // Stores supplied `*PublishInput`.
+}
+
+func (fake *FakeSNS) Publish(input *sns.PublishInput) (*sns.PublishOutput, error) {
+	fake.published = append(fake.published, input)
+	return &sns.PublishOutput{}, fake.errorToReturn
+}
+
+func TestSNSSink(t *testing.T) {
+	fake := &FakeSNS{}
+	sn := &Snitcher{SNS: fake}
+	sink := SinkTypes["sns"](sn, &SinkConfig{URL: "arn:aws:sns:us-east-1:123456789012:snitch", Thresholds: map[string]float64{"*": 5}})
+	full, roomy := NewClusterResources(aws.String("full-cluster")), NewClusterResources(aws.String("roomy-cluster"))
+	full.Remaining["fake.large"] = 2
+	roomy.Remaining["fake.large"] = 20
+	if err := sink.Send(append(full.ToMetricData(), roomy.ToMetricData()...)); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.published) != 1 {
+		t.Fatalf("expected only full-cluster notified but got %v", fake.published)
+	}
+	var breach Breach
+	if err := json.Unmarshal([]byte(aws.StringValue(fake.published[0].Message)), &breach); err != nil {
+		t.Fatal(err)
+	}
+	if breach.Cluster != "full-cluster" || breach.Value != 2 || breach.Threshold != 5 {
+		t.Errorf("expected full-cluster's 2 below 5 but got %+v", breach)
+	}
+	if expected := "snitch: full-cluster RemainingSchedulable 2 below 5"; aws.StringValue(fake.published[0].Subject) != expected {
+		t.Errorf("expected subject %q but got %q", expected, aws.StringValue(fake.published[0].Subject))
+	}
+	fake.errorToReturn = errors.New("fake")
+	if err := sink.Send(full.ToMetricData()); err == nil {
+		t.Error("expected an error when SNS fails")
+	}
+}
+
+func TestSNSSinkLongSubject(t *testing.T) {
+	fake := &FakeSNS{}
+	sn := &Snitcher{SNS: fake}
+	sink := SinkTypes["sns"](sn, &SinkConfig{URL: "arn:aws:sns:us-east-1:123456789012:snitch", Thresholds: map[string]float64{"*": 5}})
+	cr := NewClusterResources(aws.String(strings.Repeat("é", 120)))
+	cr.Remaining["fake.large"] = 2
+	if err := sink.Send(cr.ToMetricData()); err != nil {
+		t.Fatal(err)
+	}
+	subject := aws.StringValue(fake.published[0].Subject)
+	if !utf8.ValidString(subject) || utf8.RuneCountInString(subject) != snsSubjectLength {
+		t.Errorf("expected subject of %d whole characters but got %q", snsSubjectLength, subject)
+	}
+}
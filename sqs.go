@@ -0,0 +1,87 @@
+package snitch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// sqsBatchSize is how many messages SendMessageBatch takes at most.
+const sqsBatchSize = 10
+
+// SQSSink sends a message per cluster to QueueURL, its ClusterReport as JSON,
+// with string attributes "Cluster" and, if known, "AccountId" and "Region" to
+// filter on. Messages to FIFO queues are grouped by cluster.
+type SQSSink struct {
+	Snitcher *Snitcher
+	QueueURL string
+}
+
+// Send metrics to SQS, a message per cluster, in batches. All batches are
+// tried even if some fail, and the last failure is returned.
+//
+// Requires IAM permission "sqs:SendMessage".
+func (sink *SQSSink) Send(metricData []*cloudwatch.MetricDatum) (err error) {
+	if sink.QueueURL == "" {
+		return errors.New("no SQS queue URL")
+	}
+	reports := Report(metricData)
+	for i := 0; i < len(reports); i += sqsBatchSize {
+		end := i + sqsBatchSize
+		if end > len(reports) {
+			end = len(reports)
+		}
+		input := &sqs.SendMessageBatchInput{QueueUrl: aws.String(sink.QueueURL)}
+		for j, report := range reports[i:end] {
+			entry, marshalErr := sink.entry(strconv.Itoa(j), report)
+			if marshalErr != nil {
+				err = marshalErr
+				continue
+			}
+			input.Entries = append(input.Entries, entry)
+		}
+		output, sendErr := sink.Snitcher.SQS.SendMessageBatch(input)
+		if sendErr != nil {
+			sink.Snitcher.logger().Errorf("Failed to send %d messages to %q: %s", len(input.Entries), sink.QueueURL, sendErr)
+			err = sendErr
+			continue
+		}
+		for _, failed := range output.Failed {
+			err = fmt.Errorf("message %s failed: %s", aws.StringValue(failed.Id), aws.StringValue(failed.Message))
+			sink.Snitcher.logger().Errorf("Failed to send message to %q: %s", sink.QueueURL, err)
+		}
+	}
+	return
+}
+
+// entry creates a message, by id unique within its batch, of a cluster's
+// report.
+func (sink *SQSSink) entry(id string, report *ClusterReport) (*sqs.SendMessageBatchRequestEntry, error) {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return nil, err
+	}
+	entry := &sqs.SendMessageBatchRequestEntry{
+		Id:          aws.String(id),
+		MessageBody: aws.String(string(body)),
+		MessageAttributes: map[string]*sqs.MessageAttributeValue{
+			"Cluster": {DataType: aws.String("String"), StringValue: aws.String(report.Cluster)},
+		},
+	}
+	for _, dimension := range []string{"AccountId", "Region"} {
+		if value, ok := report.Dimensions[dimension]; ok {
+			entry.MessageAttributes[dimension] = &sqs.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(value)}
+		}
+	}
+	if strings.HasSuffix(sink.QueueURL, ".fifo") {
+		entry.MessageGroupId = aws.String(report.Key())
+		entry.MessageDeduplicationId = aws.String(fmt.Sprintf("%s@%d", report.Key(), report.Timestamp.Unix()))
+	}
+	return entry, nil
+}
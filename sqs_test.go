@@ -0,0 +1,66 @@
+package snitch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+)
+
+// FakeSQS mocks SQS, keeping batches sent.
+type FakeSQS struct {
+	sqsiface.SQSAPI
+	errorToReturn error                        // `error` to return from fake methods.
+	failed        []*sqs.BatchResultErrorEntry // Entries to report failed.
+	batches       []*sqs.SendMessageBatchInput // Stores supplied `*SendMessageBatchInput`.
+}
+
+func (fake *FakeSQS) SendMessageBatch(input *sqs.SendMessageBatchInput) (*sqs.SendMessageBatchOutput, error) {
+	fake.batches = append(fake.batches, input)
+	return &sqs.SendMessageBatchOutput{Failed: fake.failed}, fake.errorToReturn
+}
+
+func TestSQSSink(t *testing.T) {
+	fake := &FakeSQS{}
+	sink := &SQSSink{Snitcher: &Snitcher{SQS: fake}, QueueURL: "https://sqs.us-east-1.amazonaws.com/123456789012/snitch.fifo"}
+	var metricData []*cloudwatch.MetricDatum
+	for i := 0; i < 12; i++ {
+		cr := NewClusterResources(aws.String(fmt.Sprintf("cluster-%02d", i)))
+		cr.Remaining["fake.large"] = i
+		cr.ClusterDimensions = []*cloudwatch.Dimension{{Name: aws.String("Region"), Value: aws.String("us-east-1")}}
+		metricData = append(metricData, cr.ToMetricData()...)
+	}
+	if err := sink.Send(metricData); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.batches) != 2 || len(fake.batches[0].Entries) != 10 || len(fake.batches[1].Entries) != 2 {
+		t.Fatalf("expected batches of 10 and 2 but got %v", fake.batches)
+	}
+	entry := fake.batches[1].Entries[1]
+	var report ClusterReport
+	if err := json.Unmarshal([]byte(aws.StringValue(entry.MessageBody)), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Cluster != "cluster-11" || report.InstanceTypes["fake.large"]["RemainingSchedulable"] != 11 {
+		t.Errorf("expected cluster-11's report but got %+v", report)
+	}
+	if aws.StringValue(entry.MessageAttributes["Cluster"].StringValue) != "cluster-11" || aws.StringValue(entry.MessageAttributes["Region"].StringValue) != "us-east-1" {
+		t.Errorf("expected Cluster and Region attributes but got %v", entry.MessageAttributes)
+	}
+	if aws.StringValue(entry.MessageGroupId) != "us-east-1/cluster-11" {
+		t.Errorf("expected FIFO message grouped by cluster but got %v", entry.MessageGroupId)
+	}
+	fake.failed = []*sqs.BatchResultErrorEntry{{Id: aws.String("0"), Message: aws.String("fake")}}
+	if err := sink.Send(metricData); err == nil {
+		t.Error("expected an error when a message fails")
+	}
+	fake.failed, fake.errorToReturn = nil, errors.New("fake")
+	if err := sink.Send(metricData); err == nil {
+		t.Error("expected an error when SQS fails")
+	}
+}
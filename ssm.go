@@ -0,0 +1,81 @@
+package snitch
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+)
+
+// LoadConfig reads a Snitcher, like ParseConfig, from location: a YAML or JSON
+// file, or, prefixed "ssm:", an SSM Parameter Store parameter, like
+// "ssm:/snitch/prod".
+//
+// Parameters under a path ending in "/", like "ssm:/snitch/prod/", are all
+// read, in order of name, later ones overriding earlier ones' settings.
+//
+// The Snitcher read has ConfigLocation of location, for IAMPolicy.
+//
+// Requires IAM permission "ssm:GetParameter", or "ssm:GetParametersByPath",
+// and "kms:Decrypt" of SecureString parameters' key.
+func (sn *Snitcher) LoadConfig(location string) (*Snitcher, error) {
+	config, err := sn.loadConfig(location)
+	if err != nil {
+		return nil, err
+	}
+	config.ConfigLocation = aws.String(location)
+	return config, nil
+}
+
+func (sn *Snitcher) loadConfig(location string) (*Snitcher, error) {
+	if !strings.HasPrefix(location, "ssm:") {
+		data, err := ioutil.ReadFile(location)
+		if err != nil {
+			return nil, err
+		}
+		return ParseConfig(data)
+	}
+	if sn.SSM == nil {
+		sn.SSM = ssmiface.SSMAPI(ssm.New(sn.session()))
+	}
+	name := strings.TrimPrefix(location, "ssm:")
+	if !strings.HasSuffix(name, "/") {
+		output, err := sn.SSM.GetParameter(&ssm.GetParameterInput{
+			Name:           aws.String(name),
+			WithDecryption: aws.Bool(true),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return ParseConfig([]byte(aws.StringValue(output.Parameter.Value)))
+	}
+	input := &ssm.GetParametersByPathInput{
+		Path:           aws.String(name),
+		Recursive:      aws.Bool(true),
+		WithDecryption: aws.Bool(true),
+	}
+	var parameters []*ssm.Parameter
+	err := sn.SSM.GetParametersByPathPages(input, func(output *ssm.GetParametersByPathOutput, lastPage bool) bool {
+		parameters = append(parameters, output.Parameters...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(parameters, func(i, j int) bool {
+		return aws.StringValue(parameters[i].Name) < aws.StringValue(parameters[j].Name)
+	})
+	config := &Snitcher{}
+	for _, parameter := range parameters {
+		parsed, err := ParseConfig([]byte(aws.StringValue(parameter.Value)))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", aws.StringValue(parameter.Name), err)
+		}
+		config = parsed.WithDefaults(config)
+	}
+	return config, nil
+}
@@ -0,0 +1,89 @@
+package snitch
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+)
+
+// FakeSSM mocks SSM Parameter Store with parameter values by name.
+type FakeSSM struct {
+	ssmiface.SSMAPI
+	errorToReturn error             // `error` to return from fake methods.
+	parameters    map[string]string // Parameter values by name.
+}
+
+func (fake *FakeSSM) GetParameter(input *ssm.GetParameterInput) (*ssm.GetParameterOutput, error) {
+	if fake.errorToReturn != nil {
+		return nil, fake.errorToReturn
+	}
+	value, ok := fake.parameters[aws.StringValue(input.Name)]
+	if !ok {
+		return nil, errors.New("fake parameter not found")
+	}
+	return &ssm.GetParameterOutput{Parameter: &ssm.Parameter{Name: input.Name, Value: aws.String(value)}}, nil
+}
+
+func (fake *FakeSSM) GetParametersByPathPages(input *ssm.GetParametersByPathInput, fn func(*ssm.GetParametersByPathOutput, bool) bool) error {
+	if fake.errorToReturn != nil {
+		return fake.errorToReturn
+	}
+	// One page per parameter, in whatever order maps go.
+	for name, value := range fake.parameters {
+		if strings.HasPrefix(name, aws.StringValue(input.Path)) {
+			page := &ssm.GetParametersByPathOutput{Parameters: []*ssm.Parameter{{Name: aws.String(name), Value: aws.String(value)}}}
+			if !fn(page, false) {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func TestSnitcher_LoadConfig(t *testing.T) {
+	fake := &FakeSSM{parameters: map[string]string{
+		"/snitch/prod":           "namespace: ECS/Snitch",
+		"/snitch/staging/a-base": "namespace: ECS/Base\nexclude: [\"ci-*\"]",
+		"/snitch/staging/b-team": "namespace: ECS/Team",
+	}}
+	sn := &Snitcher{SSM: fake}
+	config, err := sn.LoadConfig("ssm:/snitch/prod")
+	if err != nil || aws.StringValue(config.Namespace) != "ECS/Snitch" || aws.StringValue(config.ConfigLocation) != "ssm:/snitch/prod" {
+		t.Errorf("expected ECS/Snitch from ssm:/snitch/prod but got %+v: %s", config, err)
+	}
+	config, err = sn.LoadConfig("ssm:/snitch/staging/")
+	if err != nil || aws.StringValue(config.Namespace) != "ECS/Team" || len(config.Exclude) != 1 {
+		t.Errorf("expected b-team's namespace over a-base's, with a-base's exclude, but got %+v: %s", config, err)
+	}
+	if _, err = sn.LoadConfig("ssm:/snitch/missing"); err == nil {
+		t.Error("expected missing parameter to fail")
+	}
+	fake.parameters["/snitch/broken/parameter"] = "namespace: ["
+	if _, err = sn.LoadConfig("ssm:/snitch/broken/"); err == nil || !strings.Contains(err.Error(), "/snitch/broken/parameter") {
+		t.Errorf("expected invalid parameter named in error but got %v", err)
+	}
+	fake.errorToReturn = errors.New("fake")
+	if _, err = sn.LoadConfig("ssm:/snitch/staging/"); err == nil {
+		t.Error("expected SSM failing to fail")
+	}
+
+	file, err := ioutil.TempFile("", "snitch-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString(`{"Namespace": "Testable/Namespace"}`)
+	file.Close()
+	if config, err = sn.LoadConfig(file.Name()); err != nil || aws.StringValue(config.Namespace) != "Testable/Namespace" {
+		t.Errorf("expected file read but got %+v: %s", config, err)
+	}
+	if _, err = sn.LoadConfig(file.Name() + ".missing"); err == nil {
+		t.Error("expected missing file to fail")
+	}
+}
@@ -0,0 +1,71 @@
+package snitch
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// statisticSets aggregates metrics by instance type into a StatisticSet (their
+// sample count, sum, minimum and maximum) per metric and their other
+// dimensions, like per cluster, so large clusters of many instance types
+// publish a few data points rather than hundreds. Other metrics are kept.
+func statisticSets(metricData []*cloudwatch.MetricDatum) (aggregated []*cloudwatch.MetricDatum) {
+	sets := map[string]*cloudwatch.MetricDatum{}
+	for _, datum := range metricData {
+		dimensions, ok := withoutInstanceType(datum.Dimensions)
+		if !ok || datum.Value == nil {
+			aggregated = append(aggregated, datum)
+			continue
+		}
+		key := aws.StringValue(datum.MetricName) + "\x00" + dimensionsKey(dimensions)
+		set, found := sets[key]
+		if !found {
+			set = &cloudwatch.MetricDatum{
+				MetricName: datum.MetricName,
+				Dimensions: dimensions,
+				Timestamp:  datum.Timestamp,
+				Unit:       datum.Unit,
+				StatisticValues: &cloudwatch.StatisticSet{
+					SampleCount: aws.Float64(0),
+					Sum:         aws.Float64(0),
+					Minimum:     aws.Float64(math.Inf(1)),
+					Maximum:     aws.Float64(math.Inf(-1)),
+				},
+			}
+			sets[key] = set
+			aggregated = append(aggregated, set)
+		}
+		value, statistics := *datum.Value, set.StatisticValues
+		*statistics.SampleCount++
+		*statistics.Sum += value
+		*statistics.Minimum = math.Min(*statistics.Minimum, value)
+		*statistics.Maximum = math.Max(*statistics.Maximum, value)
+	}
+	return
+}
+
+// withoutInstanceType is dimensions but "InstanceType", if they have it.
+func withoutInstanceType(dimensions []*cloudwatch.Dimension) (without []*cloudwatch.Dimension, had bool) {
+	for _, dimension := range dimensions {
+		if aws.StringValue(dimension.Name) == "InstanceType" {
+			had = true
+			continue
+		}
+		without = append(without, dimension)
+	}
+	return
+}
+
+// dimensionsKey identifies dimensions regardless of their order.
+func dimensionsKey(dimensions []*cloudwatch.Dimension) string {
+	pairs := make([]string, len(dimensions))
+	for i, dimension := range dimensions {
+		pairs[i] = aws.StringValue(dimension.Name) + "=" + aws.StringValue(dimension.Value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "\x00")
+}
@@ -0,0 +1,53 @@
+package snitch
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestStatisticSets(t *testing.T) {
+	cr := NewClusterResources(aws.String("cluster"))
+	cr.Remaining["c5.large"] = 2
+	cr.Remaining["c5.xlarge"] = 5
+	cr.Remaining["m5.large"] = 0
+	cr.Registered["c5.large"] = 10
+	cr.Totals["PendingTasks"] = 3
+	aggregated := statisticSets(cr.ToMetricData())
+	if len(aggregated) != 3 {
+		t.Fatalf("expected 2 StatisticSets and PendingTasks but got %v", aggregated)
+	}
+	for _, datum := range aggregated {
+		switch aws.StringValue(datum.MetricName) {
+		case "RemainingSchedulable":
+			statistics := datum.StatisticValues
+			if *statistics.SampleCount != 3 || *statistics.Sum != 7 || *statistics.Minimum != 0 || *statistics.Maximum != 5 {
+				t.Errorf("expected 3 samples summing to 7, from 0 to 5, but got %s", statistics)
+			}
+			if len(datum.Dimensions) != 1 || datum.Value != nil {
+				t.Errorf("expected only ClusterName dimension and no value but got %s", datum)
+			}
+		case "PendingTasks":
+			if datum.StatisticValues != nil || aws.Float64Value(datum.Value) != 3 {
+				t.Errorf("expected PendingTasks kept but got %s", datum)
+			}
+		}
+		if err := LookupMetric(aws.StringValue(datum.MetricName)).Validate(datum); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestSnitcher_publishStatisticSets(t *testing.T) {
+	cr := NewClusterResources(aws.String("cluster"))
+	cr.Remaining["c5.large"] = 2
+	cr.Remaining["c5.xlarge"] = 5
+	cloudWatch := &FakeCloudWatch{}
+	sn := &Snitcher{CloudWatch: cloudWatch, Namespace: aws.String("Testable/Namespace"), StatisticSets: aws.Bool(true)}
+	if err := sn.publish(sn.Namespace, cr.ToMetricData()); err != nil {
+		t.Fatal(err)
+	}
+	if len(cloudWatch.payload) != 1 || len(cloudWatch.payload[0].MetricData) != 1 || cloudWatch.payload[0].MetricData[0].StatisticValues == nil {
+		t.Errorf("expected one StatisticSet published but got %v", cloudWatch.payload)
+	}
+}
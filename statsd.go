@@ -0,0 +1,84 @@
+package snitch
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// maxStatsDPacket keeps UDP packets within an Ethernet frame, so StatsD
+// servers get whole lines.
+const maxStatsDPacket = 1432
+
+// StatsDSink sends metrics as StatsD gauges over UDP, like:
+//
+//	ECS.Snitch.RemainingSchedulable.my-cluster.c5_large:3|g
+//
+// or, with Tags, as DogStatsD gauges tagged with their dimensions:
+//
+//	ECS.Snitch.RemainingSchedulable:3|g|#cluster:my-cluster,instance_type:c5.large
+type StatsDSink struct {
+	// Address of the StatsD server, like "localhost:8125".
+	Address string
+	// Prefix of metric names, like "ECS/Snitch", with "/" as ".".
+	Prefix string
+	// Whether to tag, DogStatsD-style, rather than add dimension values to
+	// metric names.
+	Tags bool
+}
+
+// Send metrics to StatsD, in as few packets as fit.
+func (sink *StatsDSink) Send(metricData []*cloudwatch.MetricDatum) error {
+	conn, err := net.Dial("udp", sink.Address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	var packet bytes.Buffer
+	for _, datum := range metricData {
+		line := sink.line(datum)
+		if packet.Len() > 0 && packet.Len()+1+len(line) > maxStatsDPacket {
+			if _, err := conn.Write(packet.Bytes()); err != nil {
+				return err
+			}
+			packet.Reset()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	if packet.Len() > 0 {
+		_, err = conn.Write(packet.Bytes())
+	}
+	return err
+}
+
+// statsDUnsafe replaces characters StatsD treats specially in names.
+var statsDUnsafe = strings.NewReplacer(":", "_", "|", "_", "@", "_", "#", "_", ",", "_", "/", ".", " ", "_")
+
+// line formats a metric as a StatsD gauge.
+func (sink *StatsDSink) line(datum *cloudwatch.MetricDatum) string {
+	name := []string{statsDUnsafe.Replace(aws.StringValue(datum.MetricName))}
+	if sink.Prefix != "" {
+		name = append([]string{statsDUnsafe.Replace(sink.Prefix)}, name...)
+	}
+	var tags []string
+	for _, dimension := range datum.Dimensions {
+		value := statsDUnsafe.Replace(aws.StringValue(dimension.Value))
+		if sink.Tags {
+			tags = append(tags, label(aws.StringValue(dimension.Name))+":"+value)
+		} else {
+			name = append(name, strings.Replace(value, ".", "_", -1))
+		}
+	}
+	line := fmt.Sprintf("%s:%g|g", strings.Join(name, "."), aws.Float64Value(datum.Value))
+	if len(tags) > 0 {
+		line += "|#" + strings.Join(tags, ",")
+	}
+	return line
+}
@@ -0,0 +1,67 @@
+package snitch
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+func TestStatsDSink_line(t *testing.T) {
+	datum := &cloudwatch.MetricDatum{
+		MetricName: aws.String("RemainingSchedulable"),
+		Dimensions: []*cloudwatch.Dimension{
+			{Name: aws.String("ClusterName"), Value: aws.String("my-cluster")},
+			{Name: aws.String("InstanceType"), Value: aws.String("c5.large")},
+		},
+		Value: aws.Float64(3),
+	}
+	for sink, expected := range map[*StatsDSink]string{
+		{Prefix: "ECS/Snitch"}:             "ECS.Snitch.RemainingSchedulable.my-cluster.c5_large:3|g",
+		{Prefix: "ECS/Snitch", Tags: true}: "ECS.Snitch.RemainingSchedulable:3|g|#cluster:my-cluster,instance_type:c5.large",
+		{}:                                 "RemainingSchedulable.my-cluster.c5_large:3|g",
+	} {
+		if actual := sink.line(datum); actual != expected {
+			t.Errorf("expected %q but got %q", expected, actual)
+		}
+	}
+}
+
+func TestStatsDSink_Send(t *testing.T) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	server.SetReadDeadline(time.Now().Add(5 * time.Second))
+	cr := NewClusterResources(aws.String("fake-cluster"))
+	for i := 0; i < 100; i++ {
+		cr.Remaining[strings.Repeat("x", i)+".large"] = i
+	}
+	metricData := cr.ToMetricData()
+	sink := &StatsDSink{Address: server.LocalAddr().String(), Tags: true}
+	if err := sink.Send(metricData); err != nil {
+		t.Fatal(err)
+	}
+	lines := 0
+	buf := make([]byte, 65536)
+	for lines < len(metricData) {
+		n, _, err := server.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n > maxStatsDPacket {
+			t.Errorf("expected packets of at most %d bytes but got %d", maxStatsDPacket, n)
+		}
+		lines += len(strings.Split(string(buf[:n]), "\n"))
+	}
+	if lines != len(metricData) {
+		t.Errorf("expected %d lines but got %d", len(metricData), lines)
+	}
+	if err := (&StatsDSink{Address: "not an address"}).Send(metricData); err == nil {
+		t.Error("expected an error sending to a bad address")
+	}
+}
@@ -0,0 +1,59 @@
+package snitch
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+func TestSnitcher_Stream(t *testing.T) {
+	fake := NewFakeECS(t)
+	fake.checkCluster = false
+	cloudWatch := &FakeCloudWatch{}
+	sn := &Snitcher{ECS: fake, CloudWatch: cloudWatch, Namespace: aws.String("Testable/Namespace"), ShouldPublish: aws.Bool(true), Stream: aws.Bool(true), Summary: &Summary{}}
+	if err := sn.run(); err != nil {
+		t.Fatal(err)
+	}
+	if sn.stream != nil {
+		t.Error("expected stream forgotten after the run")
+	}
+	if len(cloudWatch.payload) < sn.Summary.Clusters {
+		t.Fatalf("expected a publish per cluster but got %d for %d clusters", len(cloudWatch.payload), sn.Summary.Clusters)
+	}
+	for _, input := range cloudWatch.payload {
+		clusters := map[string]bool{}
+		for _, datum := range input.MetricData {
+			for _, dimension := range datum.Dimensions {
+				if aws.StringValue(dimension.Name) == "ClusterName" {
+					clusters[aws.StringValue(dimension.Value)] = true
+				}
+			}
+		}
+		if len(clusters) != 1 {
+			t.Errorf("expected each batch of one cluster but got %v", clusters)
+		}
+	}
+}
+
+func TestSnitcher_Stream_streamless(t *testing.T) {
+	for _, sn := range []*Snitcher{
+		{DryRun: aws.String("json")},
+		{Sinks: []*SinkConfig{{Type: "webhook"}, {Type: "json"}}},
+	} {
+		sn.ECS = NewFakeECS(t)
+		sn.Stream = aws.Bool(true)
+		if err := sn.run(); err == nil {
+			t.Errorf("expected streaming %v to fail", sn)
+		}
+	}
+}
+
+func TestSnitcher_AsRole_stream(t *testing.T) {
+	var streamed []*cloudwatch.MetricDatum
+	sn := &Snitcher{stream: func(metricData []*cloudwatch.MetricDatum) { streamed = metricData }}
+	sn.AsRole("arn:aws:iam::123456789012:role/snitch").stream([]*cloudwatch.MetricDatum{{MetricName: aws.String("RunningTasks")}})
+	if len(streamed) != 1 || len(streamed[0].Dimensions) != 1 || aws.StringValue(streamed[0].Dimensions[0].Value) != "123456789012" {
+		t.Errorf("expected metrics streamed with AccountId but got %v", streamed)
+	}
+}
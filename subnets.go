@@ -0,0 +1,98 @@
+package snitch
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+// DescribeInstanceSubnets maps EC2 Instance IDs to their subnet's ID.
+//
+// Requires IAM permission "ec2:DescribeInstances".
+func (sn *Snitcher) DescribeInstanceSubnets(instanceIDs []*string) map[string]string {
+	subnets := map[string]string{}
+	if len(instanceIDs) == 0 {
+		return subnets
+	}
+	input := &ec2.DescribeInstancesInput{
+		InstanceIds: instanceIDs,
+	}
+	err := sn.EC2.DescribeInstancesPages(
+		input,
+		func(page *ec2.DescribeInstancesOutput, last bool) bool {
+			for _, reservation := range page.Reservations {
+				for _, instance := range reservation.Instances {
+					subnets[aws.StringValue(instance.InstanceId)] = aws.StringValue(instance.SubnetId)
+				}
+			}
+			return true
+		},
+	)
+	if err != nil {
+		sn.logger().Errorf("Failed to DescribeInstancesPages! %s", err)
+	}
+	return subnets
+}
+
+// DescribeAvailableIPs maps subnet IDs to how many IP addresses are free in
+// each. Subnets that couldn't be described are missing.
+//
+// Requires IAM permission "ec2:DescribeSubnets".
+func (sn *Snitcher) DescribeAvailableIPs(subnetIDs []*string) map[string]int {
+	available := map[string]int{}
+	if len(subnetIDs) == 0 {
+		return available
+	}
+	input := &ec2.DescribeSubnetsInput{
+		SubnetIds: subnetIDs,
+	}
+	err := sn.EC2.DescribeSubnetsPages(
+		input,
+		func(page *ec2.DescribeSubnetsOutput, last bool) bool {
+			for _, subnet := range page.Subnets {
+				available[aws.StringValue(subnet.SubnetId)] = int(aws.Int64Value(subnet.AvailableIpAddressCount))
+			}
+			return true
+		},
+	)
+	if err != nil {
+		sn.logger().Errorf("Failed to DescribeSubnetsPages! %s", err)
+	}
+	return available
+}
+
+// MeasureSubnetCapacity adds, per subnet Container Instances live in, how
+// many free IPs there are and how many "awsvpc" containers fit. Each "awsvpc"
+// Task takes an IP, so busy clusters can run out of IPs before CPU or Memory.
+//
+// Subnets that can't be described are left out rather than reported as full.
+func (sn *Snitcher) MeasureSubnetCapacity(cr *ClusterResources, containers []*ecs.ContainerInstance, cpu, memory int) {
+	var instanceIDs []*string
+	for _, container := range containers {
+		if container.Ec2InstanceId != nil {
+			instanceIDs = append(instanceIDs, container.Ec2InstanceId)
+		}
+	}
+	subnets := sn.DescribeInstanceSubnets(instanceIDs)
+	remaining := map[string]int{}
+	for _, container := range containers {
+		if subnet, ok := subnets[aws.StringValue(container.Ec2InstanceId)]; ok && !isDisconnected(container) {
+			remaining[subnet] += sn.weigh(container, ContainersPossible(cpu, memory, container.RemainingResources))
+		}
+	}
+	var subnetIDs []*string
+	for subnet := range remaining {
+		subnetIDs = append(subnetIDs, aws.String(subnet))
+	}
+	cr.Subnets["RemainingSchedulableAwsvpc"] = map[string]int{}
+	cr.Subnets["SubnetAvailableIPs"] = map[string]int{}
+	for subnet, ips := range sn.DescribeAvailableIPs(subnetIDs) {
+		possible := remaining[subnet]
+		if ips < possible {
+			possible = ips
+		}
+		cr.Subnets["RemainingSchedulableAwsvpc"][subnet] = possible
+		cr.Subnets["SubnetAvailableIPs"][subnet] = ips
+	}
+	sn.logger().Infof("%q has %+v", *cr.Cluster, cr.Subnets)
+}
@@ -0,0 +1,127 @@
+package snitch
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+)
+
+// FakeEC2 mocks EC2 for testing.
+type FakeEC2 struct {
+	ec2iface.EC2API
+	errorToReturn        error                    // `error` to return from fake methods.
+	expectedSubnets      map[string]string        // Expected subnet ID by EC2 Instance ID.
+	expectedAvailableIPs map[string]int64         // Expected free IPs by subnet ID.
+	expectedRegions      []string                 // Expected AWS Regions enabled.
+	expectedInstances    map[string]*ec2.Instance // Expected instance type and ENIs by EC2 Instance ID.
+	expectedMaxENIs      map[string]int64         // Expected maximum ENIs by instance type.
+}
+
+func (fake *FakeEC2) DescribeInstancesPages(input *ec2.DescribeInstancesInput, pager func(*ec2.DescribeInstancesOutput, bool) bool) error {
+	reservation := &ec2.Reservation{}
+	for _, id := range input.InstanceIds {
+		subnet, hasSubnet := fake.expectedSubnets[*id]
+		instance, ok := fake.expectedInstances[*id]
+		if !ok && !hasSubnet {
+			continue
+		}
+		if !ok {
+			instance = &ec2.Instance{}
+		}
+		instance.InstanceId = id
+		if hasSubnet {
+			instance.SubnetId = aws.String(subnet)
+		}
+		reservation.Instances = append(reservation.Instances, instance)
+	}
+	if fake.errorToReturn == nil {
+		pager(&ec2.DescribeInstancesOutput{Reservations: []*ec2.Reservation{reservation}}, true)
+	}
+	return fake.errorToReturn
+}
+
+func (fake *FakeEC2) DescribeSubnetsPages(input *ec2.DescribeSubnetsInput, pager func(*ec2.DescribeSubnetsOutput, bool) bool) error {
+	output := &ec2.DescribeSubnetsOutput{}
+	for _, id := range input.SubnetIds {
+		if ips, ok := fake.expectedAvailableIPs[*id]; ok {
+			output.Subnets = append(output.Subnets, &ec2.Subnet{AvailableIpAddressCount: aws.Int64(ips), SubnetId: id})
+		}
+	}
+	if fake.errorToReturn == nil {
+		pager(output, true)
+	}
+	return fake.errorToReturn
+}
+
+func (fake *FakeEC2) DescribeInstanceTypesPages(input *ec2.DescribeInstanceTypesInput, pager func(*ec2.DescribeInstanceTypesOutput, bool) bool) error {
+	output := &ec2.DescribeInstanceTypesOutput{}
+	for _, instanceType := range input.InstanceTypes {
+		if enis, ok := fake.expectedMaxENIs[*instanceType]; ok {
+			output.InstanceTypes = append(output.InstanceTypes, &ec2.InstanceTypeInfo{
+				InstanceType: instanceType,
+				NetworkInfo:  &ec2.NetworkInfo{MaximumNetworkInterfaces: aws.Int64(enis)},
+			})
+		}
+	}
+	if fake.errorToReturn == nil {
+		pager(output, true)
+	}
+	return fake.errorToReturn
+}
+
+// NewFakeSubnets puts FakeECS's first two Container Instances in a subnet
+// with plenty of IPs and the third in a nearly full one.
+func NewFakeSubnets(t *testing.T) (*Snitcher, *FakeECS, *FakeEC2) {
+	fakeECS := NewFakeECS(t)
+	for index, container := range fakeECS.expectedContainerInstances {
+		container.Ec2InstanceId = aws.String("i-" + string(rune('a'+index)))
+	}
+	fake := &FakeEC2{
+		expectedSubnets:      map[string]string{"i-a": "subnet-roomy", "i-b": "subnet-roomy", "i-c": "subnet-full"},
+		expectedAvailableIPs: map[string]int64{"subnet-roomy": 250, "subnet-full": 1},
+	}
+	sn := &Snitcher{EC2: fake, ECS: fakeECS}
+	return sn, fakeECS, fake
+}
+
+func TestSnitcher_MeasureSubnetCapacity(t *testing.T) {
+	sn, fakeECS, _ := NewFakeSubnets(t)
+	cr := NewClusterResources(fakeECS.expectedCluster)
+	sn.MeasureSubnetCapacity(cr, fakeECS.expectedContainerInstances, fakeECS.expectedCPU, fakeECS.expectedMemory)
+	perInstance := fakeECS.expectedRemainingPossible / len(fakeECS.expectedContainerInstances)
+	for subnet, expected := range map[string]int{"subnet-roomy": 2 * perInstance, "subnet-full": 1} {
+		if actual := cr.Subnets["RemainingSchedulableAwsvpc"][subnet]; actual != expected {
+			t.Errorf("expected %d RemainingSchedulableAwsvpc in %q but got %d", expected, subnet, actual)
+		}
+	}
+	if actual := cr.Subnets["SubnetAvailableIPs"]["subnet-roomy"]; actual != 250 {
+		t.Errorf("expected 250 SubnetAvailableIPs but got %d", actual)
+	}
+}
+
+func TestSnitcher_MeasureSubnetCapacityError(t *testing.T) {
+	sn, fakeECS, fake := NewFakeSubnets(t)
+	fake.errorToReturn = errors.New("subnets shouldn't be reported full on error")
+	cr := NewClusterResources(fakeECS.expectedCluster)
+	sn.MeasureSubnetCapacity(cr, fakeECS.expectedContainerInstances, fakeECS.expectedCPU, fakeECS.expectedMemory)
+	if len(cr.ToMetricData()) != 0 {
+		t.Errorf("expected no data points but got %v", cr.ToMetricData())
+	}
+}
+
+func TestSnitcher_MeasureClusterSubnets(t *testing.T) {
+	sn, fakeECS, _ := NewFakeSubnets(t)
+	sn.MeasureSubnets = aws.Bool(true)
+	found := 0
+	for _, datum := range sn.MeasureCluster(fakeECS.expectedCluster) {
+		if *datum.MetricName == "SubnetAvailableIPs" {
+			found++
+		}
+	}
+	if found != 2 {
+		t.Errorf("expected SubnetAvailableIPs for 2 subnets but got %d", found)
+	}
+}
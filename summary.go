@@ -0,0 +1,169 @@
+package snitch
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Summary counts what runs measured and published, and what failed, so
+// failures needn't only be logged. Its methods are safe to call on nil
+// Summary, which counts nothing.
+type Summary struct {
+	mutex sync.Mutex
+	// Clusters measured, which had metrics.
+	Clusters int `json:"clusters"`
+	// Metrics published, to CloudWatch or as Embedded Metric Format.
+	Published int `json:"published"`
+	// Failures, like calls to AWS that failed, in no particular order.
+	Failures []string `json:"failures,omitempty"`
+	// Failures publishing metrics, to CloudWatch or sinks.
+	PublishFailures int `json:"publishFailures"`
+	// Calls made to AWS services, like "ecs", including retries, and how many
+	// of them were throttled.
+	Calls     map[string]int `json:"calls,omitempty"`
+	Throttles map[string]int `json:"throttles,omitempty"`
+	// Clusters skipped, not measured, since Snitcher's Deadline passed.
+	Skipped []string `json:"skipped,omitempty"`
+	// Results per cluster, like for a Step Functions Map state to fan out
+	// over, in no particular order.
+	Results   []*ClusterResult `json:"results,omitempty"`
+	failed    map[string]bool  // Clusters with failures.
+	throttled map[string]bool  // Clusters with calls throttled.
+}
+
+// ClusterResult is what became of a cluster in a run.
+type ClusterResult struct {
+	Cluster string `json:"cluster"`
+	// Status is "measured", "failed" if measuring it failed at all,
+	// "throttled" if it failed since AWS throttled calls, "empty"
+	// if it had nothing to measure, "skipped" past Snitcher's Deadline, or
+	// "discovered" when DiscoverOnly.
+	Status string `json:"status"`
+	// Metrics measured of the cluster.
+	Metrics int `json:"metrics"`
+}
+
+func (s *Summary) update(update func()) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	update()
+}
+
+func (s *Summary) publish(count int) { s.update(func() { s.Published += count }) }
+
+// publishFailed records a failure publishing, formatted like fail's.
+func (s *Summary) publishFailed(format string, a ...interface{}) {
+	s.fail(format, a...)
+	s.update(func() { s.PublishFailures++ })
+}
+
+// call records a call to service, and whether it was throttled.
+func (s *Summary) call(service string, throttled bool) {
+	s.update(func() {
+		if s.Calls == nil {
+			s.Calls, s.Throttles = map[string]int{}, map[string]int{}
+		}
+		s.Calls[service]++
+		if throttled {
+			s.Throttles[service]++
+		}
+	})
+}
+
+func (s *Summary) discover(cluster string) {
+	s.update(func() { s.Results = append(s.Results, &ClusterResult{Cluster: cluster, Status: "discovered"}) })
+}
+
+func (s *Summary) skip(cluster string) {
+	s.update(func() {
+		s.Skipped = append(s.Skipped, cluster)
+		s.Results = append(s.Results, &ClusterResult{Cluster: cluster, Status: "skipped"})
+	})
+}
+
+// measure records the result of measuring cluster, once it's done.
+func (s *Summary) measure(cluster string, metrics int) {
+	s.update(func() {
+		result := &ClusterResult{Cluster: cluster, Status: "measured", Metrics: metrics}
+		switch {
+		case s.throttled[cluster]:
+			result.Status = "throttled"
+		case s.failed[cluster]:
+			result.Status = "failed"
+		case metrics == 0:
+			result.Status = "empty"
+		}
+		if metrics > 0 {
+			s.Clusters++
+		}
+		s.Results = append(s.Results, result)
+	})
+}
+
+// fail records a failure, formatted like fmt.Sprintf.
+func (s *Summary) fail(format string, a ...interface{}) {
+	s.update(func() { s.Failures = append(s.Failures, fmt.Sprintf(format, a...)) })
+}
+
+// failCluster records a failure measuring cluster, formatted like fail's,
+// which was throttled if any of a is a throttling error.
+func (s *Summary) failCluster(cluster, format string, a ...interface{}) {
+	s.fail(format, a...)
+	s.update(func() {
+		if s.failed == nil {
+			s.failed, s.throttled = map[string]bool{}, map[string]bool{}
+		}
+		s.failed[cluster] = true
+		if throttled(a...) {
+			s.throttled[cluster] = true
+		}
+	})
+}
+
+// throttledCluster tells whether measuring cluster failed since AWS throttled
+// calls.
+func (s *Summary) throttledCluster(cluster string) (throttled bool) {
+	s.update(func() { throttled = s.throttled[cluster] })
+	return
+}
+
+// Err reports whether anything failed, as an error listing the first failure.
+func (s *Summary) Err() error {
+	return s.errSince(0)
+}
+
+// failures counts failures so far, for errSince.
+func (s *Summary) failures() (count int) {
+	s.update(func() { count = len(s.Failures) })
+	return
+}
+
+// errSince is Err of failures after the first count of them.
+func (s *Summary) errSince(count int) (err error) {
+	s.update(func() {
+		switch failures := s.Failures[count:]; len(failures) {
+		case 0:
+		case 1:
+			err = fmt.Errorf("%s", failures[0])
+		default:
+			err = fmt.Errorf("%s, and %d more failures", failures[0], len(failures)-1)
+		}
+	})
+	return
+}
+
+// RunSummary is Run, summarizing what it measured and published, and
+// returning an error if measuring or publishing anything failed, so Lambda
+// can count the invocation among its Errors.
+func RunSummary(sn *Snitcher) (*Summary, error) {
+	if sn.Summary == nil {
+		sn.Summary = &Summary{}
+	}
+	if err := sn.run(); err != nil {
+		sn.Summary.fail("%s", err)
+	}
+	return sn.Summary, sn.Summary.Err()
+}
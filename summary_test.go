@@ -0,0 +1,88 @@
+package snitch
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestRunSummary(t *testing.T) {
+	fake := NewFakeECS(t)
+	fake.checkCluster = false
+	cloudWatch := &FakeCloudWatch{}
+	sn := &Snitcher{ECS: fake, CloudWatch: cloudWatch, Namespace: aws.String("Testable/Namespace"), ShouldPublish: aws.Bool(true)}
+	summary, err := RunSummary(sn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	published := 0
+	for _, input := range cloudWatch.payload {
+		published += len(input.MetricData)
+	}
+	if summary.Clusters != 3 || summary.Published != published || len(summary.Failures) != 0 {
+		t.Errorf("expected 3 clusters and %d metrics published but got %+v", published, summary)
+	}
+	if len(summary.Results) != 3 || summary.Results[0].Status != "measured" || summary.Results[0].Metrics == 0 {
+		t.Errorf("expected 3 clusters' results but got %+v", summary.Results)
+	}
+
+	fake.errorToReturn = errors.New("AccessDeniedException")
+	summary, err = RunSummary(&Snitcher{ECS: fake, CloudWatch: cloudWatch})
+	if err == nil || !strings.Contains(err.Error(), "AccessDeniedException") {
+		t.Errorf("expected ECS failing to fail but got %v", err)
+	}
+	if summary.Clusters != 0 || len(summary.Failures) == 0 {
+		t.Errorf("expected no clusters and failures but got %+v", summary)
+	}
+
+	summary, err = RunSummary(&Snitcher{ECS: fake, CloudWatch: cloudWatch, Cluster: aws.String("fake-cluster")})
+	if err == nil || len(summary.Results) != 1 || summary.Results[0].Status != "failed" {
+		t.Errorf("expected one failed cluster but got %+v: %v", summary.Results, err)
+	}
+}
+
+func TestRunSummary_discoverOnly(t *testing.T) {
+	fake := NewFakeECS(t)
+	cloudWatch := &FakeCloudWatch{}
+	sn := &Snitcher{ECS: fake, CloudWatch: cloudWatch, DiscoverOnly: aws.Bool(true), ShouldPublish: aws.Bool(true)}
+	summary, err := RunSummary(sn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.Results) != 3 || summary.Results[0].Status != "discovered" || summary.Clusters != 0 {
+		t.Errorf("expected 3 clusters discovered but got %+v", summary.Results)
+	}
+	if len(cloudWatch.payload) != 0 {
+		t.Errorf("expected nothing published but got %d batches", len(cloudWatch.payload))
+	}
+}
+
+func TestRunSummary_deadline(t *testing.T) {
+	fake := NewFakeECS(t)
+	fake.checkCluster = false
+	sn := &Snitcher{ECS: fake, CloudWatch: &FakeCloudWatch{}, Deadline: time.Now().Add(-time.Second)}
+	summary, err := RunSummary(sn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Clusters != 0 || len(summary.Skipped) != 3 {
+		t.Errorf("expected every cluster skipped past deadline but got %+v", summary)
+	}
+}
+
+func TestSummary_Err(t *testing.T) {
+	var summary *Summary
+	summary.fail("ignored by nil Summary")
+	if err := summary.Err(); err != nil {
+		t.Errorf("expected nil Summary to succeed but got %s", err)
+	}
+	summary = &Summary{}
+	summary.fail("first %s", "failure")
+	summary.fail("second failure")
+	if err := summary.Err(); err == nil || err.Error() != "first failure, and 1 more failures" {
+		t.Errorf("expected first failure and count but got %v", err)
+	}
+}
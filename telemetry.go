@@ -0,0 +1,64 @@
+package snitch
+
+import (
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// count has Summary count calls by c, including retries, and which were
+// throttled.
+func (sn *Snitcher) count(c *client.Client) {
+	c.Handlers.CompleteAttempt.PushBack(func(r *request.Request) {
+		sn.Summary.call(r.ClientInfo.ServiceName, request.IsErrorThrottle(r.Error))
+	})
+}
+
+// PublishTelemetry publishes snitch's own metrics about a run, which took
+// duration, as summary summarizes it, to TelemetryNamespace, so snitch itself
+// can be watched and alarmed on.
+func (sn *Snitcher) PublishTelemetry(summary *Summary, duration time.Duration) {
+	if summary == nil {
+		return
+	}
+	timestamp := aws.Time(time.Now())
+	datum := func(metricName string, value float64, dimensions ...*cloudwatch.Dimension) *cloudwatch.MetricDatum {
+		return &cloudwatch.MetricDatum{
+			MetricName: aws.String(metricName),
+			Dimensions: dimensions,
+			Timestamp:  timestamp,
+			Value:      aws.Float64(value),
+			Unit:       unit(metricName),
+		}
+	}
+	var metricData []*cloudwatch.MetricDatum
+	summary.update(func() {
+		metricData = []*cloudwatch.MetricDatum{
+			datum("RunDuration", duration.Seconds()),
+			datum("ClustersMeasured", float64(summary.Clusters)),
+			datum("MetricsPublished", float64(summary.Published)),
+			datum("PublishFailures", float64(summary.PublishFailures)),
+			datum("Failures", float64(len(summary.Failures))),
+			datum("ClustersThrottled", float64(len(summary.throttled))),
+		}
+		var services []string
+		for service := range summary.Calls {
+			services = append(services, service)
+		}
+		sort.Strings(services)
+		for _, service := range services {
+			dimension := &cloudwatch.Dimension{Name: aws.String("Service"), Value: aws.String(service)}
+			metricData = append(metricData,
+				datum("APICalls", float64(summary.Calls[service]), dimension),
+				datum("Throttles", float64(summary.Throttles[service]), dimension),
+			)
+		}
+	})
+	if err := sn.publishOwn(sn.TelemetryNamespace, metricData); err != nil {
+		sn.logger().Errorf("Failed to publish telemetry to %q: %s", aws.StringValue(sn.TelemetryNamespace), err)
+	}
+}
@@ -0,0 +1,75 @@
+package snitch
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+func TestSnitcher_count(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type": "ThrottlingException", "message": "Rate exceeded"}`))
+	}))
+	defer server.Close()
+	sess := session.Must(session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("fake", "fake", ""),
+		Endpoint:    aws.String(server.URL),
+		MaxRetries:  aws.Int(0),
+		Region:      aws.String("us-east-1"),
+	}))
+	client := ecs.New(sess)
+	sn := &Snitcher{Summary: &Summary{}}
+	sn.count(client.Client)
+	if _, err := client.ListClusters(&ecs.ListClustersInput{}); err == nil {
+		t.Fatal("expected throttling")
+	}
+	if sn.Summary.Calls["ecs"] != 1 || sn.Summary.Throttles["ecs"] != 1 {
+		t.Errorf("expected a throttled ECS call but got %v and %v", sn.Summary.Calls, sn.Summary.Throttles)
+	}
+}
+
+func TestSnitcher_PublishTelemetry(t *testing.T) {
+	fake := NewFakeECS(t)
+	fake.checkCluster = false
+	cloudWatch := &FakeCloudWatch{}
+	sn := &Snitcher{ECS: fake, CloudWatch: cloudWatch, Namespace: aws.String("Testable/Namespace"), ShouldPublish: aws.Bool(true), TelemetryNamespace: aws.String("Testable/Telemetry")}
+	if err := sn.run(); err != nil {
+		t.Fatal(err)
+	}
+	if sn.Summary != nil {
+		t.Error("expected run's own Summary forgotten after")
+	}
+	last := cloudWatch.payload[len(cloudWatch.payload)-1]
+	if aws.StringValue(last.Namespace) != "Testable/Telemetry" {
+		t.Fatalf("expected telemetry published last but got %s", last)
+	}
+	values := map[string]float64{}
+	for _, datum := range last.MetricData {
+		values[aws.StringValue(datum.MetricName)] = aws.Float64Value(datum.Value)
+		if LookupMetric(aws.StringValue(datum.MetricName)).Validate(datum) != nil {
+			t.Errorf("expected valid %s", datum)
+		}
+	}
+	if values["ClustersMeasured"] != 3 || values["MetricsPublished"] == 0 || values["PublishFailures"] != 0 {
+		t.Errorf("expected 3 clusters and metrics published but got %v", values)
+	}
+	if _, ok := values["RunDuration"]; !ok {
+		t.Errorf("expected RunDuration but got %v", values)
+	}
+
+	summary := &Summary{}
+	summary.call("ecs", true)
+	cloudWatch.payload = nil
+	sn.PublishTelemetry(summary, 0)
+	sn.PublishTelemetry(nil, 0)
+	if len(cloudWatch.payload) != 1 || len(cloudWatch.payload[0].MetricData) != 8 {
+		t.Errorf("expected one call's APICalls and Throttles besides run's metrics but got %v", cloudWatch.payload)
+	}
+}
@@ -0,0 +1,111 @@
+package snitch
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// Breach is a cluster's headroom, summed RemainingSchedulable, below its
+// threshold.
+type Breach struct {
+	Cluster    string            `json:"cluster"`
+	Dimensions map[string]string `json:"dimensions,omitempty"`
+	Metric     string            `json:"metric"`
+	Value      float64           `json:"value"`
+	Threshold  float64           `json:"threshold"`
+	// Metric's value per instance type, or partition, adding up to Value.
+	InstanceTypes map[string]float64 `json:"instanceTypes,omitempty"`
+}
+
+func (breach *Breach) String() string {
+	return fmt.Sprintf("%s %s %g below %g", breach.Cluster, breach.Metric, breach.Value, breach.Threshold)
+}
+
+// Breaches finds clusters whose RemainingSchedulable, summed across instance
+// types, is below their threshold.
+//
+// Thresholds are by cluster name, or by glob "prod-*" or regexp "/^prod-/"
+// like Include, the longest matching if the name isn't, so "*" is everyone
+// else's. Clusters matching none have no threshold.
+func Breaches(reports []*ClusterReport, thresholds map[string]float64) (breaches []*Breach) {
+	patterns := thresholdPatterns(thresholds)
+	for _, report := range reports {
+		threshold, ok := thresholdOf(thresholds, patterns, report.Cluster)
+		if !ok {
+			continue
+		}
+		remaining, byInstanceType := 0.0, map[string]float64{}
+		for instanceType, metrics := range report.InstanceTypes {
+			if value, ok := metrics["RemainingSchedulable"]; ok {
+				remaining += value
+				byInstanceType[instanceType] = value
+			}
+		}
+		if remaining < threshold {
+			breaches = append(breaches, &Breach{
+				Cluster:       report.Cluster,
+				Dimensions:    report.Dimensions,
+				Metric:        "RemainingSchedulable",
+				Value:         remaining,
+				Threshold:     threshold,
+				InstanceTypes: byInstanceType,
+			})
+		}
+	}
+	return
+}
+
+// thresholdPatterns are thresholds' cluster names and patterns, longest first.
+func thresholdPatterns(thresholds map[string]float64) (patterns []string) {
+	for pattern := range thresholds {
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) > len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+	return
+}
+
+// thresholdOf is cluster's threshold, by its name or else the first of
+// thresholdPatterns matching it, and whether it has one.
+func thresholdOf(thresholds map[string]float64, patterns []string, cluster string) (float64, bool) {
+	if threshold, ok := thresholds[cluster]; ok {
+		return threshold, true
+	}
+	for _, pattern := range patterns {
+		if matches(pattern, cluster) {
+			return thresholds[pattern], true
+		}
+	}
+	return 0, false
+}
+
+// ThresholdSink keeps Breaches of Thresholds by metrics it's sent, like for
+// exiting non-zero when a cluster runs low.
+type ThresholdSink struct {
+	Thresholds map[string]float64
+	mutex      sync.Mutex
+	breaches   []*Breach
+}
+
+// Send keeps breaches among metrics, adding to those kept before.
+func (sink *ThresholdSink) Send(metricData []*cloudwatch.MetricDatum) error {
+	breaches := Breaches(Report(metricData), sink.Thresholds)
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	sink.breaches = append(sink.breaches, breaches...)
+	return nil
+}
+
+// Breaches kept by Send.
+func (sink *ThresholdSink) Breaches() []*Breach {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	return sink.breaches
+}
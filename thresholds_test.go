@@ -0,0 +1,46 @@
+package snitch
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestBreaches(t *testing.T) {
+	var reports []*ClusterReport
+	for cluster, remaining := range map[string]int{"prod-api": 3, "prod-web": 3, "ci-builds": 0, "dev": 1} {
+		cr := NewClusterResources(aws.String(cluster))
+		cr.Remaining["fake.large"] = remaining
+		cr.Remaining["fake.xlarge"] = remaining
+		reports = append(reports, Report(cr.ToMetricData())...)
+	}
+	breaches := Breaches(Report(nil), map[string]float64{"*": 1})
+	if len(breaches) != 0 {
+		t.Errorf("expected no breaches of no reports but got %v", breaches)
+	}
+	breaches = Breaches(reports, map[string]float64{"prod-*": 10, "prod-web": 5, "/^ci-/": 0, "*": 1})
+	if len(breaches) != 1 || breaches[0].Cluster != "prod-api" || breaches[0].Value != 6 || breaches[0].Threshold != 10 {
+		t.Errorf("expected only prod-api's 6 below 10 but got %v", breaches)
+	}
+	if breaches[0].InstanceTypes["fake.large"] != 3 || breaches[0].InstanceTypes["fake.xlarge"] != 3 {
+		t.Errorf("expected 3 per instance type but got %v", breaches[0].InstanceTypes)
+	}
+	if breaches = Breaches(reports, map[string]float64{"*": 3}); len(breaches) != 2 {
+		t.Errorf("expected ci-builds and dev below 3 but got %v", breaches)
+	}
+}
+
+func TestThresholdSink(t *testing.T) {
+	sink := &ThresholdSink{Thresholds: map[string]float64{"*": 2}}
+	for cluster, remaining := range map[string]int{"roomy": 5, "cramped": 1} {
+		cr := NewClusterResources(aws.String(cluster))
+		cr.Remaining["fake.large"] = remaining
+		sn := &Snitcher{Sinks: []*SinkConfig{{Sink: sink}}}
+		if failed := sn.Send(cr.ToMetricData()); failed != 0 {
+			t.Fatalf("expected ThresholdSink not to fail but %d did", failed)
+		}
+	}
+	if breaches := sink.Breaches(); len(breaches) != 1 || breaches[0].Cluster != "cramped" {
+		t.Errorf("expected only cramped below 2 but got %v", breaches)
+	}
+}
@@ -0,0 +1,55 @@
+package snitch
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// defaultECSRetries is how many times throttled ECS calls are retried, unless
+// ECSRetries says otherwise.
+const defaultECSRetries = 5
+
+// defaultPublishRetries is how many times failed PutMetricData calls are
+// retried, unless PublishRetries says otherwise.
+const defaultPublishRetries = 3
+
+// retryer retries calls failing retryably, like throttled by
+// ThrottlingException or RequestLimitExceeded, or by 5xx responses, retries
+// times, or else defaultRetries, backing off exponentially, with jitter, up to
+// 30 seconds, from half a second when throttled.
+func retryer(retries *int, defaultRetries int) request.Retryer {
+	if retries == nil || *retries < 0 {
+		retries = &defaultRetries
+	}
+	return client.DefaultRetryer{
+		NumMaxRetries:    *retries,
+		MaxRetryDelay:    30 * time.Second,
+		MinThrottleDelay: 500 * time.Millisecond,
+		MaxThrottleDelay: 30 * time.Second,
+	}
+}
+
+// withECSRetryer is config retrying ECSRetries times.
+func (sn *Snitcher) withECSRetryer(config *aws.Config) *aws.Config {
+	return request.WithRetryer(config, retryer(sn.ECSRetries, defaultECSRetries))
+}
+
+// withPublishRetryer is config retrying PublishRetries times.
+func (sn *Snitcher) withPublishRetryer(config *aws.Config) *aws.Config {
+	return request.WithRetryer(config, retryer(sn.PublishRetries, defaultPublishRetries))
+}
+
+// throttled tells whether any of a is an error of AWS throttling calls, even
+// after retrying them.
+func throttled(a ...interface{}) bool {
+	for _, arg := range a {
+		if err, ok := arg.(awserr.Error); ok && request.IsErrorThrottle(err) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package snitch
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+func TestRetryer(t *testing.T) {
+	tests := []struct {
+		retries  *int
+		expected int
+	}{
+		{nil, defaultECSRetries},
+		{aws.Int(-1), defaultECSRetries},
+		{aws.Int(0), 0},
+		{aws.Int(10), 10},
+	}
+	for _, test := range tests {
+		retryer := retryer(test.retries, defaultECSRetries).(client.DefaultRetryer)
+		if retryer.MaxRetries() != test.expected || retryer.MinThrottleDelay == 0 {
+			t.Errorf("expected %d retries backing off but got %+v", test.expected, retryer)
+		}
+	}
+}
+
+// ThrottledECS throttles ListContainerInstances of cluster.
+type ThrottledECS struct {
+	*FakeECS
+	cluster string
+}
+
+func (fake *ThrottledECS) ListContainerInstances(input *ecs.ListContainerInstancesInput) (*ecs.ListContainerInstancesOutput, error) {
+	if aws.StringValue(input.Cluster) == fake.cluster {
+		return nil, awserr.New("ThrottlingException", "Rate exceeded", nil)
+	}
+	return fake.FakeECS.ListContainerInstances(input)
+}
+
+func TestSnitcher_MeasureThrottled(t *testing.T) {
+	fake := &ThrottledECS{FakeECS: NewFakeECS(t), cluster: "fake-ecs-cluster"}
+	fake.checkCluster = false
+	for _, summary := range []*Summary{nil, {}} {
+		sn := &Snitcher{ECS: fake, Summary: summary}
+		for _, datum := range sn.Measure() {
+			for _, dimension := range datum.Dimensions {
+				if aws.StringValue(dimension.Value) == fake.cluster {
+					t.Fatalf("expected throttled cluster not measured but got %s", datum)
+				}
+			}
+		}
+		if summary == nil {
+			continue
+		}
+		for _, result := range summary.Results {
+			if (result.Cluster == fake.cluster) != (result.Status == "throttled") {
+				t.Errorf("expected only %q throttled but got %+v", fake.cluster, result)
+			}
+		}
+	}
+}
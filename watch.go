@@ -0,0 +1,57 @@
+package snitch
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// ANSI escapes coloring Watch's remaining slots.
+const (
+	colorRed    = "\033[31m"
+	colorYellow = "\033[33m"
+	colorGreen  = "\033[32m"
+	colorReset  = "\033[0m"
+)
+
+// WatchColors colors remaining slots at or below Critical red, at or below
+// Warning yellow and otherwise green. Watch with nil WatchColors doesn't color.
+type WatchColors struct {
+	Warning  float64
+	Critical float64
+}
+
+// color wraps remaining in the escape for its threshold.
+func (colors *WatchColors) color(remaining float64) string {
+	text := fmt.Sprintf("%.0f", remaining)
+	switch {
+	case colors == nil:
+		return text
+	case remaining <= colors.Critical:
+		return colorRed + text + colorReset
+	case remaining <= colors.Warning:
+		return colorYellow + text + colorReset
+	}
+	return colorGreen + text + colorReset
+}
+
+// Watch writes a table of each cluster's instance types, lowest common
+// multiple container size and registered and remaining slots, then the
+// cluster's totals across instance types, for `snitch watch`.
+func Watch(w io.Writer, reports []*ClusterReport, colors *WatchColors) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "CLUSTER\tINSTANCE TYPE\tLCM CPU\tLCM MEMORY\tREGISTERED\tREMAINING")
+	for _, report := range reports {
+		var registered, remaining float64
+		for _, instanceType := range sortedKeys(report.InstanceTypes) {
+			metrics := report.InstanceTypes[instanceType]
+			registered += metrics["RegisteredSchedulable"]
+			remaining += metrics["RemainingSchedulable"]
+			fmt.Fprintf(tw, "%s\t%s\t%.0f\t%.0f\t%.0f\t%s\n", report.Key(), instanceType,
+				metrics["LowestCommonMultipleCPU"], metrics["LowestCommonMultipleMemory"],
+				metrics["RegisteredSchedulable"], colors.color(metrics["RemainingSchedulable"]))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t\t\t%.0f\t%s\n", report.Key(), "(total)", registered, colors.color(remaining))
+	}
+	tw.Flush()
+}
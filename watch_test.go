@@ -0,0 +1,43 @@
+package snitch
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestWatch(t *testing.T) {
+	cr := NewClusterResources(aws.String("fake-cluster"))
+	cr.CPU["fake.large"], cr.Memory["fake.large"] = 256, 512
+	cr.Registered["fake.large"], cr.Remaining["fake.large"] = 8, 2
+	cr.CPU["fake.small"], cr.Memory["fake.small"] = 256, 512
+	cr.Registered["fake.small"], cr.Remaining["fake.small"] = 4, 4
+	reports := Report(cr.ToMetricData())
+
+	var buffer bytes.Buffer
+	Watch(&buffer, reports, nil)
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected header, 2 instance types and total but got:\n%s", buffer.String())
+	}
+	if fields := strings.Fields(lines[1]); strings.Join(fields, " ") != "fake-cluster fake.large 256 512 8 2" {
+		t.Errorf("expected fake.large's row but got %q", lines[1])
+	}
+	if fields := strings.Fields(lines[3]); strings.Join(fields, " ") != "fake-cluster (total) 12 6" {
+		t.Errorf("expected totals but got %q", lines[3])
+	}
+	if strings.Contains(buffer.String(), "\033[") {
+		t.Error("expected no color without WatchColors")
+	}
+
+	buffer.Reset()
+	Watch(&buffer, reports, &WatchColors{Warning: 4, Critical: 2})
+	lines = strings.Split(buffer.String(), "\n")
+	for i, color := range []string{colorRed, colorYellow, colorGreen} {
+		if !strings.Contains(lines[i+1], color) {
+			t.Errorf("expected row %d colored %q but got %q", i+1, color, lines[i+1])
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package snitch
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// WebhookSink POSTs each run's ClusterReports as a JSON list to URL. If
+// SigningKey is set, header "X-Snitch-Signature" is "sha256=" and the hex
+// HMAC-SHA256 of the body, so receivers can verify it came from snitch.
+type WebhookSink struct {
+	URL        string
+	SigningKey string
+	// How many times to retry, waiting Backoff, then twice as long each
+	// time, when the request fails or the response is 429 or 5xx.
+	Retries int
+	Backoff time.Duration
+	Client  *http.Client
+	// Logger logs retries, or else the standard library's log package does.
+	Logger Logger
+}
+
+// Sign figures out the "X-Snitch-Signature" of body with key.
+func Sign(key string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send metrics to the webhook, retrying if need be.
+func (sink *WebhookSink) Send(metricData []*cloudwatch.MetricDatum) error {
+	if sink.URL == "" {
+		return errors.New("no webhook URL")
+	}
+	body, err := json.Marshal(Report(metricData))
+	if err != nil {
+		return err
+	}
+	backoff := sink.Backoff
+	for attempt := 0; ; attempt++ {
+		retryable, err := sink.post(body)
+		if err == nil || !retryable || attempt >= sink.Retries {
+			return err
+		}
+		sink.logger().Warnf("Failed to POST to webhook (attempt %d of %d); retrying in %s: %s", attempt+1, sink.Retries+1, backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (sink *WebhookSink) logger() Logger {
+	if sink.Logger != nil {
+		return sink.Logger
+	}
+	return defaultLogger
+}
+
+// post body once, telling whether it's worth retrying if it fails.
+func (sink *WebhookSink) post(body []byte) (retryable bool, err error) {
+	request, err := http.NewRequest("POST", sink.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if sink.SigningKey != "" {
+		request.Header.Set("X-Snitch-Signature", Sign(sink.SigningKey, body))
+	}
+	response, err := sink.Client.Do(request)
+	if err != nil {
+		return true, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		return response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500, fmt.Errorf("webhook responded %s", response.Status)
+	}
+	return false, nil
+}
@@ -0,0 +1,63 @@
+package snitch
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestWebhookSink(t *testing.T) {
+	attempts := 0
+	var reports []*ClusterReport
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get("X-Snitch-Signature") != Sign("fake-key", body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if err := json.Unmarshal(body, &reports); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+	sink := &WebhookSink{URL: server.URL, SigningKey: "fake-key", Retries: 1, Client: http.DefaultClient}
+	cr := NewClusterResources(aws.String("fake-cluster"))
+	cr.Remaining["fake.large"] = 2
+	if err := sink.Send(cr.ToMetricData()); err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 2 || len(reports) != 1 || reports[0].Cluster != "fake-cluster" {
+		t.Errorf("expected fake-cluster's report on the second attempt but got %v after %d", reports, attempts)
+	}
+	sink.SigningKey = "wrong-key"
+	if err := sink.Send(cr.ToMetricData()); err == nil || attempts != 3 {
+		t.Errorf("expected unauthorized not retried but got %v after %d attempts", err, attempts)
+	}
+}
+
+func TestSign(t *testing.T) {
+	// Like Python's hmac.new(b"key", b"body", "sha256").hexdigest()
+	expected := "sha256=515aae133b435d4000956731f68ae5cf5eb85d4f0dc6a546d2bfcd3595ec1ae1"
+	if actual := Sign("key", []byte("body")); actual != expected {
+		t.Errorf("expected %q but got %q", expected, actual)
+	}
+}
+
+func TestWebhookSinkUnresolvedSecret(t *testing.T) {
+	sn := &Snitcher{SecretsManager: &FakeSecretsManager{}}
+	sink := SinkTypes["webhook"](sn, &SinkConfig{URL: "http://localhost", Secret: "snitch/webhook"})
+	cr := NewClusterResources(aws.String("fake-cluster"))
+	cr.Remaining["fake.large"] = 2
+	if err := sink.Send(cr.ToMetricData()); err == nil {
+		t.Error("expected an error rather than posting unsigned")
+	}
+}
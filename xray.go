@@ -0,0 +1,135 @@
+//go:build xray
+// +build xray
+
+package snitch
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+)
+
+// instrument has AWS X-Ray trace calls by c, if Trace is set.
+func (sn *Snitcher) instrument(c *client.Client) {
+	if aws.BoolValue(sn.Trace) {
+		xray.AWS(c)
+	}
+}
+
+// trace runs fn in a subsegment, named name, of Context's, if Trace is set,
+// with a Snitcher calling ECS, EC2 and CloudWatch under it. Otherwise, it
+// just runs fn.
+func (sn *Snitcher) trace(name string, fn func(sn *Snitcher) error) error {
+	if !aws.BoolValue(sn.Trace) {
+		return fn(sn)
+	}
+	ctx := sn.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, segment := xray.BeginSubsegment(ctx, name)
+	err := fn(sn.traced(ctx))
+	segment.Close(err)
+	return err
+}
+
+// traced copies Snitcher to call ECS, EC2 and CloudWatch with ctx, which AWS
+// X-Ray needs to trace the calls under ctx's segment.
+func (sn *Snitcher) traced(ctx context.Context) *Snitcher {
+	traced := *sn
+	traced.Context = ctx
+	if c, ok := sn.ECS.(*tracedECS); ok {
+		traced.ECS = &tracedECS{c.ECSAPI, ctx}
+	} else if sn.ECS != nil {
+		traced.ECS = &tracedECS{sn.ECS, ctx}
+	}
+	if c, ok := sn.EC2.(*tracedEC2); ok {
+		traced.EC2 = &tracedEC2{c.EC2API, ctx}
+	} else if sn.EC2 != nil {
+		traced.EC2 = &tracedEC2{sn.EC2, ctx}
+	}
+	if c, ok := sn.CloudWatch.(*tracedCloudWatch); ok {
+		traced.CloudWatch = &tracedCloudWatch{c.CloudWatchAPI, ctx}
+	} else if sn.CloudWatch != nil {
+		traced.CloudWatch = &tracedCloudWatch{sn.CloudWatch, ctx}
+	}
+	return &traced
+}
+
+// tracedECS calls ECS with ctx, for the calls snitch makes.
+type tracedECS struct {
+	ecsiface.ECSAPI
+	ctx context.Context
+}
+
+func (t *tracedECS) DescribeClusters(input *ecs.DescribeClustersInput) (*ecs.DescribeClustersOutput, error) {
+	return t.DescribeClustersWithContext(t.ctx, input)
+}
+
+func (t *tracedECS) DescribeContainerInstances(input *ecs.DescribeContainerInstancesInput) (*ecs.DescribeContainerInstancesOutput, error) {
+	return t.DescribeContainerInstancesWithContext(t.ctx, input)
+}
+
+func (t *tracedECS) DescribeServices(input *ecs.DescribeServicesInput) (*ecs.DescribeServicesOutput, error) {
+	return t.DescribeServicesWithContext(t.ctx, input)
+}
+
+func (t *tracedECS) DescribeTaskDefinition(input *ecs.DescribeTaskDefinitionInput) (*ecs.DescribeTaskDefinitionOutput, error) {
+	return t.DescribeTaskDefinitionWithContext(t.ctx, input)
+}
+
+func (t *tracedECS) DescribeTasks(input *ecs.DescribeTasksInput) (*ecs.DescribeTasksOutput, error) {
+	return t.DescribeTasksWithContext(t.ctx, input)
+}
+
+func (t *tracedECS) ListClustersPages(input *ecs.ListClustersInput, fn func(*ecs.ListClustersOutput, bool) bool) error {
+	return t.ListClustersPagesWithContext(t.ctx, input, fn)
+}
+
+func (t *tracedECS) ListContainerInstances(input *ecs.ListContainerInstancesInput) (*ecs.ListContainerInstancesOutput, error) {
+	return t.ListContainerInstancesWithContext(t.ctx, input)
+}
+
+func (t *tracedECS) ListTasksPages(input *ecs.ListTasksInput, fn func(*ecs.ListTasksOutput, bool) bool) error {
+	return t.ListTasksPagesWithContext(t.ctx, input, fn)
+}
+
+// tracedEC2 calls EC2 with ctx, for the calls snitch makes.
+type tracedEC2 struct {
+	ec2iface.EC2API
+	ctx context.Context
+}
+
+func (t *tracedEC2) DescribeInstanceTypesPages(input *ec2.DescribeInstanceTypesInput, fn func(*ec2.DescribeInstanceTypesOutput, bool) bool) error {
+	return t.DescribeInstanceTypesPagesWithContext(t.ctx, input, fn)
+}
+
+func (t *tracedEC2) DescribeInstancesPages(input *ec2.DescribeInstancesInput, fn func(*ec2.DescribeInstancesOutput, bool) bool) error {
+	return t.DescribeInstancesPagesWithContext(t.ctx, input, fn)
+}
+
+func (t *tracedEC2) DescribeRegions(input *ec2.DescribeRegionsInput) (*ec2.DescribeRegionsOutput, error) {
+	return t.DescribeRegionsWithContext(t.ctx, input)
+}
+
+func (t *tracedEC2) DescribeSubnetsPages(input *ec2.DescribeSubnetsInput, fn func(*ec2.DescribeSubnetsOutput, bool) bool) error {
+	return t.DescribeSubnetsPagesWithContext(t.ctx, input, fn)
+}
+
+// tracedCloudWatch calls CloudWatch with ctx, for the calls snitch makes.
+type tracedCloudWatch struct {
+	cloudwatchiface.CloudWatchAPI
+	ctx context.Context
+}
+
+func (t *tracedCloudWatch) PutMetricData(input *cloudwatch.PutMetricDataInput) (*cloudwatch.PutMetricDataOutput, error) {
+	return t.PutMetricDataWithContext(t.ctx, input)
+}
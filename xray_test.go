@@ -0,0 +1,67 @@
+//go:build xray
+// +build xray
+
+package snitch
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+)
+
+// ContextECS mocks ECS, remembering the context it's called with.
+type ContextECS struct {
+	ecsiface.ECSAPI
+	ctx context.Context
+}
+
+func (fake *ContextECS) DescribeClustersWithContext(ctx aws.Context, input *ecs.DescribeClustersInput, options ...request.Option) (*ecs.DescribeClustersOutput, error) {
+	fake.ctx = ctx
+	return &ecs.DescribeClustersOutput{}, nil
+}
+
+func TestSnitcher_traced(t *testing.T) {
+	fake := &ContextECS{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	traced := (&Snitcher{ECS: fake}).traced(ctx)
+	traced = traced.traced(ctx) // Not wrapped twice.
+	if _, err := traced.ECS.DescribeClusters(&ecs.DescribeClustersInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if fake.ctx != ctx || traced.Context != ctx {
+		t.Errorf("expected ECS called with context but got %v", fake.ctx)
+	}
+	if traced.EC2 != nil || traced.CloudWatch != nil {
+		t.Error("expected missing clients to stay missing")
+	}
+}
+
+func TestSnitcher_trace(t *testing.T) {
+	sn := &Snitcher{}
+	sn.trace("untraced", func(traced *Snitcher) error {
+		if traced != sn {
+			t.Error("expected Snitcher as is without Trace")
+		}
+		return nil
+	})
+
+	ctx, segment := xray.BeginSegment(context.Background(), "test")
+	defer segment.Close(nil)
+	sn = &Snitcher{Trace: aws.Bool(true), Context: ctx, ECS: &ContextECS{}}
+	err := sn.trace("fake-cluster", func(traced *Snitcher) error {
+		if subsegment := xray.GetSegment(traced.Context); subsegment == nil || subsegment.Name != "fake-cluster" {
+			t.Errorf("expected fake-cluster subsegment but got %v", subsegment)
+		}
+		return errors.New("fake")
+	})
+	if err == nil {
+		t.Error("expected fn's error")
+	}
+}